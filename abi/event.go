@@ -6,6 +6,10 @@
 package abi
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
 	ethabi "github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/vechain/thor/v2/thor"
 )
@@ -50,3 +54,75 @@ func (e *Event) Encode(args ...interface{}) ([]byte, error) {
 func (e *Event) Decode(data []byte, v interface{}) error {
 	return e.argsWithoutIndexed.Unpack(v, data)
 }
+
+// Signature returns the canonical signature of the event, e.g. Transfer(address,address,uint256).
+func (e *Event) Signature() string {
+	types := make([]string, len(e.event.Inputs))
+	for i, input := range e.event.Inputs {
+		types[i] = input.Type.String()
+	}
+	return fmt.Sprintf("%v(%v)", e.event.Name, strings.Join(types, ","))
+}
+
+// LogArg is a decoded input of an event log.
+type LogArg struct {
+	Name    string
+	Type    string
+	Indexed bool
+	Value   interface{}
+}
+
+// DecodeLog decodes all inputs of the event log in the declared order, from topics and data.
+// Indexed inputs of dynamic types are stored as hashes, so their values are the raw topics.
+// An error returned if the log doesn't match the event.
+func (e *Event) DecodeLog(topics []thor.Bytes32, data []byte) (args []*LogArg, err error) {
+	// the decoder doesn't fully validate malformed data
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("decode log: %v", e)
+		}
+	}()
+
+	if !e.event.Anonymous {
+		if len(topics) == 0 || topics[0] != e.id {
+			return nil, errors.New("event id mismatch")
+		}
+		topics = topics[1:]
+	}
+	if len(topics) != len(e.event.Inputs)-len(e.argsWithoutIndexed) {
+		return nil, errors.New("indexed inputs mismatch")
+	}
+
+	values, err := e.argsWithoutIndexed.UnpackValues(data)
+	if err != nil {
+		return nil, err
+	}
+	args = make([]*LogArg, 0, len(e.event.Inputs))
+	for _, input := range e.event.Inputs {
+		arg := &LogArg{
+			Name:    input.Name,
+			Type:    input.Type.String(),
+			Indexed: input.Indexed,
+		}
+		if input.Indexed {
+			topic := topics[0]
+			topics = topics[1:]
+			switch input.Type.T {
+			case ethabi.StringTy, ethabi.BytesTy, ethabi.SliceTy, ethabi.ArrayTy:
+				arg.Value = topic
+			default:
+				// static types are encoded in a word, as in data
+				v, err := ethabi.Arguments{{Type: input.Type}}.UnpackValues(topic[:])
+				if err != nil {
+					return nil, err
+				}
+				arg.Value = v[0]
+			}
+		} else {
+			arg.Value = values[0]
+			values = values[1:]
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
@@ -0,0 +1,371 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+// Package registry keeps contract ABIs registered by node operators, to decode event logs served by the API.
+//
+// An ABI is registered by name. If the name is a contract address, the ABI applies to events of that contract only,
+// otherwise it's generic and applies to events of any contract, e.g. an ERC-20 ABI.
+package registry
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/pkg/errors"
+	"github.com/vechain/thor/v2/abi"
+	"github.com/vechain/thor/v2/log"
+	"github.com/vechain/thor/v2/thor"
+)
+
+var (
+	logger = log.WithContext("pkg", "abiregistry")
+
+	nameRegexp = regexp.MustCompile(`^[0-9A-Za-z_\-][0-9A-Za-z_\-.]{0,127}$`)
+)
+
+// fileExt is the extension of ABI files in the registry directory.
+const fileExt = ".json"
+
+// tmpExt is the extension of temp files written before renamed to ABI files.
+const tmpExt = ".tmp"
+
+// Entry describes a registered ABI.
+type Entry struct {
+	Name    string        `json:"name"`
+	Address *thor.Address `json:"address"` // nil for generic ABIs
+	Events  []string      `json:"events"`  // event signatures
+}
+
+// Param is a decoded event param.
+type Param struct {
+	Name    string      `json:"name"`
+	Type    string      `json:"type"`
+	Indexed bool        `json:"indexed"`
+	Value   interface{} `json:"value"` // the topic if it's an indexed param of dynamic type
+}
+
+// DecodedEvent is an event log decoded with a registered ABI.
+type DecodedEvent struct {
+	Name   string   `json:"name"`
+	Params []*Param `json:"params"`
+}
+
+type entry struct {
+	name    string
+	address *thor.Address
+	abi     *abi.ABI
+}
+
+// Registry is the ABI registry. If it's backed by a directory, ABIs are loaded from and saved to
+// files named <name>.json in the directory.
+type Registry struct {
+	dir     string
+	lock    sync.RWMutex
+	entries map[string]*entry
+	byAddr  map[thor.Address]*entry
+	generic []*entry // sorted by name
+}
+
+// New creates a registry and loads ABI files in the dir. The registry is in memory only if the dir is empty.
+func New(dir string) (*Registry, error) {
+	r := &Registry{
+		dir:     dir,
+		entries: make(map[string]*entry),
+		byAddr:  make(map[thor.Address]*entry),
+	}
+	if dir == "" {
+		return r, nil
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "read abi dir")
+	}
+	for _, f := range files {
+		// temp files are left by failed writes
+		if !f.IsDir() && strings.HasSuffix(f.Name(), fileExt+tmpExt) {
+			removeTemp(filepath.Join(dir, f.Name()))
+			continue
+		}
+		if f.IsDir() || filepath.Ext(f.Name()) != fileExt {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimSuffix(f.Name(), fileExt)
+		if err := r.add(name, data); err != nil {
+			return nil, errors.WithMessage(err, "load abi file "+f.Name())
+		}
+	}
+	logger.Info("ABIs loaded", "dir", dir, "count", len(r.entries))
+	return r, nil
+}
+
+// removeTemp removes the temp file, which is removed again when the registry is loaded if it fails.
+func removeTemp(path string) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		logger.Warn("failed to remove temp file", "path", path, "err", err)
+	}
+}
+
+// add parses and adds the ABI, replacing the one with the same name.
+func (r *Registry) add(name string, data []byte) error {
+	e, err := r.parse(name, data)
+	if err != nil {
+		return err
+	}
+	r.put(e)
+	return nil
+}
+
+// parse parses the ABI into an entry, which is checked against registered ABIs but not added.
+func (r *Registry) parse(name string, data []byte) (*entry, error) {
+	if !nameRegexp.MatchString(name) {
+		return nil, fmt.Errorf("invalid name %q", name)
+	}
+	a, err := abi.New(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse abi")
+	}
+	e := &entry{name: name, abi: a}
+	if addr, err := thor.ParseAddress(name); err == nil {
+		if existing, ok := r.byAddr[addr]; ok && existing.name != name {
+			return nil, fmt.Errorf("address already registered by %q", existing.name)
+		}
+		e.address = &addr
+	}
+	return e, nil
+}
+
+// put adds the entry, replacing the one with the same name.
+func (r *Registry) put(e *entry) {
+	r.entries[e.name] = e
+	r.reindex()
+}
+
+func (r *Registry) reindex() {
+	r.byAddr = make(map[thor.Address]*entry)
+	r.generic = r.generic[:0]
+	for _, e := range r.entries {
+		if e.address != nil {
+			r.byAddr[*e.address] = e
+		} else {
+			r.generic = append(r.generic, e)
+		}
+	}
+	sort.Slice(r.generic, func(i, j int) bool { return r.generic[i].name < r.generic[j].name })
+}
+
+// Register registers the ABI in JSON under the name, replacing the existing one.
+func (r *Registry) Register(name string, data []byte) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	e, err := r.parse(name, data)
+	if err != nil {
+		return err
+	}
+	// the file is written before the entry is added, so that they are consistent on failure
+	if r.dir != "" {
+		// write to a temp file first to not leave a broken file on failure
+		path := filepath.Join(r.dir, name+fileExt)
+		tmp := path + tmpExt
+		if err := os.WriteFile(tmp, data, 0600); err != nil {
+			removeTemp(tmp)
+			return errors.Wrap(err, "write abi file")
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			removeTemp(tmp)
+			return errors.Wrap(err, "write abi file")
+		}
+	}
+	r.put(e)
+	return nil
+}
+
+// Unregister removes the ABI of the name. It returns false if the name is not registered.
+func (r *Registry) Unregister(name string) (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.entries[name]; !ok {
+		return false, nil
+	}
+	if r.dir != "" {
+		if err := os.Remove(filepath.Join(r.dir, name+fileExt)); err != nil && !os.IsNotExist(err) {
+			return false, errors.Wrap(err, "remove abi file")
+		}
+	}
+	delete(r.entries, name)
+	r.reindex()
+	return true, nil
+}
+
+// Entries returns all registered ABIs sorted by name.
+func (r *Registry) Entries() []*Entry {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	entries := make([]*Entry, 0, len(r.entries))
+	for _, e := range r.entries {
+		entries = append(entries, e.info())
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+// Entry returns the registered ABI of the name, or nil if not found.
+func (r *Registry) Entry(name string) *Entry {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if e, ok := r.entries[name]; ok {
+		return e.info()
+	}
+	return nil
+}
+
+func (e *entry) info() *Entry {
+	events := make([]string, 0, len(e.abi.Events()))
+	for _, ev := range e.abi.Events() {
+		events = append(events, ev.Signature())
+	}
+	return &Entry{
+		Name:    e.name,
+		Address: e.address,
+		Events:  events,
+	}
+}
+
+// candidates returns the ABIs applied to events of the address, the contract one first.
+func (r *Registry) candidates(address *thor.Address) []*entry {
+	var candidates []*entry
+	if address != nil {
+		if e, ok := r.byAddr[*address]; ok {
+			candidates = append(candidates, e)
+		}
+	}
+	return append(candidates, r.generic...)
+}
+
+// DecodeEvent decodes the event log emitted by the address. It returns nil if no registered ABI matches the log.
+// A nil registry decodes nothing.
+func (r *Registry) DecodeEvent(address thor.Address, topics []thor.Bytes32, data []byte) *DecodedEvent {
+	if r == nil || len(topics) == 0 {
+		return nil
+	}
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for _, e := range r.candidates(&address) {
+		ev, ok := e.abi.EventByID(topics[0])
+		if !ok {
+			continue
+		}
+		// events with the same signature may differ in indexed params, e.g. ERC-20 and ERC-721 Transfer
+		args, err := ev.DecodeLog(topics, data)
+		if err != nil {
+			continue
+		}
+		decoded := &DecodedEvent{Name: ev.Name(), Params: make([]*Param, 0, len(args))}
+		for _, arg := range args {
+			decoded.Params = append(decoded.Params, &Param{
+				Name:    arg.Name,
+				Type:    arg.Type,
+				Indexed: arg.Indexed,
+				Value:   jsonValue(arg.Type, arg.Value),
+			})
+		}
+		return decoded
+	}
+	return nil
+}
+
+// EventID resolves the event to its id (topic0). The event is either a signature like Transfer(address,address,uint256),
+// which is hashed directly, or a name which is looked up in ABIs applied to the address, or in all generic ABIs if the
+// address is nil. A nil registry resolves signatures only.
+func (r *Registry) EventID(address *thor.Address, event string) (thor.Bytes32, error) {
+	if strings.Contains(event, "(") {
+		return thor.Keccak256([]byte(strings.Join(strings.Fields(event), ""))), nil
+	}
+	if r == nil {
+		return thor.Bytes32{}, fmt.Errorf("event %q not found in registered ABIs", event)
+	}
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	var ids []thor.Bytes32
+	for _, e := range r.candidates(address) {
+		if ev, ok := e.abi.EventByName(event); ok {
+			if len(ids) > 0 && ids[0] != ev.ID() {
+				return thor.Bytes32{}, fmt.Errorf("ambiguous event name %q, use the event signature instead", event)
+			}
+			ids = append(ids, ev.ID())
+		}
+	}
+	if len(ids) == 0 {
+		return thor.Bytes32{}, fmt.Errorf("event %q not found in registered ABIs", event)
+	}
+	return ids[0], nil
+}
+
+// jsonValue converts the decoded value of the abi type to the form of the API, where integers and bytes are hex
+// encoded.
+func jsonValue(typ string, v interface{}) interface{} {
+	switch v := v.(type) {
+	case thor.Bytes32:
+		// indexed param of dynamic type
+		return v
+	case *big.Int:
+		return (*math.HexOrDecimal256)(v)
+	case common.Address:
+		// Address marshals itself with a pointer receiver
+		addr := thor.Address(v)
+		return &addr
+	case common.Hash:
+		return thor.Bytes32(v)
+	case string, bool:
+		return v
+	}
+
+	rv := reflect.ValueOf(v)
+	if i := strings.LastIndex(typ, "["); i > 0 && strings.HasSuffix(typ, "]") {
+		if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			list := make([]interface{}, rv.Len())
+			for j := range list {
+				list[j] = jsonValue(typ[:i], rv.Index(j).Interface())
+			}
+			return list
+		}
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return (*math.HexOrDecimal256)(big.NewInt(rv.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return (*math.HexOrDecimal256)(new(big.Int).SetUint64(rv.Uint()))
+	case reflect.Slice, reflect.Array:
+		// bytes or bytesN
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return hexutil.Bytes(b)
+		}
+	}
+	return v
+}
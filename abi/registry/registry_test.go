@@ -0,0 +1,187 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package registry
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/stretchr/testify/assert"
+	"github.com/vechain/thor/v2/thor"
+)
+
+const (
+	erc20ABI = `[
+	{"type":"event","name":"Transfer","anonymous":false,"inputs":[
+		{"indexed":true,"name":"from","type":"address"},
+		{"indexed":true,"name":"to","type":"address"},
+		{"indexed":false,"name":"value","type":"uint256"}]},
+	{"type":"event","name":"Approval","anonymous":false,"inputs":[
+		{"indexed":true,"name":"owner","type":"address"},
+		{"indexed":true,"name":"spender","type":"address"},
+		{"indexed":false,"name":"value","type":"uint256"}]}
+]`
+	erc721ABI = `[
+	{"type":"event","name":"Transfer","anonymous":false,"inputs":[
+		{"indexed":true,"name":"from","type":"address"},
+		{"indexed":true,"name":"to","type":"address"},
+		{"indexed":true,"name":"tokenId","type":"uint256"}]}
+]`
+	noteABI = `[
+	{"type":"event","name":"Note","anonymous":false,"inputs":[
+		{"indexed":true,"name":"tag","type":"string"},
+		{"indexed":false,"name":"id","type":"bytes4"},
+		{"indexed":false,"name":"data","type":"bytes"},
+		{"indexed":false,"name":"nums","type":"uint8[]"}]}
+]`
+)
+
+var transferID = thor.Keccak256([]byte("Transfer(address,address,uint256)"))
+
+func addressTopic(addr thor.Address) thor.Bytes32 {
+	return thor.BytesToBytes32(addr.Bytes())
+}
+
+func TestDecodeEvent(t *testing.T) {
+	r, err := New("")
+	assert.Nil(t, err)
+
+	from := thor.BytesToAddress([]byte("from"))
+	to := thor.BytesToAddress([]byte("to"))
+	token := thor.BytesToAddress([]byte("token"))
+
+	// not registered
+	topics := []thor.Bytes32{transferID, addressTopic(from), addressTopic(to)}
+	data := math.PaddedBigBytes(big.NewInt(100), 32)
+	assert.Nil(t, r.DecodeEvent(token, topics, data))
+
+	assert.Nil(t, r.Register("erc20", []byte(erc20ABI)))
+	assert.Nil(t, r.Register("erc721", []byte(erc721ABI)))
+
+	decoded := r.DecodeEvent(token, topics, data)
+	assert.Equal(t, "Transfer", decoded.Name)
+	js, _ := json.Marshal(decoded.Params)
+	assert.JSONEq(t, `[
+		{"name":"from","type":"address","indexed":true,"value":"`+from.String()+`"},
+		{"name":"to","type":"address","indexed":true,"value":"`+to.String()+`"},
+		{"name":"value","type":"uint256","indexed":false,"value":"0x64"}
+	]`, string(js))
+
+	// the erc721 one matches the indexed token id
+	decoded = r.DecodeEvent(token, append(topics, thor.BytesToBytes32([]byte{1})), nil)
+	assert.Equal(t, "tokenId", decoded.Params[2].Name)
+	assert.True(t, decoded.Params[2].Indexed)
+
+	// malformed data
+	assert.Nil(t, r.DecodeEvent(token, topics, []byte{1}))
+
+	// indexed dynamic types are hashes
+	note := thor.BytesToAddress([]byte("note"))
+	assert.Nil(t, r.Register(note.String(), []byte(noteABI)))
+	tag := thor.Keccak256([]byte("tag"))
+	data = make([]byte, 32)
+	copy(data, []byte{1, 2, 3, 4})
+	data = append(data, math.PaddedBigBytes(big.NewInt(96), 32)...)
+	data = append(data, math.PaddedBigBytes(big.NewInt(160), 32)...)
+	data = append(data, math.PaddedBigBytes(big.NewInt(2), 32)...)
+	data = append(data, common.RightPadBytes([]byte{0xab, 0xcd}, 32)...)
+	data = append(data, math.PaddedBigBytes(big.NewInt(2), 32)...)
+	data = append(data, math.PaddedBigBytes(big.NewInt(7), 32)...)
+	data = append(data, math.PaddedBigBytes(big.NewInt(8), 32)...)
+	decoded = r.DecodeEvent(note, []thor.Bytes32{thor.Keccak256([]byte("Note(string,bytes4,bytes,uint8[])")), tag}, data)
+	js, _ = json.Marshal(decoded)
+	assert.JSONEq(t, `{"name":"Note","params":[
+		{"name":"tag","type":"string","indexed":true,"value":"`+tag.String()+`"},
+		{"name":"id","type":"bytes4","indexed":false,"value":"0x01020304"},
+		{"name":"data","type":"bytes","indexed":false,"value":"0xabcd"},
+		{"name":"nums","type":"uint8[]","indexed":false,"value":["0x7","0x8"]}
+	]}`, string(js))
+	// the contract ABI applies to the contract only
+	assert.Nil(t, r.DecodeEvent(token, []thor.Bytes32{thor.Keccak256([]byte("Note(string,bytes4,bytes,uint8[])")), tag}, data))
+}
+
+func TestEventID(t *testing.T) {
+	r, err := New("")
+	assert.Nil(t, err)
+
+	id, err := r.EventID(nil, "Transfer(address, address, uint256)")
+	assert.Nil(t, err)
+	assert.Equal(t, transferID, id)
+
+	_, err = r.EventID(nil, "Transfer")
+	assert.EqualError(t, err, `event "Transfer" not found in registered ABIs`)
+
+	assert.Nil(t, r.Register("erc20", []byte(erc20ABI)))
+	id, err = r.EventID(nil, "Approval")
+	assert.Nil(t, err)
+	assert.Equal(t, thor.Keccak256([]byte("Approval(address,address,uint256)")), id)
+
+	// the same signature in different ABIs
+	assert.Nil(t, r.Register("erc721", []byte(erc721ABI)))
+	id, err = r.EventID(nil, "Transfer")
+	assert.Nil(t, err)
+	assert.Equal(t, transferID, id)
+
+	// the contract ABI is searched first
+	note := thor.BytesToAddress([]byte("note"))
+	assert.Nil(t, r.Register(note.String(), []byte(`[{"type":"event","name":"Transfer","inputs":[{"name":"to","type":"address"}]}]`)))
+	_, err = r.EventID(&note, "Transfer")
+	assert.EqualError(t, err, `ambiguous event name "Transfer", use the event signature instead`)
+	id, err = r.EventID(nil, "Transfer")
+	assert.Nil(t, err)
+	assert.Equal(t, transferID, id)
+}
+
+func TestRegistryDir(t *testing.T) {
+	dir := t.TempDir()
+	addr := thor.BytesToAddress([]byte("addr"))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "erc20.json"), []byte(erc20ABI), 0600))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("ignored"), 0600))
+
+	r, err := New(dir)
+	assert.Nil(t, err)
+	assert.Nil(t, r.Register(addr.String(), []byte(erc721ABI)))
+	assert.NotNil(t, r.Register("../erc721", []byte(erc721ABI)))
+	assert.NotNil(t, r.Register("bad", []byte(`{}`)))
+
+	// a failed write leaves the registry unchanged
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "erc721.json.tmp"), 0700))
+	assert.NotNil(t, r.Register("erc721", []byte(erc721ABI)))
+	assert.Len(t, r.Entries(), 2)
+	// and the temp file is removed
+	_, err = os.Stat(filepath.Join(dir, "erc721.json.tmp"))
+	assert.True(t, os.IsNotExist(err))
+
+	// reloaded from the dir, with stale temp files removed
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "stale.json.tmp"), []byte(`[`), 0600))
+	r, err = New(dir)
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(dir, "stale.json.tmp"))
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, []*Entry{
+		{Name: addr.String(), Address: &addr, Events: []string{"Transfer(address,address,uint256)"}},
+		{Name: "erc20", Events: []string{"Transfer(address,address,uint256)", "Approval(address,address,uint256)"}},
+	}, r.Entries())
+
+	removed, err := r.Unregister("erc20")
+	assert.Nil(t, err)
+	assert.True(t, removed)
+	removed, err = r.Unregister("erc20")
+	assert.Nil(t, err)
+	assert.False(t, removed)
+	_, err = os.Stat(filepath.Join(dir, "erc20.json"))
+	assert.True(t, os.IsNotExist(err))
+
+	// invalid files fail the loading
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "bad.json"), []byte(`{}`), 0600))
+	_, err = New(dir)
+	assert.NotNil(t, err)
+}
@@ -6,13 +6,32 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"io"
 	"log/slog"
+	"math"
 	"net/http"
+	"path/filepath"
 
+	"github.com/gorilla/mux"
+	"github.com/vechain/thor/v2/abi/registry"
+	"github.com/vechain/thor/v2/block"
 	"github.com/vechain/thor/v2/log"
+	"github.com/vechain/thor/v2/logdb"
+	"github.com/vechain/thor/v2/logdb/logexport"
+	"github.com/vechain/thor/v2/thor"
 )
 
+// BackupFunc backs up databases consistently into the given directory, and returns the best block ID of the backup.
+type BackupFunc func(ctx context.Context, dir string) (thor.Bytes32, error)
+
+// maxABISize is the max size of ABIs registered through the admin API.
+const maxABISize = 4 * 1024 * 1024
+
+// LogExportFunc exports logs specified by opts into a new file at path, and returns the number of exported logs.
+type LogExportFunc func(ctx context.Context, opts *logexport.Options, path string) (uint64, error)
+
 type logLevelRequest struct {
 	Level string `json:"level"`
 }
@@ -21,6 +40,30 @@ type logLevelResponse struct {
 	CurrentLevel string `json:"currentLevel"`
 }
 
+type backupRequest struct {
+	Dir string `json:"dir"`
+}
+
+type backupResponse struct {
+	Dir         string       `json:"dir"`
+	BlockID     thor.Bytes32 `json:"blockID"`
+	BlockNumber uint32       `json:"blockNumber"`
+}
+
+type logExportRequest struct {
+	Kind        logexport.Kind   `json:"kind"`
+	Format      logexport.Format `json:"format"`
+	From        uint32           `json:"from"`
+	To          *uint32          `json:"to"` // the newest block if not set
+	CriteriaSet json.RawMessage  `json:"criteriaSet"`
+	File        string           `json:"file"`
+}
+
+type logExportResponse struct {
+	File  string `json:"file"`
+	Count uint64 `json:"count"`
+}
+
 type errorResponse struct {
 	ErrorMessage string `json:"errorMessage"`
 }
@@ -78,3 +121,132 @@ func postLogLevelHandler(logLevel *slog.LevelVar) http.HandlerFunc {
 		json.NewEncoder(w).Encode(response)
 	}
 }
+
+func postBackupHandler(backup BackupFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req backupRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		// the directory is on the node side, relative paths are ambiguous
+		if !filepath.IsAbs(req.Dir) {
+			writeError(w, http.StatusBadRequest, "Backup directory must be an absolute path")
+			return
+		}
+
+		blockID, err := backup(r.Context(), req.Dir)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to backup: "+err.Error())
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(backupResponse{
+			Dir:         req.Dir,
+			BlockID:     blockID,
+			BlockNumber: block.Number(blockID),
+		})
+	}
+}
+
+// options converts the request into export options. The criteria set is in the same form as the
+// one of the events or transfers API, according to the kind.
+func (req *logExportRequest) options() (*logexport.Options, error) {
+	opts := &logexport.Options{
+		Kind:   req.Kind,
+		Format: req.Format,
+		Range:  &logdb.Range{From: req.From, To: math.MaxUint32},
+	}
+	if req.To != nil {
+		opts.Range.To = *req.To
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if len(req.CriteriaSet) > 0 {
+		if err := opts.UnmarshalCriteriaSet(req.CriteriaSet); err != nil {
+			return nil, err
+		}
+	}
+	return opts, nil
+}
+
+func getABIsHandler(abis *registry.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(abis.Entries())
+	}
+}
+
+func putABIHandler(abis *registry.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["name"]
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxABISize))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if err := abis.Register(name, data); err != nil {
+			writeError(w, http.StatusBadRequest, "Failed to register ABI: "+err.Error())
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(abis.Entry(name))
+	}
+}
+
+func deleteABIHandler(abis *registry.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		removed, err := abis.Unregister(mux.Vars(r)["name"])
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to unregister ABI: "+err.Error())
+			return
+		}
+		if !removed {
+			writeError(w, http.StatusNotFound, "ABI not found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func postLogExportHandler(exportLogs LogExportFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req logExportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		opts, err := req.options()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+			return
+		}
+		if opts.Range.To < opts.Range.From {
+			writeError(w, http.StatusBadRequest, "Invalid block range")
+			return
+		}
+		// the file is on the node side, relative paths are ambiguous
+		if !filepath.IsAbs(req.File) {
+			writeError(w, http.StatusBadRequest, "Export file must be an absolute path")
+			return
+		}
+
+		count, err := exportLogs(r.Context(), opts, req.File)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to export logs: "+err.Error())
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(logExportResponse{
+			File:  req.File,
+			Count: count,
+		})
+	}
+}
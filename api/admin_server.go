@@ -14,10 +14,11 @@ import (
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
+	"github.com/vechain/thor/v2/abi/registry"
 	"github.com/vechain/thor/v2/co"
 )
 
-func HTTPHandler(logLevel *slog.LevelVar) http.Handler {
+func HTTPHandler(logLevel *slog.LevelVar, backup BackupFunc, exportLogs LogExportFunc, abis *registry.Registry) http.Handler {
 	router := mux.NewRouter()
 	sub := router.PathPrefix("/admin").Subrouter()
 	sub.Path("/loglevel").
@@ -30,17 +31,46 @@ func HTTPHandler(logLevel *slog.LevelVar) http.Handler {
 		Name("post-log-level").
 		HandlerFunc(postLogLevelHandler(logLevel))
 
+	if backup != nil {
+		sub.Path("/backup").
+			Methods(http.MethodPost).
+			Name("post-backup").
+			HandlerFunc(postBackupHandler(backup))
+	}
+
+	if exportLogs != nil {
+		sub.Path("/logs/export").
+			Methods(http.MethodPost).
+			Name("post-logs-export").
+			HandlerFunc(postLogExportHandler(exportLogs))
+	}
+
+	if abis != nil {
+		sub.Path("/abis").
+			Methods(http.MethodGet).
+			Name("get-abis").
+			HandlerFunc(getABIsHandler(abis))
+		sub.Path("/abis/{name}").
+			Methods(http.MethodPut).
+			Name("put-abi").
+			HandlerFunc(putABIHandler(abis))
+		sub.Path("/abis/{name}").
+			Methods(http.MethodDelete).
+			Name("delete-abi").
+			HandlerFunc(deleteABIHandler(abis))
+	}
+
 	return handlers.CompressHandler(router)
 }
 
-func StartAdminServer(addr string, logLevel *slog.LevelVar) (string, func(), error) {
+func StartAdminServer(addr string, logLevel *slog.LevelVar, backup BackupFunc, exportLogs LogExportFunc, abis *registry.Registry) (string, func(), error) {
 	listener, err := net.Listen("tcp", addr)
 	if err != nil {
 		return "", nil, errors.Wrapf(err, "listen admin API addr [%v]", addr)
 	}
 
 	router := mux.NewRouter()
-	router.PathPrefix("/admin").Handler(HTTPHandler(logLevel))
+	router.PathPrefix("/admin").Handler(HTTPHandler(logLevel, backup, exportLogs, abis))
 	handler := handlers.CompressHandler(router)
 
 	srv := &http.Server{Handler: handler, ReadHeaderTimeout: time.Second, ReadTimeout: 5 * time.Second}
@@ -7,11 +7,20 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vechain/thor/v2/abi/registry"
+	"github.com/vechain/thor/v2/logdb"
+	"github.com/vechain/thor/v2/logdb/logexport"
+	"github.com/vechain/thor/v2/thor"
 )
 
 type TestCase struct {
@@ -73,7 +82,7 @@ func TestLogLevelHandler(t *testing.T) {
 			}
 
 			rr := httptest.NewRecorder()
-			handler := http.HandlerFunc(HTTPHandler(&logLevel).ServeHTTP)
+			handler := http.HandlerFunc(HTTPHandler(&logLevel, nil, nil, nil).ServeHTTP)
 			handler.ServeHTTP(rr, req)
 
 			if status := rr.Code; status != tt.expectedStatus {
@@ -100,3 +109,180 @@ func TestLogLevelHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestBackupHandler(t *testing.T) {
+	blockID := thor.MustParseBytes32("0x0000000a00000000000000000000000000000000000000000000000000000000")
+	backup := func(ctx context.Context, dir string) (thor.Bytes32, error) {
+		if dir == "/fail" {
+			return thor.Bytes32{}, errors.New("disk full")
+		}
+		return blockID, nil
+	}
+
+	tests := []struct {
+		name             string
+		body             string
+		expectedStatus   int
+		expectedErrorMsg string
+	}{
+		{"valid", `{"dir":"/backup"}`, http.StatusOK, ""},
+		{"invalid body", `{"dir":`, http.StatusBadRequest, "Invalid request body"},
+		{"relative dir", `{"dir":"backup"}`, http.StatusBadRequest, "Backup directory must be an absolute path"},
+		{"failed", `{"dir":"/fail"}`, http.StatusInternalServerError, "Failed to backup: disk full"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logLevel slog.LevelVar
+			req, err := http.NewRequest(http.MethodPost, "/admin/backup", bytes.NewBufferString(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			HTTPHandler(&logLevel, backup, nil, nil).ServeHTTP(rr, req)
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+
+			if tt.expectedErrorMsg != "" {
+				var response errorResponse
+				assert.Nil(t, json.NewDecoder(rr.Body).Decode(&response))
+				assert.Equal(t, tt.expectedErrorMsg, response.ErrorMessage)
+			} else {
+				var response backupResponse
+				assert.Nil(t, json.NewDecoder(rr.Body).Decode(&response))
+				assert.Equal(t, backupResponse{Dir: "/backup", BlockID: blockID, BlockNumber: 10}, response)
+			}
+		})
+	}
+
+	// not mounted without backup func
+	var logLevel slog.LevelVar
+	req, _ := http.NewRequest(http.MethodPost, "/admin/backup", bytes.NewBufferString(`{"dir":"/backup"}`))
+	rr := httptest.NewRecorder()
+	HTTPHandler(&logLevel, nil, nil, nil).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestLogExportHandler(t *testing.T) {
+	var got *logexport.Options
+	exportLogs := func(ctx context.Context, opts *logexport.Options, path string) (uint64, error) {
+		if path == "/fail" {
+			return 0, errors.New("disk full")
+		}
+		got = opts
+		return 3, nil
+	}
+
+	addr := thor.MustParseAddress("0x7567d83b7b8d80addcb281a71d54fc7b3364ffed")
+	topic := thor.MustParseBytes32("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+	to := uint32(100)
+
+	tests := []struct {
+		name             string
+		body             string
+		expectedStatus   int
+		expectedErrorMsg string
+		expectedOptions  *logexport.Options
+	}{
+		{
+			"events",
+			`{"kind":"events","format":"csv","from":10,"to":100,"file":"/events.csv","criteriaSet":[{"address":"` + addr.String() + `","topic0":"` + topic.String() + `"}]}`,
+			http.StatusOK,
+			"",
+			&logexport.Options{
+				Kind:          logexport.Events,
+				Format:        logexport.CSV,
+				Range:         &logdb.Range{From: 10, To: to},
+				EventCriteria: []*logdb.EventCriteria{{Address: &addr, Topics: [5]*thor.Bytes32{&topic}}},
+			},
+		},
+		{
+			"transfers",
+			`{"kind":"transfers","format":"parquet","file":"/transfers.parquet","criteriaSet":[{"sender":"` + addr.String() + `"}]}`,
+			http.StatusOK,
+			"",
+			&logexport.Options{
+				Kind:             logexport.Transfers,
+				Format:           logexport.Parquet,
+				Range:            &logdb.Range{From: 0, To: math.MaxUint32},
+				TransferCriteria: []*logdb.TransferCriteria{{Sender: &addr}},
+			},
+		},
+		{"invalid body", `{"kind":`, http.StatusBadRequest, "Invalid request body", nil},
+		{"invalid kind", `{"kind":"txs","format":"csv","file":"/txs.csv"}`, http.StatusBadRequest, `Invalid request body: unsupported kind "txs"`, nil},
+		{"invalid format", `{"kind":"events","format":"json","file":"/events.json"}`, http.StatusBadRequest, `Invalid request body: unsupported format "json"`, nil},
+		{"invalid range", `{"kind":"events","format":"csv","from":10,"to":1,"file":"/events.csv"}`, http.StatusBadRequest, "Invalid block range", nil},
+		{"relative file", `{"kind":"events","format":"csv","file":"events.csv"}`, http.StatusBadRequest, "Export file must be an absolute path", nil},
+		{"failed", `{"kind":"events","format":"csv","file":"/fail"}`, http.StatusInternalServerError, "Failed to export logs: disk full", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logLevel slog.LevelVar
+			req, err := http.NewRequest(http.MethodPost, "/admin/logs/export", bytes.NewBufferString(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got = nil
+			rr := httptest.NewRecorder()
+			HTTPHandler(&logLevel, nil, exportLogs, nil).ServeHTTP(rr, req)
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+
+			if tt.expectedErrorMsg != "" {
+				var response errorResponse
+				assert.Nil(t, json.NewDecoder(rr.Body).Decode(&response))
+				assert.Equal(t, tt.expectedErrorMsg, response.ErrorMessage)
+			} else {
+				var response logExportResponse
+				assert.Nil(t, json.NewDecoder(rr.Body).Decode(&response))
+				assert.Equal(t, uint64(3), response.Count)
+				assert.Equal(t, tt.expectedOptions, got)
+			}
+		})
+	}
+}
+
+func TestABIHandlers(t *testing.T) {
+	var logLevel slog.LevelVar
+	abis, err := registry.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := HTTPHandler(&logLevel, nil, nil, abis)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do(http.MethodPut, "/admin/abis/erc20", `[{"type":"event","name":"Transfer","inputs":[{"indexed":true,"name":"from","type":"address"},{"indexed":true,"name":"to","type":"address"},{"name":"value","type":"uint256"}]}]`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var entry registry.Entry
+	assert.Nil(t, json.NewDecoder(rr.Body).Decode(&entry))
+	assert.Equal(t, registry.Entry{Name: "erc20", Events: []string{"Transfer(address,address,uint256)"}}, entry)
+
+	rr = do(http.MethodPut, "/admin/abis/bad", `{}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = do(http.MethodGet, "/admin/abis", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var entries []*registry.Entry
+	assert.Nil(t, json.NewDecoder(rr.Body).Decode(&entries))
+	assert.Equal(t, []*registry.Entry{&entry}, entries)
+
+	rr = do(http.MethodDelete, "/admin/abis/erc20", "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	rr = do(http.MethodDelete, "/admin/abis/erc20", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// not mounted without registry
+	req, _ := http.NewRequest(http.MethodGet, "/admin/abis", nil)
+	rr = httptest.NewRecorder()
+	HTTPHandler(&logLevel, nil, nil, nil).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
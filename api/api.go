@@ -12,21 +12,26 @@ import (
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
+	"github.com/vechain/thor/v2/abi/registry"
 	"github.com/vechain/thor/v2/api/accounts"
 	"github.com/vechain/thor/v2/api/blocks"
 	"github.com/vechain/thor/v2/api/debug"
 	"github.com/vechain/thor/v2/api/doc"
 	"github.com/vechain/thor/v2/api/events"
+	"github.com/vechain/thor/v2/api/internalcalls"
 	"github.com/vechain/thor/v2/api/node"
 	"github.com/vechain/thor/v2/api/subscriptions"
+	"github.com/vechain/thor/v2/api/tokentransfers"
 	"github.com/vechain/thor/v2/api/transactions"
 	"github.com/vechain/thor/v2/api/transfers"
 	"github.com/vechain/thor/v2/bft"
 	"github.com/vechain/thor/v2/chain"
+	"github.com/vechain/thor/v2/kv"
 	"github.com/vechain/thor/v2/log"
 	"github.com/vechain/thor/v2/logdb"
 	"github.com/vechain/thor/v2/state"
 	"github.com/vechain/thor/v2/thor"
+	"github.com/vechain/thor/v2/tracers"
 	"github.com/vechain/thor/v2/txpool"
 )
 
@@ -38,6 +43,7 @@ func New(
 	stater *state.Stater,
 	txPool *txpool.TxPool,
 	logDB *logdb.LogDB,
+	abis *registry.Registry,
 	bft bft.Committer,
 	nw node.Network,
 	forkConfig thor.ForkConfig,
@@ -50,7 +56,11 @@ func New(
 	enableReqLogger bool,
 	enableMetrics bool,
 	logsLimit uint64,
+	logsStreamLimit uint64,
 	allowedTracers map[string]interface{},
+	tracerLimits tracers.Limits,
+	traceCacheSize int,
+	traceCacheStore kv.Store,
 	soloMode bool,
 ) (http.HandlerFunc, func()) {
 	origins := strings.Split(strings.TrimSpace(allowedOrigins), ",")
@@ -74,21 +84,28 @@ func New(
 	accounts.New(repo, stater, callGasLimit, forkConfig, bft).
 		Mount(router, "/accounts")
 
+	// the transactions filter relies on logdb as well
+	var txLogDB *logdb.LogDB
 	if !skipLogs {
-		events.New(repo, logDB, logsLimit).
+		events.New(repo, logDB, logsLimit, logsStreamLimit, abis).
 			Mount(router, "/logs/event")
-		transfers.New(repo, logDB, logsLimit).
+		transfers.New(repo, logDB, logsLimit, logsStreamLimit).
 			Mount(router, "/logs/transfer")
+		tokentransfers.New(repo, logDB, logsLimit, logsStreamLimit).
+			Mount(router, "/logs/token-transfer")
+		internalcalls.New(repo, logDB, logsLimit, logsStreamLimit).
+			Mount(router, "/logs/internal-call")
+		txLogDB = logDB
 	}
 	blocks.New(repo, bft).
 		Mount(router, "/blocks")
-	transactions.New(repo, txPool).
+	transactions.New(repo, txPool, txLogDB, logsLimit).
 		Mount(router, "/transactions")
-	debug.New(repo, stater, forkConfig, callGasLimit, allowCustomTracer, bft, allowedTracers, soloMode).
+	debug.New(repo, stater, forkConfig, callGasLimit, allowCustomTracer, bft, allowedTracers, tracerLimits, traceCacheSize, traceCacheStore, soloMode).
 		Mount(router, "/debug")
 	node.New(nw).
 		Mount(router, "/node")
-	subs := subscriptions.New(repo, origins, backtraceLimit, txPool)
+	subs := subscriptions.New(repo, origins, backtraceLimit, txPool, abis)
 	subs.Mount(router, "/subscriptions")
 
 	if pprofOn {
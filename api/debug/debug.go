@@ -6,6 +6,7 @@
 package debug
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -16,14 +17,17 @@ import (
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethmath "github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
 	"github.com/vechain/thor/v2/api/utils"
 	"github.com/vechain/thor/v2/bft"
 	"github.com/vechain/thor/v2/block"
 	"github.com/vechain/thor/v2/chain"
 	"github.com/vechain/thor/v2/consensus"
+	"github.com/vechain/thor/v2/kv"
 	"github.com/vechain/thor/v2/muxdb"
 	"github.com/vechain/thor/v2/runtime"
 	"github.com/vechain/thor/v2/state"
@@ -44,8 +48,12 @@ type Debug struct {
 	callGasLimit      uint64
 	allowCustomTracer bool
 	allowedTracers    map[string]interface{}
+	tracerLimits      tracers.Limits
+	traceCache        *traceCache
 	bft               bft.Committer
 	skipPoA           bool
+
+	correctReceiptsRoots map[string]string
 }
 
 func New(
@@ -56,6 +64,9 @@ func New(
 	allowCustomTracer bool,
 	bft bft.Committer,
 	allowedTracers map[string]interface{},
+	tracerLimits tracers.Limits,
+	traceCacheSize int,
+	traceCacheStore kv.Store,
 	soloMode bool) *Debug {
 	return &Debug{
 		repo,
@@ -64,8 +75,11 @@ func New(
 		callGaslimit,
 		allowCustomTracer,
 		allowedTracers,
+		tracerLimits,
+		newTraceCache(traceCacheSize, traceCacheStore),
 		bft,
 		soloMode,
+		thor.LoadCorrectReceiptsRoots(),
 	}
 }
 
@@ -125,7 +139,7 @@ func (d *Debug) prepareClauseEnv(ctx context.Context, blockID thor.Bytes32, txIn
 }
 
 // trace an existed clause
-func (d *Debug) traceClause(ctx context.Context, tracer tracers.Tracer, blockID thor.Bytes32, txIndex uint64, clauseIndex uint32) (interface{}, error) {
+func (d *Debug) traceClause(ctx context.Context, tracer tracers.Tracer, blockID thor.Bytes32, txIndex uint64, clauseIndex uint32) (json.RawMessage, error) {
 	rt, txExec, txID, err := d.prepareClauseEnv(ctx, blockID, txIndex, clauseIndex)
 	if err != nil {
 		return nil, err
@@ -140,11 +154,21 @@ func (d *Debug) traceClause(ctx context.Context, tracer tracers.Tracer, blockID
 		State:       rt.State(),
 	})
 	rt.SetVMConfig(vm.Config{Tracer: tracer})
-	errCh := make(chan error, 1)
 	exec, interrupt := txExec.PrepareNext()
-	go func() {
+	if err := execTraced(ctx, tracer, func() error {
 		_, _, err := exec()
-		errCh <- err
+		return err
+	}, interrupt); err != nil {
+		return nil, err
+	}
+	return tracerResult(tracer)
+}
+
+// execTraced runs the exec, and stops the tracer and interrupts the execution if the ctx is done.
+func execTraced(ctx context.Context, tracer tracers.Tracer, exec func() error, interrupt func()) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- exec()
 	}()
 
 	select {
@@ -152,13 +176,110 @@ func (d *Debug) traceClause(ctx context.Context, tracer tracers.Tracer, blockID
 		err := ctx.Err()
 		tracer.Stop(err)
 		interrupt()
-		return nil, err
+		return err
 	case err := <-errCh:
+		return err
+	}
+}
+
+// traceBlock replays the block and traces every clause of the tx at txIndex, or of all txs if txIndex is negative.
+// Each clause is traced by a new tracer, and traced txs are finalized to report the gas payment and reward.
+func (d *Debug) traceBlock(ctx context.Context, name string, config json.RawMessage, blk *block.Block, txIndex int) ([]*TxTraceResult, error) {
+	rt, err := consensus.New(
+		d.repo,
+		d.stater,
+		d.forkConfig,
+	).NewRuntimeForReplay(blk.Header(), d.skipPoA)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*TxTraceResult, 0)
+	for i, tx := range blk.Transactions() {
+		if txIndex >= 0 && i > txIndex {
+			break
+		}
+		traced := txIndex < 0 || i == txIndex
+
+		txExec, err := rt.PrepareTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+		result := &TxTraceResult{
+			TxID:    tx.ID(),
+			TxIndex: uint64(i),
+			Clauses: make([]*ClauseTraceResult, 0, len(tx.Clauses())),
+		}
+		var txTracer tracers.TxTracer
+		for clauseIndex := uint32(0); txExec.HasNextClause(); clauseIndex++ {
+			if !traced {
+				exec, _ := txExec.PrepareNext()
+				if _, _, err := exec(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			tracer, err := d.createTracer(name, config)
+			if err != nil {
+				return nil, utils.Forbidden(err)
+			}
+			tracer.SetContext(&tracers.Context{
+				BlockID:     blk.Header().ID(),
+				BlockTime:   blk.Header().Timestamp(),
+				TxID:        tx.ID(),
+				TxIndex:     uint64(i),
+				ClauseIndex: clauseIndex,
+				State:       rt.State(),
+			})
+			rt.SetVMConfig(vm.Config{Tracer: tracer})
+			exec, interrupt := txExec.PrepareNext()
+			err = execTraced(ctx, tracer, func() error {
+				_, _, err := exec()
+				return err
+			}, interrupt)
+			rt.SetVMConfig(vm.Config{})
+			if err != nil {
+				return nil, err
+			}
+			res, err := tracerResult(tracer)
+			if err != nil {
+				return nil, err
+			}
+			result.Clauses = append(result.Clauses, &ClauseTraceResult{ClauseIndex: clauseIndex, Result: res})
+			if t, ok := tracer.(tracers.TxTracer); ok {
+				txTracer = t
+			}
+		}
+		receipt, err := txExec.Finalize()
 		if err != nil {
 			return nil, err
 		}
+		if traced {
+			result.Reverted = receipt.Reverted
+			result.GasUsed = receipt.GasUsed
+			result.GasPayer = receipt.GasPayer
+			result.Paid = (*ethmath.HexOrDecimal256)(receipt.Paid)
+			result.Reward = (*ethmath.HexOrDecimal256)(receipt.Reward)
+			if txTracer != nil {
+				clauses := make([]json.RawMessage, 0, len(result.Clauses))
+				for _, c := range result.Clauses {
+					clauses = append(clauses, c.Result)
+				}
+				if result.Result, err = txTracer.TxResult(clauses, receipt, blk.Header().Beneficiary()); err != nil {
+					return nil, err
+				}
+			}
+			results = append(results, result)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
 	}
-	return tracer.GetResult()
+	return results, nil
 }
 
 func (d *Debug) handleTraceClause(w http.ResponseWriter, req *http.Request) error {
@@ -176,10 +297,435 @@ func (d *Debug) handleTraceClause(w http.ResponseWriter, req *http.Request) erro
 	if err != nil {
 		return err
 	}
+
+	// the traces of finalized blocks never change, while custom tracers are arbitrary code and not cached
+	var (
+		cacheKey []byte
+		stored   bool
+	)
+	if name, ok := tracers.DefaultDirectory.Lookup(opt.Name); ok && d.traceCache != nil {
+		finalized, err := d.isFinalized(blockID)
+		if err != nil {
+			return err
+		}
+		if finalized {
+			cacheKey = traceCacheKey(blockID, txIndex, clauseIndex, name, opt.Config)
+			stored = !usesJSTracer(name, opt.Config)
+			if res, ok := d.traceCache.Get(cacheKey, stored); ok {
+				return utils.WriteJSON(w, res)
+			}
+		}
+	}
+
 	res, err := d.traceClause(req.Context(), tracer, blockID, txIndex, clauseIndex)
 	if err != nil {
 		return err
 	}
+	if cacheKey != nil {
+		d.traceCache.Set(cacheKey, res, stored)
+	}
+	return utils.WriteJSON(w, res)
+}
+
+// isFinalized returns whether the block is on the finalized chain.
+func (d *Debug) isFinalized(blockID thor.Bytes32) (bool, error) {
+	finalized := d.bft.Finalized()
+	if block.Number(blockID) > block.Number(finalized) {
+		return false, nil
+	}
+	id, err := d.repo.NewChain(finalized).GetBlockID(block.Number(blockID))
+	if err != nil {
+		if d.repo.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return id == blockID, nil
+}
+
+// getTxBlock returns the block including the tx of the id on the best chain, and the index of the tx in the block.
+func (d *Debug) getTxBlock(id string) (*block.Block, int, error) {
+	txID, err := thor.ParseBytes32(id)
+	if err != nil {
+		return nil, 0, utils.BadRequest(errors.WithMessage(err, "id"))
+	}
+	txMeta, err := d.repo.NewBestChain().GetTransactionMeta(txID)
+	if err != nil {
+		if d.repo.IsNotFound(err) {
+			return nil, 0, utils.Forbidden(errors.New("transaction not found"))
+		}
+		return nil, 0, err
+	}
+	blk, err := d.repo.GetBlock(txMeta.BlockID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return blk, int(txMeta.Index), nil
+}
+
+// getRevisionBlock returns the block of the revision.
+func (d *Debug) getRevisionBlock(rev string) (*block.Block, error) {
+	revision, err := utils.ParseRevision(rev, false)
+	if err != nil {
+		return nil, utils.BadRequest(errors.WithMessage(err, "revision"))
+	}
+	summary, err := utils.GetSummary(revision, d.repo, d.bft)
+	if err != nil {
+		if d.repo.IsNotFound(err) {
+			return nil, utils.BadRequest(errors.WithMessage(err, "revision"))
+		}
+		return nil, err
+	}
+	return d.repo.GetBlock(summary.Header.ID())
+}
+
+func (d *Debug) handleTraceTransaction(w http.ResponseWriter, req *http.Request) error {
+	var opt TraceOption
+	if err := utils.ParseJSON(req.Body, &opt); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "body"))
+	}
+	// fail early before replaying the block
+	if _, err := d.createTracer(opt.Name, opt.Config); err != nil {
+		return utils.Forbidden(err)
+	}
+
+	blk, txIndex, err := d.getTxBlock(mux.Vars(req)["id"])
+	if err != nil {
+		return err
+	}
+	res, err := d.traceBlock(req.Context(), opt.Name, opt.Config, blk, txIndex)
+	if err != nil {
+		return err
+	}
+	return utils.WriteJSON(w, res[0])
+}
+
+func (d *Debug) handleTraceBlock(w http.ResponseWriter, req *http.Request) error {
+	var opt TraceOption
+	if err := utils.ParseJSON(req.Body, &opt); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "body"))
+	}
+	if _, err := d.createTracer(opt.Name, opt.Config); err != nil {
+		return utils.Forbidden(err)
+	}
+
+	blk, err := d.getRevisionBlock(mux.Vars(req)["revision"])
+	if err != nil {
+		return err
+	}
+	res, err := d.traceBlock(req.Context(), opt.Name, opt.Config, blk, -1)
+	if err != nil {
+		return err
+	}
+	return utils.WriteJSON(w, res)
+}
+
+// stateDiff replays the block and computes the state changes made by the tx at txIndex, or by the whole block if
+// txIndex is negative. Changes made outside of clause execution, e.g. the gas payment and the reward to the
+// beneficiary, are included.
+func (d *Debug) stateDiff(ctx context.Context, blk *block.Block, txIndex int) (*StateDiff, error) {
+	rt, err := consensus.New(
+		d.repo,
+		d.stater,
+		d.forkConfig,
+	).NewRuntimeForReplay(blk.Header(), d.skipPoA)
+	if err != nil {
+		return nil, err
+	}
+
+	st := rt.State()
+	checkpoint := 0
+	for i, tx := range blk.Transactions() {
+		if txIndex >= 0 && i > txIndex {
+			break
+		}
+		if i == txIndex {
+			checkpoint = st.NewCheckpoint()
+		}
+		if _, err := rt.ExecuteTransaction(tx); err != nil {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+	}
+
+	// accounts touched by previous txs are included, and will be filtered out if not changed
+	touched := st.Touched()
+	blockTime := blk.Header().Timestamp()
+	post, err := readAccounts(st, touched, blockTime)
+	if err != nil {
+		return nil, err
+	}
+
+	preState := st
+	if txIndex >= 0 {
+		st.RevertTo(checkpoint)
+	} else {
+		parent, err := d.repo.GetBlockSummary(blk.Header().ParentID())
+		if err != nil {
+			return nil, err
+		}
+		preState = d.stater.NewState(parent.Header.StateRoot(), parent.Header.Number(), parent.Conflicts, parent.SteadyNum)
+	}
+	pre, err := readAccounts(preState, touched, blockTime)
+	if err != nil {
+		return nil, err
+	}
+	return diffAccounts(pre, post), nil
+}
+
+// accountState is the state of an account read for computing state diff.
+type accountState struct {
+	balance *big.Int
+	energy  *big.Int
+	master  thor.Address
+	code    []byte
+	storage map[thor.Bytes32]rlp.RawValue
+	barrier int
+}
+
+func readAccounts(st *state.State, touched map[thor.Address][]thor.Bytes32, blockTime uint64) (map[thor.Address]*accountState, error) {
+	accounts := make(map[thor.Address]*accountState, len(touched))
+	for addr, keys := range touched {
+		var (
+			acc = accountState{storage: make(map[thor.Bytes32]rlp.RawValue, len(keys))}
+			err error
+		)
+		if acc.balance, err = st.GetBalance(addr); err != nil {
+			return nil, err
+		}
+		if acc.energy, err = st.GetEnergy(addr, blockTime); err != nil {
+			return nil, err
+		}
+		if acc.master, err = st.GetMaster(addr); err != nil {
+			return nil, err
+		}
+		if acc.code, err = st.GetCode(addr); err != nil {
+			return nil, err
+		}
+		acc.barrier = st.StorageBarrier(addr)
+		for _, key := range keys {
+			if acc.storage[key], err = st.GetRawStorage(addr, key); err != nil {
+				return nil, err
+			}
+		}
+		accounts[addr] = &acc
+	}
+	return accounts, nil
+}
+
+// diffAccounts computes the diff of accounts. Pre has all fields of changed accounts except unchanged storage,
+// and post has changed fields only.
+func diffAccounts(pre, post map[thor.Address]*accountState) *StateDiff {
+	diff := &StateDiff{
+		Pre:  make(map[string]*AccountDiff),
+		Post: make(map[string]*AccountDiff),
+	}
+	for addr, postAcc := range post {
+		var (
+			preAcc   = pre[addr]
+			preDiff  = &AccountDiff{Storage: make(map[string]string)}
+			postDiff = &AccountDiff{Storage: make(map[string]string)}
+			modified bool
+		)
+		if preAcc.balance.Cmp(postAcc.balance) != 0 {
+			modified = true
+			postDiff.Balance = (*ethmath.HexOrDecimal256)(postAcc.balance)
+		}
+		if preAcc.energy.Cmp(postAcc.energy) != 0 {
+			modified = true
+			postDiff.Energy = (*ethmath.HexOrDecimal256)(postAcc.energy)
+		}
+		if preAcc.master != postAcc.master {
+			modified = true
+			postDiff.Master = &postAcc.master
+		}
+		if !bytes.Equal(preAcc.code, postAcc.code) {
+			modified = true
+			postDiff.Code = postAcc.code
+		}
+		if preAcc.barrier != postAcc.barrier {
+			modified = true
+			postDiff.StorageReset = true
+		}
+		for key, value := range postAcc.storage {
+			if !bytes.Equal(preAcc.storage[key], value) {
+				modified = true
+				preDiff.Storage[key.String()] = storageValue(preAcc.storage[key])
+				postDiff.Storage[key.String()] = storageValue(value)
+			}
+		}
+		if !modified {
+			continue
+		}
+
+		preDiff.Balance = (*ethmath.HexOrDecimal256)(preAcc.balance)
+		preDiff.Energy = (*ethmath.HexOrDecimal256)(preAcc.energy)
+		if !preAcc.master.IsZero() {
+			preDiff.Master = &preAcc.master
+		}
+		preDiff.Code = preAcc.code
+		if len(preDiff.Storage) == 0 {
+			preDiff.Storage = nil
+			postDiff.Storage = nil
+		}
+		diff.Pre[addr.String()] = preDiff
+		diff.Post[addr.String()] = postDiff
+	}
+	return diff
+}
+
+// storageValue converts the raw storage value to 32 bytes in hex, or the raw RLP list in hex.
+func storageValue(raw rlp.RawValue) string {
+	if len(raw) == 0 {
+		return thor.Bytes32{}.String()
+	}
+	kind, content, _, err := rlp.Split(raw)
+	if err != nil || kind == rlp.List {
+		return hexutil.Encode(raw)
+	}
+	return thor.BytesToBytes32(content).String()
+}
+
+func (d *Debug) handleStateDiffTransaction(w http.ResponseWriter, req *http.Request) error {
+	blk, txIndex, err := d.getTxBlock(mux.Vars(req)["id"])
+	if err != nil {
+		return err
+	}
+	res, err := d.stateDiff(req.Context(), blk, txIndex)
+	if err != nil {
+		return err
+	}
+	return utils.WriteJSON(w, res)
+}
+
+func (d *Debug) handleStateDiffBlock(w http.ResponseWriter, req *http.Request) error {
+	blk, err := d.getRevisionBlock(mux.Vars(req)["revision"])
+	if err != nil {
+		return err
+	}
+	res, err := d.stateDiff(req.Context(), blk, -1)
+	if err != nil {
+		return err
+	}
+	return utils.WriteJSON(w, res)
+}
+
+// verifyBlock replays the block, and compares the receipts root, the state root and the receipts with the stored ones.
+// The state is staged to compute the root but never committed.
+func (d *Debug) verifyBlock(ctx context.Context, blk *block.Block) (*VerifyBlockResult, error) {
+	header := blk.Header()
+	if header.Number() == 0 {
+		return nil, utils.Forbidden(errors.New("genesis block can't be replayed"))
+	}
+	summary, err := d.repo.GetBlockSummary(header.ID())
+	if err != nil {
+		return nil, err
+	}
+	stored, err := d.repo.GetBlockReceipts(header.ID())
+	if err != nil && !d.repo.IsNotFound(err) {
+		return nil, err
+	}
+
+	rt, err := consensus.New(
+		d.repo,
+		d.stater,
+		d.forkConfig,
+	).NewRuntimeForReplay(header, d.skipPoA)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &VerifyBlockResult{
+		BlockID:  header.ID(),
+		Number:   header.Number(),
+		Receipts: make([]*ReceiptMismatch, 0),
+	}
+	receipts := make(tx.Receipts, 0, len(blk.Transactions()))
+	for i, trx := range blk.Transactions() {
+		receipt, err := rt.ExecuteTransaction(trx)
+		if err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, receipt)
+
+		var expected *tx.Receipt
+		if i < len(stored) {
+			expected = stored[i]
+		}
+		if !equalReceipts(expected, receipt) {
+			result.Receipts = append(result.Receipts, &ReceiptMismatch{
+				TxID:    trx.ID(),
+				TxIndex: uint64(i),
+				Diff:    jsonDiff(expected, receipt),
+			})
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+	}
+
+	receiptsRoot := receipts.RootHash()
+	result.ReceiptsRoot = &RootVerification{
+		Expected: header.ReceiptsRoot(),
+		Actual:   receiptsRoot,
+		// the receipts roots of a few blocks on mainnet are known to be incorrect
+		Match: header.ReceiptsRoot() == receiptsRoot ||
+			d.correctReceiptsRoots[header.ID().String()] == receiptsRoot.String(),
+	}
+
+	stage, err := rt.State().Stage(header.Number(), summary.Conflicts)
+	if err != nil {
+		return nil, err
+	}
+	stateRoot := stage.Hash()
+	result.StateRoot = &RootVerification{
+		Expected: header.StateRoot(),
+		Actual:   stateRoot,
+		Match:    header.StateRoot() == stateRoot,
+	}
+
+	result.Valid = result.ReceiptsRoot.Match && result.StateRoot.Match && len(result.Receipts) == 0
+	return result, nil
+}
+
+func equalReceipts(a, b *tx.Receipt) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	encA, errA := rlp.EncodeToBytes(a)
+	encB, errB := rlp.EncodeToBytes(b)
+	return errA == nil && errB == nil && bytes.Equal(encA, encB)
+}
+
+// jsonDiff returns the unified diff of the expected and actual values in JSON.
+func jsonDiff(expected, actual interface{}) string {
+	e, _ := json.MarshalIndent(expected, "", "  ")
+	a, _ := json.MarshalIndent(actual, "", "  ")
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(e)),
+		B:        difflib.SplitLines(string(a)),
+		FromFile: "Expected",
+		ToFile:   "Actual",
+		Context:  1,
+	})
+	return diff
+}
+
+func (d *Debug) handleVerifyBlock(w http.ResponseWriter, req *http.Request) error {
+	blk, err := d.getRevisionBlock(mux.Vars(req)["revision"])
+	if err != nil {
+		return err
+	}
+	res, err := d.verifyBlock(req.Context(), blk)
+	if err != nil {
+		return err
+	}
 	return utils.WriteJSON(w, res)
 }
 
@@ -209,6 +755,9 @@ func (d *Debug) handleTraceCall(w http.ResponseWriter, req *http.Request) error
 	if err != nil {
 		return err
 	}
+	if err := applyStateOverrides(st, opt.StateOverrides, summary.Header.Timestamp()); err != nil {
+		return err
+	}
 
 	res, err := d.traceCall(req.Context(), tracer, summary.Header, st, txCtx, gas, clause)
 	if err != nil {
@@ -218,19 +767,117 @@ func (d *Debug) handleTraceCall(w http.ResponseWriter, req *http.Request) error
 	return utils.WriteJSON(w, res)
 }
 
+// applyStateOverrides applies the account overrides to the state.
+func applyStateOverrides(st *state.State, overrides map[string]*AccountOverride, blockTime uint64) error {
+	for account, override := range overrides {
+		addr, err := thor.ParseAddress(account)
+		if err != nil {
+			return utils.BadRequest(errors.WithMessage(err, "stateOverrides"))
+		}
+		if override == nil {
+			continue
+		}
+		if override.Balance != nil {
+			if err := st.SetBalance(addr, (*big.Int)(override.Balance)); err != nil {
+				return err
+			}
+		}
+		if override.Energy != nil {
+			if err := st.SetEnergy(addr, (*big.Int)(override.Energy), blockTime); err != nil {
+				return err
+			}
+		}
+		if override.Code != nil {
+			if err := st.SetCode(addr, *override.Code); err != nil {
+				return err
+			}
+		}
+		for k, v := range override.Storage {
+			key, err := thor.ParseBytes32(k)
+			if err != nil {
+				return utils.BadRequest(errors.WithMessage(err, "stateOverrides["+account+"].storage"))
+			}
+			value, err := thor.ParseBytes32(v)
+			if err != nil {
+				return utils.BadRequest(errors.WithMessage(err, "stateOverrides["+account+"].storage["+k+"]"))
+			}
+			st.SetStorage(addr, key, value)
+		}
+	}
+	return nil
+}
+
 func (d *Debug) createTracer(name string, config json.RawMessage) (tracers.Tracer, error) {
 	if strings.TrimSpace(name) == "" {
 		return nil, fmt.Errorf("tracer name must be defined")
 	}
-	_, noTracers := d.allowedTracers["none"]
-	_, allTracers := d.allowedTracers["all"]
-
-	// fail if the requested tracer is not allowed OR if the "all" tracers code isn't active
-	if _, ok := d.allowedTracers[name]; noTracers || (!ok && !allTracers) {
+	if !d.isTracerAllowed(name) {
 		return nil, fmt.Errorf("creating tracer is not allowed: %s", name)
 	}
+	// the tracers run by the mux tracer are subject to the same restriction
+	if name == "mux" || name == "muxTracer" {
+		var subTracers map[string]json.RawMessage
+		if len(config) > 0 {
+			if err := json.Unmarshal(config, &subTracers); err != nil {
+				return nil, errors.Wrap(err, "mux tracer config")
+			}
+		}
+		for subName := range subTracers {
+			if !d.isTracerAllowed(subName) {
+				return nil, fmt.Errorf("creating tracer is not allowed: %s", subName)
+			}
+		}
+	}
 
-	return tracers.DefaultDirectory.New(name, config, d.allowCustomTracer)
+	tracer, err := tracers.DefaultDirectory.New(name, config, d.allowCustomTracer)
+	if err != nil {
+		return nil, err
+	}
+	if limited, ok := tracer.(tracers.LimitedTracer); ok {
+		limited.SetLimits(d.tracerLimits)
+	}
+	return tracer, nil
+}
+
+// usesJSTracer returns whether the tracer, or any tracer run by the mux tracer, is a JS tracer, whose code is loaded
+// at startup and may change between restarts.
+func usesJSTracer(name string, config json.RawMessage) bool {
+	if tracers.DefaultDirectory.IsJS(name) {
+		return true
+	}
+	if name != "muxTracer" {
+		return false
+	}
+	var subTracers map[string]json.RawMessage
+	if len(config) > 0 {
+		if err := json.Unmarshal(config, &subTracers); err != nil {
+			return true
+		}
+	}
+	for subName, subConfig := range subTracers {
+		if usesJSTracer(subName, subConfig) {
+			return true
+		}
+	}
+	return false
+}
+
+// tracerResult returns the result of the tracer, the tracer interrupted by the execution limits is forbidden.
+func tracerResult(tracer tracers.Tracer) (json.RawMessage, error) {
+	res, err := tracer.GetResult()
+	if _, ok := tracers.AsLimitError(err); ok {
+		return nil, utils.Forbidden(err)
+	}
+	return res, err
+}
+
+func (d *Debug) isTracerAllowed(name string) bool {
+	_, noTracers := d.allowedTracers["none"]
+	_, allTracers := d.allowedTracers["all"]
+
+	// not allowed if the requested tracer is not listed OR if the "all" tracers code isn't active
+	_, ok := d.allowedTracers[name]
+	return !noTracers && (ok || allTracers)
 }
 
 func (d *Debug) traceCall(ctx context.Context, tracer tracers.Tracer, header *block.Header, st *state.State, txCtx *xenv.TransactionContext, gas uint64, clause *tx.Clause) (interface{}, error) {
@@ -256,42 +903,118 @@ func (d *Debug) traceCall(ctx context.Context, tracer tracers.Tracer, header *bl
 	})
 	rt.SetVMConfig(vm.Config{Tracer: tracer})
 
-	errCh := make(chan error, 1)
 	exec, interrupt := rt.PrepareClause(clause, 0, gas, txCtx)
-	go func() {
+	if err := execTraced(ctx, tracer, func() error {
 		_, _, err := exec()
-		errCh <- err
-	}()
-	select {
-	case <-ctx.Done():
-		err := ctx.Err()
-		tracer.Stop(err)
-		interrupt()
+		return err
+	}, interrupt); err != nil {
 		return nil, err
-	case err := <-errCh:
+	}
+	return tracerResult(tracer)
+}
+
+// debugStorage returns the storage range of the contract at the target, which is the post-state of a block, a tx or
+// a clause.
+func (d *Debug) debugStorage(ctx context.Context, contractAddress thor.Address, target string, keyStart, hashedKeyPrefix []byte, maxResult int) (*StorageRangeResult, error) {
+	st, err := d.targetState(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	storageTrie, err := st.BuildStorageTrie(contractAddress)
+	if err != nil {
+		return nil, err
+	}
+	return storageRangeAt(storageTrie, keyStart, hashedKeyPrefix, maxResult)
+}
+
+// targetState returns the state of the target, in the format of `blockID` for the state after the block,
+// `blockID/(txIndex|txID)` for the state after the tx, or `blockID/(txIndex|txID)/clauseIndex` for the state
+// before the clause.
+func (d *Debug) targetState(ctx context.Context, target string) (*state.State, error) {
+	parts := strings.Split(target, "/")
+	switch len(parts) {
+	case 1:
+		blockID, err := parseTargetBlockID(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		summary, err := d.repo.GetBlockSummary(blockID)
 		if err != nil {
+			if d.repo.IsNotFound(err) {
+				return nil, utils.Forbidden(errors.New("block not found"))
+			}
 			return nil, err
 		}
+		return d.stater.NewState(summary.Header.StateRoot(), summary.Header.Number(), summary.Conflicts, summary.SteadyNum), nil
+	case 2:
+		blockID, err := parseTargetBlockID(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		txIndex, err := d.parseTargetTxIndex(blockID, parts[1])
+		if err != nil {
+			return nil, err
+		}
+		return d.txPostState(ctx, blockID, txIndex)
+	default:
+		blockID, txIndex, clauseIndex, err := d.parseTarget(target)
+		if err != nil {
+			return nil, err
+		}
+		rt, _, _, err := d.prepareClauseEnv(ctx, blockID, txIndex, clauseIndex)
+		if err != nil {
+			return nil, err
+		}
+		return rt.State(), nil
 	}
-	return tracer.GetResult()
 }
 
-func (d *Debug) debugStorage(ctx context.Context, contractAddress thor.Address, blockID thor.Bytes32, txIndex uint64, clauseIndex uint32, keyStart []byte, maxResult int) (*StorageRangeResult, error) {
-	rt, _, _, err := d.prepareClauseEnv(ctx, blockID, txIndex, clauseIndex)
+// txPostState replays the block to the tx at txIndex, and returns the state after the tx is finalized.
+func (d *Debug) txPostState(ctx context.Context, blockID thor.Bytes32, txIndex uint64) (*state.State, error) {
+	blk, err := d.repo.GetBlock(blockID)
 	if err != nil {
+		if d.repo.IsNotFound(err) {
+			return nil, utils.Forbidden(errors.New("block not found"))
+		}
 		return nil, err
 	}
-	storageTrie, err := rt.State().BuildStorageTrie(contractAddress)
+	txs := blk.Transactions()
+	if txIndex >= uint64(len(txs)) {
+		return nil, utils.Forbidden(errors.New("tx index out of range"))
+	}
+	rt, err := consensus.New(
+		d.repo,
+		d.stater,
+		d.forkConfig,
+	).NewRuntimeForReplay(blk.Header(), d.skipPoA)
 	if err != nil {
 		return nil, err
 	}
-	return storageRangeAt(storageTrie, keyStart, maxResult)
+	for _, tx := range txs[:txIndex+1] {
+		if _, err := rt.ExecuteTransaction(tx); err != nil {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+	}
+	return rt.State(), nil
 }
 
-func storageRangeAt(t *muxdb.Trie, start []byte, maxResult int) (*StorageRangeResult, error) {
+// storageRangeAt returns at most maxResult entries from the start key, whose hashed keys have the prefix. The trie is
+// ordered by the hashed keys, so entries can't be enumerated by the prefix of slots.
+func storageRangeAt(t *muxdb.Trie, start, prefix []byte, maxResult int) (*StorageRangeResult, error) {
+	if bytes.Compare(start, prefix) < 0 {
+		start = prefix
+	}
 	it := trie.NewIterator(t.NodeIterator(start, 0))
+	next := func() bool {
+		return it.Next() && bytes.HasPrefix(it.Key, prefix)
+	}
 	result := StorageRangeResult{Storage: StorageMap{}}
-	for i := 0; i < maxResult && it.Next(); i++ {
+	for i := 0; i < maxResult && next(); i++ {
 		_, content, _, err := rlp.Split(it.Value)
 		if err != nil {
 			return nil, err
@@ -302,9 +1025,9 @@ func storageRangeAt(t *muxdb.Trie, start []byte, maxResult int) (*StorageRangeRe
 		e.Key = &preimage
 		result.Storage[thor.BytesToBytes32(it.Key).String()] = e
 	}
-	if it.Next() {
-		next := thor.BytesToBytes32(it.Key)
-		result.NextKey = &next
+	if next() {
+		nextKey := thor.BytesToBytes32(it.Key)
+		result.NextKey = &nextKey
 	}
 	return &result, nil
 }
@@ -323,10 +1046,6 @@ func (d *Debug) handleDebugStorage(w http.ResponseWriter, req *http.Request) err
 		opt.MaxResult = defaultMaxStorageResult
 	}
 
-	blockID, txIndex, clauseIndex, err := d.parseTarget(opt.Target)
-	if err != nil {
-		return err
-	}
 	var keyStart []byte
 	if opt.KeyStart != "" {
 		k, err := hexutil.Decode(opt.KeyStart)
@@ -335,7 +1054,15 @@ func (d *Debug) handleDebugStorage(w http.ResponseWriter, req *http.Request) err
 		}
 		keyStart = k
 	}
-	res, err := d.debugStorage(req.Context(), opt.Address, blockID, txIndex, clauseIndex, keyStart, opt.MaxResult)
+	var hashedKeyPrefix []byte
+	if opt.HashedKeyPrefix != "" {
+		k, err := hexutil.Decode(opt.HashedKeyPrefix)
+		if err != nil || len(k) > 32 {
+			return utils.BadRequest(errors.New("hashedKeyPrefix: invalid format"))
+		}
+		hashedKeyPrefix = k
+	}
+	res, err := d.debugStorage(req.Context(), opt.Address, opt.Target, keyStart, hashedKeyPrefix, opt.MaxResult)
 	if err != nil {
 		return err
 	}
@@ -347,39 +1074,52 @@ func (d *Debug) parseTarget(target string) (blockID thor.Bytes32, txIndex uint64
 	if len(parts) != 3 {
 		return thor.Bytes32{}, 0, 0, utils.BadRequest(errors.New("target:" + target + " unsupported"))
 	}
-	blockID, err = thor.ParseBytes32(parts[0])
+	if blockID, err = parseTargetBlockID(parts[0]); err != nil {
+		return thor.Bytes32{}, 0, 0, err
+	}
+	if txIndex, err = d.parseTargetTxIndex(blockID, parts[1]); err != nil {
+		return thor.Bytes32{}, 0, 0, err
+	}
+	i, err := strconv.ParseUint(parts[2], 0, 0)
+	if err != nil {
+		return thor.Bytes32{}, 0, 0, utils.BadRequest(errors.WithMessage(err, "target[2]"))
+	} else if i > math.MaxUint32 {
+		return thor.Bytes32{}, 0, 0, utils.BadRequest(errors.New("invalid target[2]"))
+	}
+	clauseIndex = uint32(i)
+	return
+}
+
+func parseTargetBlockID(part string) (thor.Bytes32, error) {
+	blockID, err := thor.ParseBytes32(part)
 	if err != nil {
-		return thor.Bytes32{}, 0, 0, utils.BadRequest(errors.WithMessage(err, "target[0]"))
+		return thor.Bytes32{}, utils.BadRequest(errors.WithMessage(err, "target[0]"))
 	}
-	if len(parts[1]) == 64 || len(parts[1]) == 66 {
-		txID, err := thor.ParseBytes32(parts[1])
+	return blockID, nil
+}
+
+// parseTargetTxIndex parses the tx part of the target, which is either the tx index or the tx id.
+func (d *Debug) parseTargetTxIndex(blockID thor.Bytes32, part string) (uint64, error) {
+	if len(part) == 64 || len(part) == 66 {
+		txID, err := thor.ParseBytes32(part)
 		if err != nil {
-			return thor.Bytes32{}, 0, 0, utils.BadRequest(errors.WithMessage(err, "target[1]"))
+			return 0, utils.BadRequest(errors.WithMessage(err, "target[1]"))
 		}
 
 		txMeta, err := d.repo.NewChain(blockID).GetTransactionMeta(txID)
 		if err != nil {
 			if d.repo.IsNotFound(err) {
-				return thor.Bytes32{}, 0, 0, utils.Forbidden(errors.New("transaction not found"))
+				return 0, utils.Forbidden(errors.New("transaction not found"))
 			}
-			return thor.Bytes32{}, 0, 0, err
+			return 0, err
 		}
-		txIndex = txMeta.Index
-	} else {
-		i, err := strconv.ParseUint(parts[1], 0, 0)
-		if err != nil {
-			return thor.Bytes32{}, 0, 0, utils.BadRequest(errors.WithMessage(err, "target[1]"))
-		}
-		txIndex = i
+		return txMeta.Index, nil
 	}
-	i, err := strconv.ParseUint(parts[2], 0, 0)
+	i, err := strconv.ParseUint(part, 0, 0)
 	if err != nil {
-		return thor.Bytes32{}, 0, 0, utils.BadRequest(errors.WithMessage(err, "target[2]"))
-	} else if i > math.MaxUint32 {
-		return thor.Bytes32{}, 0, 0, utils.BadRequest(errors.New("invalid target[2]"))
+		return 0, utils.BadRequest(errors.WithMessage(err, "target[1]"))
 	}
-	clauseIndex = uint32(i)
-	return
+	return i, nil
 }
 
 func (d *Debug) handleTraceCallOption(opt *TraceCallOption) (*xenv.TransactionContext, uint64, *tx.Clause, error) {
@@ -453,10 +1193,30 @@ func (d *Debug) Mount(root *mux.Router, pathPrefix string) {
 		Methods(http.MethodPost).
 		Name("debug_trace_clause").
 		HandlerFunc(utils.WrapHandlerFunc(d.handleTraceClause))
+	sub.Path("/tracers/transaction/{id}").
+		Methods(http.MethodPost).
+		Name("debug_trace_transaction").
+		HandlerFunc(utils.WrapHandlerFunc(d.handleTraceTransaction))
+	sub.Path("/tracers/block/{revision}").
+		Methods(http.MethodPost).
+		Name("debug_trace_block").
+		HandlerFunc(utils.WrapHandlerFunc(d.handleTraceBlock))
 	sub.Path("/tracers/call").
 		Methods(http.MethodPost).
 		Name("debug_trace_call").
 		HandlerFunc(utils.WrapHandlerFunc(d.handleTraceCall))
+	sub.Path("/state-diff/transaction/{id}").
+		Methods(http.MethodGet).
+		Name("debug_state_diff_transaction").
+		HandlerFunc(utils.WrapHandlerFunc(d.handleStateDiffTransaction))
+	sub.Path("/state-diff/block/{revision}").
+		Methods(http.MethodGet).
+		Name("debug_state_diff_block").
+		HandlerFunc(utils.WrapHandlerFunc(d.handleStateDiffBlock))
+	sub.Path("/verify-block/{revision}").
+		Methods(http.MethodGet).
+		Name("debug_verify_block").
+		HandlerFunc(utils.WrapHandlerFunc(d.handleVerifyBlock))
 	sub.Path("/storage-range").
 		Methods(http.MethodPost).
 		Name("debug_trace_storage").
@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -22,6 +23,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/vechain/thor/v2/block"
@@ -33,6 +35,7 @@ import (
 	"github.com/vechain/thor/v2/packer"
 	"github.com/vechain/thor/v2/state"
 	"github.com/vechain/thor/v2/thor"
+	"github.com/vechain/thor/v2/tracers"
 	"github.com/vechain/thor/v2/tracers/logger"
 	"github.com/vechain/thor/v2/tx"
 
@@ -64,6 +67,36 @@ func TestDebug(t *testing.T) {
 		"testTraceClauseWithClauseIndexOutOfBound": testTraceClauseWithClauseIndexOutOfBound,
 		"testTraceClauseWithCustomTracer":          testTraceClauseWithCustomTracer,
 		"testTraceClause":                          testTraceClause,
+		"testTraceClauseWithMuxTracer":             testTraceClauseWithMuxTracer,
+		"testTraceClauseWithTraceCache":            testTraceClauseWithTraceCache,
+	} {
+		t.Run(name, tt)
+	}
+
+	// /tracers/transaction and /tracers/block endpoints
+	for name, tt := range map[string]func(*testing.T){
+		"testTraceTransactionWithBadRequest": testTraceTransactionWithBadRequest,
+		"testTraceTransaction":               testTraceTransaction,
+		"testTraceTransactionBalanceChange":  testTraceTransactionBalanceChange,
+		"testTraceBlockWithBadRequest":       testTraceBlockWithBadRequest,
+		"testTraceBlock":                     testTraceBlock,
+	} {
+		t.Run(name, tt)
+	}
+
+	// /state-diff endpoints
+	for name, tt := range map[string]func(*testing.T){
+		"testStateDiffWithBadRequest": testStateDiffWithBadRequest,
+		"testStateDiffTransaction":    testStateDiffTransaction,
+		"testStateDiffBlock":          testStateDiffBlock,
+	} {
+		t.Run(name, tt)
+	}
+
+	// /verify-block endpoint
+	for name, tt := range map[string]func(*testing.T){
+		"testVerifyBlockWithBadRequest": testVerifyBlockWithBadRequest,
+		"testVerifyBlock":               testVerifyBlock,
 	} {
 		t.Run(name, tt)
 	}
@@ -81,6 +114,8 @@ func TestDebug(t *testing.T) {
 		"testHandleTraceCallWithBadBlockRef":                 testHandleTraceCallWithBadBlockRef,
 		"testHandleTraceCallWithInvalidLengthBlockRef":       testHandleTraceCallWithInvalidLengthBlockRef,
 		"testTraceCallNextBlock":                             testTraceCallNextBlock,
+		"testTraceCallWithTracerLimits":                      testTraceCallWithTracerLimits,
+		"testTraceCallWithStateOverrides":                    testTraceCallWithStateOverrides,
 	} {
 		t.Run(name, tt)
 	}
@@ -90,6 +125,7 @@ func TestDebug(t *testing.T) {
 		"testStorageRangeWithError":     testStorageRangeWithError,
 		"testStorageRange":              testStorageRange,
 		"testStorageRangeDefaultOption": testStorageRangeDefaultOption,
+		"testStorageRangeAtBlockAndTx":  testStorageRangeAtBlockAndTx,
 	} {
 		t.Run(name, tt)
 	}
@@ -118,7 +154,7 @@ func TestStorageRangeFunc(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	storageRangeRes, err := storageRangeAt(trie, start, 1)
+	storageRangeRes, err := storageRangeAt(trie, start, nil, 1)
 	assert.NoError(t, err)
 	assert.NotNil(t, storageRangeRes.NextKey)
 	storage := storageRangeRes.Storage
@@ -145,19 +181,70 @@ func TestStorageRangeMaxResult(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	storageRangeRes, err := storageRangeAt(trie, start, 1001)
+	storageRangeRes, err := storageRangeAt(trie, start, nil, 1001)
 	assert.NoError(t, err)
 	assert.Equal(t, 1001, len(storageRangeRes.Storage))
 
-	storageRangeRes, err = storageRangeAt(trie, start, 1000)
+	storageRangeRes, err = storageRangeAt(trie, start, nil, 1000)
 	assert.NoError(t, err)
 	assert.Equal(t, 1000, len(storageRangeRes.Storage))
 
-	storageRangeRes, err = storageRangeAt(trie, start, 10)
+	storageRangeRes, err = storageRangeAt(trie, start, nil, 10)
 	assert.NoError(t, err)
 	assert.Equal(t, 10, len(storageRangeRes.Storage))
 }
 
+func TestStorageRangeHashedKeyPrefix(t *testing.T) {
+	db := muxdb.NewMem()
+	state := state.New(db, thor.Bytes32{}, 0, 0, 0)
+
+	addr := thor.BytesToAddress([]byte("account1"))
+	for i := 0; i < 100; i++ {
+		key := thor.BytesToBytes32([]byte(fmt.Sprintf("key%d", i)))
+		value := thor.BytesToBytes32([]byte(fmt.Sprintf("value%d", i)))
+		state.SetRawStorage(addr, key, value[:])
+	}
+
+	trie, err := state.BuildStorageTrie(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := storageRangeAt(trie, nil, nil, 100)
+	assert.NoError(t, err)
+	assert.Nil(t, all.NextKey)
+
+	// the first byte shared by the most keys
+	counts := make(map[string]int)
+	var prefixKey string
+	for key := range all.Storage {
+		counts[key[:4]]++
+		if counts[key[:4]] > counts[prefixKey] {
+			prefixKey = key[:4]
+		}
+	}
+	prefix := hexutil.MustDecode(prefixKey)
+	expected := counts[prefixKey]
+	assert.Greater(t, expected, 1)
+
+	storageRangeRes, err := storageRangeAt(trie, nil, prefix, 100)
+	assert.NoError(t, err)
+	assert.Nil(t, storageRangeRes.NextKey)
+	assert.Equal(t, expected, len(storageRangeRes.Storage))
+	for key := range storageRangeRes.Storage {
+		assert.True(t, strings.HasPrefix(key, prefixKey))
+	}
+
+	// the next key is in the prefix
+	storageRangeRes, err = storageRangeAt(trie, nil, prefix, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(storageRangeRes.Storage))
+	assert.True(t, strings.HasPrefix(storageRangeRes.NextKey.String(), prefixKey))
+
+	storageRangeRes, err = storageRangeAt(trie, storageRangeRes.NextKey.Bytes(), prefix, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, expected-1, len(storageRangeRes.Storage))
+}
+
 func testTraceClauseWithEmptyTracerName(t *testing.T) {
 	res := httpPostAndCheckResponseStatus(t, ts.URL+"/debug/tracers", &TraceClauseOption{}, 403)
 	assert.Equal(t, "tracer name must be defined", strings.TrimSpace(res))
@@ -275,6 +362,162 @@ func testTraceClause(t *testing.T) {
 	assert.Equal(t, expectedExecutionResult, parsedExecutionRes)
 }
 
+type finalizedBFT thor.Bytes32
+
+func (f finalizedBFT) Finalized() thor.Bytes32          { return thor.Bytes32(f) }
+func (f finalizedBFT) Justified() (thor.Bytes32, error) { return thor.Bytes32(f), nil }
+
+func testTraceClauseWithTraceCache(t *testing.T) {
+	newServer := func(finalized thor.Bytes32) (*httptest.Server, *traceCache) {
+		d := *debug
+		d.bft = finalizedBFT(finalized)
+		d.traceCache = newTraceCache(16, muxdb.NewMem().NewStore("tracecache"))
+		router := mux.NewRouter()
+		d.Mount(router, "/debug")
+		return httptest.NewServer(router), d.traceCache
+	}
+	traceClauseOption := &TraceClauseOption{
+		Name:   "call",
+		Target: fmt.Sprintf("%s/1/1", blk.Header().ID()),
+		Config: json.RawMessage(`{ "onlyTopCall": true }`),
+	}
+	key := traceCacheKey(blk.Header().ID(), 1, 1, "callTracer", json.RawMessage(`{"onlyTopCall":true}`))
+
+	// not cached if the block is not finalized
+	srv, cache := newServer(blk.Header().ParentID())
+	defer srv.Close()
+	expected := httpPostAndCheckResponseStatus(t, srv.URL+"/debug/tracers", traceClauseOption, 200)
+	_, ok := cache.Get(key, true)
+	assert.False(t, ok)
+
+	srv, cache = newServer(blk.Header().ID())
+	defer srv.Close()
+	res := httpPostAndCheckResponseStatus(t, srv.URL+"/debug/tracers", traceClauseOption, 200)
+	assert.Equal(t, expected, res)
+	cached, ok := cache.Get(key, true)
+	assert.True(t, ok)
+	assert.Equal(t, strings.TrimSpace(expected), string(cached))
+
+	// served from the in-memory cache, then from the store
+	cache.Set(key, json.RawMessage(`"cached"`), true)
+	res = httpPostAndCheckResponseStatus(t, srv.URL+"/debug/tracers", traceClauseOption, 200)
+	assert.Equal(t, `"cached"`, strings.TrimSpace(res))
+
+	cache.cache.Purge()
+	res = httpPostAndCheckResponseStatus(t, srv.URL+"/debug/tracers", traceClauseOption, 200)
+	assert.Equal(t, `"cached"`, strings.TrimSpace(res))
+
+	// the name with the suffix shares the key
+	traceClauseOption.Name = "callTracer"
+	res = httpPostAndCheckResponseStatus(t, srv.URL+"/debug/tracers", traceClauseOption, 200)
+	assert.Equal(t, `"cached"`, strings.TrimSpace(res))
+
+	// results of JS tracers are cached in memory only, as well as the mux tracer running any of them
+	for _, opt := range []*TraceClauseOption{
+		{Name: "unigram", Target: traceClauseOption.Target},
+		{Name: "mux", Target: traceClauseOption.Target, Config: json.RawMessage(`{"call":{},"unigram":{}}`)},
+	} {
+		name, _ := tracers.DefaultDirectory.Lookup(opt.Name)
+		key := traceCacheKey(blk.Header().ID(), 1, 1, name, opt.Config)
+		httpPostAndCheckResponseStatus(t, srv.URL+"/debug/tracers", opt, 200)
+		_, ok = cache.Get(key, false)
+		assert.True(t, ok, opt.Name)
+		ok, _ = cache.store.Has(key)
+		assert.False(t, ok, opt.Name)
+	}
+
+	// custom tracers are not cached
+	traceClauseOption.Name = "{data: [], fault: function(log) {}, step: function(log) {}, result: function() { return 1; }}"
+	traceClauseOption.Config = nil
+	httpPostAndCheckResponseStatus(t, srv.URL+"/debug/tracers", traceClauseOption, 200)
+	_, ok = cache.Get(traceCacheKey(blk.Header().ID(), 1, 1, traceClauseOption.Name, nil), true)
+	assert.False(t, ok)
+}
+
+func TestTraceCacheStore(t *testing.T) {
+	store := muxdb.NewMem().NewStore("tracecache")
+	key := func(n uint32) []byte {
+		var id thor.Bytes32
+		binary.BigEndian.PutUint32(id[:], n)
+		return traceCacheKey(id, 0, 0, "call", nil)
+	}
+
+	// results of other versions are deleted
+	assert.Nil(t, store.Put(append([]byte{traceStoreVersion - 1}, key(1)...), []byte(`"old"`)))
+	cache := newTraceCache(0, store)
+	_, ok := cache.Get(key(1), true)
+	assert.False(t, ok)
+	ok, _ = store.Has(append([]byte{traceStoreVersion - 1}, key(1)...))
+	assert.False(t, ok)
+
+	// results of the oldest blocks are evicted
+	res := json.RawMessage(`"result"`)
+	entrySize := uint64(len(key(0)) + len(res))
+	cache.storeLimit = entrySize * 10
+	for n := uint32(10); n > 0; n-- {
+		cache.Set(key(n), res, true)
+	}
+	assert.Equal(t, cache.storeLimit, cache.storeSize)
+	cache.Set(key(11), res, true)
+	assert.Equal(t, entrySize*9, cache.storeSize)
+	for n := uint32(1); n <= 11; n++ {
+		_, ok := cache.Get(key(n), true)
+		assert.Equal(t, n > 2, ok, "block %d", n)
+	}
+
+	// the size is restored at startup
+	cache.Set(key(11), res, true)
+	assert.Equal(t, entrySize*9, newTraceCache(0, store).storeSize)
+}
+
+func testTraceClauseWithMuxTracer(t *testing.T) {
+	traceClauseOption := &TraceClauseOption{
+		Name:   "muxTracer",
+		Target: fmt.Sprintf("%s/%s/1", blk.Header().ID(), transaction.ID()),
+		Config: json.RawMessage(`{"call": {}, "4byte": {}}`),
+	}
+	res := httpPostAndCheckResponseStatus(t, ts.URL+"/debug/tracers", traceClauseOption, 200)
+
+	var results map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(res), &results); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(results))
+	assert.JSONEq(t, `{}`, string(results["4byte"]))
+
+	var call struct {
+		To    thor.Address          `json:"to"`
+		Value *math.HexOrDecimal256 `json:"value"`
+	}
+	if err := json.Unmarshal(results["call"], &call); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, *transaction.Clauses()[1].To(), call.To)
+	assert.Equal(t, transaction.Clauses()[1].Value(), (*big.Int)(call.Value))
+
+	traceClauseOption.Config = json.RawMessage(`{}`)
+	res = httpPostAndCheckResponseStatus(t, ts.URL+"/debug/tracers", traceClauseOption, 403)
+	assert.Equal(t, "no tracer specified", strings.TrimSpace(res))
+}
+
+func TestCreateMuxTracer(t *testing.T) {
+	d := &Debug{allowedTracers: map[string]interface{}{"mux": new(interface{}), "call": new(interface{})}}
+
+	_, err := d.createTracer("mux", json.RawMessage(`{"call": {}}`))
+	assert.Nil(t, err)
+	_, err = d.createTracer("mux", json.RawMessage(`{"call": {}, "prestate": {}}`))
+	assert.EqualError(t, err, "creating tracer is not allowed: prestate")
+	_, err = d.createTracer("mux", json.RawMessage(`[]`))
+	assert.Contains(t, err.Error(), "mux tracer config")
+	_, err = d.createTracer("muxTracer", json.RawMessage(`{"call": {}}`))
+	assert.EqualError(t, err, "creating tracer is not allowed: muxTracer")
+
+	// the nested mux tracer would bypass the restriction
+	d.allowedTracers["muxTracer"] = new(interface{})
+	_, err = d.createTracer("mux", json.RawMessage(`{"muxTracer": {"prestate": {}}}`))
+	assert.EqualError(t, err, "nested mux tracer is not allowed")
+}
+
 func testTraceClauseWithTxIndexOutOfBound(t *testing.T) {
 	traceClauseOption := &TraceClauseOption{
 		Name:   "logger",
@@ -297,6 +540,220 @@ func testTraceClauseWithClauseIndexOutOfBound(t *testing.T) {
 	assert.Equal(t, "clause index out of range", strings.TrimSpace(res))
 }
 
+func testTraceTransactionWithBadRequest(t *testing.T) {
+	res := httpPostAndCheckResponseStatus(t, ts.URL+"/debug/tracers/transaction/0x01", &TraceOption{Name: "logger"}, 400)
+	assert.Contains(t, res, "id")
+
+	res = httpPostAndCheckResponseStatus(t, ts.URL+"/debug/tracers/transaction/"+transaction.ID().String(), &TraceOption{}, 403)
+	assert.Equal(t, "tracer name must be defined", strings.TrimSpace(res))
+
+	res = httpPostAndCheckResponseStatus(t, ts.URL+"/debug/tracers/transaction/"+randBytes32().String(), &TraceOption{Name: "logger"}, 403)
+	assert.Equal(t, "transaction not found", strings.TrimSpace(res))
+}
+
+func testTraceTransaction(t *testing.T) {
+	res := httpPostAndCheckResponseStatus(t, ts.URL+"/debug/tracers/transaction/"+transaction.ID().String(), &TraceOption{Name: "logger"}, 200)
+
+	var result TxTraceResult
+	if err := json.Unmarshal([]byte(res), &result); err != nil {
+		t.Fatal(err)
+	}
+	receipts, err := debug.repo.GetBlockReceipts(blk.Header().ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, transaction.ID(), result.TxID)
+	assert.Equal(t, uint64(1), result.TxIndex)
+	assert.Equal(t, 2, len(result.Clauses))
+	for i, clause := range result.Clauses {
+		assert.Equal(t, uint32(i), clause.ClauseIndex)
+		var executionRes logger.ExecutionResult
+		if err := json.Unmarshal(clause.Result, &executionRes); err != nil {
+			t.Fatal(err)
+		}
+		assert.False(t, executionRes.Failed)
+	}
+	assert.False(t, result.Reverted)
+	assert.Equal(t, receipts[1].GasUsed, result.GasUsed)
+	assert.Equal(t, receipts[1].GasPayer, result.GasPayer)
+	assert.Equal(t, receipts[1].Paid, (*big.Int)(result.Paid))
+	assert.Equal(t, receipts[1].Reward, (*big.Int)(result.Reward))
+}
+
+func testTraceTransactionBalanceChange(t *testing.T) {
+	res := httpPostAndCheckResponseStatus(t, ts.URL+"/debug/tracers/transaction/"+transaction.ID().String(), &TraceOption{Name: "balanceChange"}, 200)
+
+	var result TxTraceResult
+	if err := json.Unmarshal([]byte(res), &result); err != nil {
+		t.Fatal(err)
+	}
+	receipts, err := debug.repo.GetBlockReceipts(blk.Header().ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	type change struct {
+		Address  thor.Address `json:"address"`
+		Token    string       `json:"token"`
+		Sent     string       `json:"sent"`
+		Received string       `json:"received"`
+	}
+	var changes []change
+	if err := json.Unmarshal(result.Result, &changes); err != nil {
+		t.Fatal(err)
+	}
+
+	// both clauses send VET to the recipient, and the origin pays the gas to itself as the beneficiary
+	origin := genesis.DevAccounts()[0].Address
+	recipient := thor.BytesToAddress([]byte("to"))
+	assert.ElementsMatch(t, []change{
+		{Address: recipient, Token: "VET", Sent: "0x0", Received: "0x4e20"},
+		{Address: origin, Token: "VET", Sent: "0x4e20", Received: "0x0"},
+		{Address: origin, Token: "VTHO", Sent: hexutil.EncodeBig(receipts[1].Paid), Received: hexutil.EncodeBig(receipts[1].Reward)},
+	}, changes)
+}
+
+func testTraceBlockWithBadRequest(t *testing.T) {
+	res := httpPostAndCheckResponseStatus(t, ts.URL+"/debug/tracers/block/next", &TraceOption{Name: "logger"}, 400)
+	assert.Contains(t, res, "revision")
+
+	res = httpPostAndCheckResponseStatus(t, ts.URL+"/debug/tracers/block/"+randBytes32().String(), &TraceOption{Name: "logger"}, 400)
+	assert.Contains(t, res, "revision")
+
+	httpPostAndCheckResponseStatus(t, ts.URL+"/debug/tracers/block/best", &TraceOption{}, 403)
+}
+
+func testTraceBlock(t *testing.T) {
+	for _, revision := range []string{"best", "1", blk.Header().ID().String()} {
+		res := httpPostAndCheckResponseStatus(t, ts.URL+"/debug/tracers/block/"+revision, &TraceOption{Name: "4byteTracer"}, 200)
+
+		var results []*TxTraceResult
+		if err := json.Unmarshal([]byte(res), &results); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 2, len(results))
+		assert.Equal(t, blk.Transactions()[0].ID(), results[0].TxID)
+		assert.Equal(t, 0, len(results[0].Clauses))
+		assert.NotZero(t, results[0].GasUsed)
+		assert.Equal(t, transaction.ID(), results[1].TxID)
+		assert.Equal(t, 2, len(results[1].Clauses))
+	}
+}
+
+func testStateDiffWithBadRequest(t *testing.T) {
+	res := httpGetAndCheckResponseStatus(t, ts.URL+"/debug/state-diff/transaction/0x01", 400)
+	assert.Contains(t, res, "id")
+
+	res = httpGetAndCheckResponseStatus(t, ts.URL+"/debug/state-diff/transaction/"+randBytes32().String(), 403)
+	assert.Equal(t, "transaction not found", strings.TrimSpace(res))
+
+	res = httpGetAndCheckResponseStatus(t, ts.URL+"/debug/state-diff/block/next", 400)
+	assert.Contains(t, res, "revision")
+}
+
+func testVerifyBlockWithBadRequest(t *testing.T) {
+	res := httpGetAndCheckResponseStatus(t, ts.URL+"/debug/verify-block/next", 400)
+	assert.Contains(t, res, "revision")
+
+	res = httpGetAndCheckResponseStatus(t, ts.URL+"/debug/verify-block/0", 403)
+	assert.Equal(t, "genesis block can't be replayed", strings.TrimSpace(res))
+}
+
+func testVerifyBlock(t *testing.T) {
+	res := httpGetAndCheckResponseStatus(t, ts.URL+"/debug/verify-block/"+blk.Header().ID().String(), 200)
+
+	var result VerifyBlockResult
+	if err := json.Unmarshal([]byte(res), &result); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, result.Valid)
+	assert.Equal(t, blk.Header().ID(), result.BlockID)
+	assert.Equal(t, &RootVerification{blk.Header().ReceiptsRoot(), blk.Header().ReceiptsRoot(), true}, result.ReceiptsRoot)
+	assert.Equal(t, &RootVerification{blk.Header().StateRoot(), blk.Header().StateRoot(), true}, result.StateRoot)
+	assert.Empty(t, result.Receipts)
+
+	receipts, err := debug.repo.GetBlockReceipts(blk.Header().ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupted := *receipts[1]
+	corrupted.GasUsed++
+	assert.True(t, equalReceipts(receipts[1], receipts[1]))
+	assert.False(t, equalReceipts(receipts[1], &corrupted))
+	assert.False(t, equalReceipts(nil, receipts[1]))
+	diff := jsonDiff(receipts[1], &corrupted)
+	assert.Contains(t, diff, fmt.Sprintf("-  \"GasUsed\": %d,", receipts[1].GasUsed))
+	assert.Contains(t, diff, fmt.Sprintf("+  \"GasUsed\": %d,", corrupted.GasUsed))
+}
+
+func testStateDiffTransaction(t *testing.T) {
+	res := httpGetAndCheckResponseStatus(t, ts.URL+"/debug/state-diff/transaction/"+transaction.ID().String(), 200)
+
+	var diff StateDiff
+	if err := json.Unmarshal([]byte(res), &diff); err != nil {
+		t.Fatal(err)
+	}
+	receipts, err := debug.repo.GetBlockReceipts(blk.Header().ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the origin is also the beneficiary, which pays the gas and gets the reward
+	origin := genesis.DevAccounts()[0].Address.String()
+	to := thor.BytesToAddress([]byte("to")).String()
+	// the energy contract keeps the total growth and burned energy
+	assert.Equal(t, 3, len(diff.Post))
+	assert.Equal(t, 1, len(diff.Post[builtin.Energy.Address.String()].Storage))
+	assert.Equal(t, big.NewInt(-20000), new(big.Int).Sub((*big.Int)(diff.Post[origin].Balance), (*big.Int)(diff.Pre[origin].Balance)))
+	energyChange := new(big.Int).Sub(receipts[1].Reward, receipts[1].Paid)
+	assert.Equal(t, energyChange, new(big.Int).Sub((*big.Int)(diff.Post[origin].Energy), (*big.Int)(diff.Pre[origin].Energy)))
+	assert.Nil(t, diff.Post[origin].Storage)
+
+	assert.Equal(t, 0, (*big.Int)(diff.Pre[to].Balance).Sign())
+	assert.Equal(t, big.NewInt(20000), (*big.Int)(diff.Post[to].Balance))
+	assert.Nil(t, diff.Post[to].Energy)
+}
+
+func TestDiffAccountsStorageReset(t *testing.T) {
+	addr := thor.BytesToAddress([]byte("addr"))
+	acc := func(barrier int) map[thor.Address]*accountState {
+		return map[thor.Address]*accountState{addr: {
+			balance: new(big.Int),
+			energy:  new(big.Int),
+			storage: map[thor.Bytes32]rlp.RawValue{},
+			barrier: barrier,
+		}}
+	}
+
+	assert.Empty(t, diffAccounts(acc(0), acc(0)).Post)
+
+	// cleared storage is reported even if no slot is enumerated
+	diff := diffAccounts(acc(0), acc(1))
+	assert.False(t, diff.Pre[addr.String()].StorageReset)
+	assert.True(t, diff.Post[addr.String()].StorageReset)
+}
+
+func testStateDiffBlock(t *testing.T) {
+	res := httpGetAndCheckResponseStatus(t, ts.URL+"/debug/state-diff/block/"+blk.Header().ID().String(), 200)
+
+	var diff StateDiff
+	if err := json.Unmarshal([]byte(res), &diff); err != nil {
+		t.Fatal(err)
+	}
+	receipts, err := debug.repo.GetBlockReceipts(blk.Header().ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	origin := genesis.DevAccounts()[0].Address.String()
+	energyChange := new(big.Int)
+	for _, receipt := range receipts {
+		energyChange.Add(energyChange, receipt.Reward)
+		energyChange.Sub(energyChange, receipt.Paid)
+	}
+	assert.Equal(t, energyChange, new(big.Int).Sub((*big.Int)(diff.Post[origin].Energy), (*big.Int)(diff.Pre[origin].Energy)))
+	assert.Equal(t, big.NewInt(20000), (*big.Int)(diff.Post[thor.BytesToAddress([]byte("to")).String()].Balance))
+}
+
 func testHandleTraceCallWithMalformedBodyRequest(t *testing.T) {
 	badBodyRequest := "badBodyRequest"
 	httpPostAndCheckResponseStatus(t, ts.URL+"/debug/tracers/call", badBodyRequest, 400)
@@ -325,6 +782,79 @@ func testTraceCallNextBlock(t *testing.T) {
 	httpPostAndCheckResponseStatus(t, ts.URL+"/debug/tracers/call?revision=next", traceCallOption, 200)
 }
 
+func testTraceCallWithTracerLimits(t *testing.T) {
+	debug.tracerLimits = tracers.Limits{Steps: 1}
+	defer func() { debug.tracerLimits = tracers.Limits{} }()
+
+	method, _ := builtin.Energy.ABI.MethodByName("balanceOf")
+	data, err := method.EncodeInput(genesis.DevAccounts()[0].Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	traceCallOption := &TraceCallOption{
+		Name: "unigram",
+		To:   &builtin.Energy.Address,
+		Data: hexutil.Encode(data),
+	}
+	res := httpPostAndCheckResponseStatus(t, ts.URL+"/debug/tracers/call", traceCallOption, 403)
+	assert.Contains(t, res, "tracer execution limit exceeded: steps (max 1)")
+
+	debug.tracerLimits = tracers.Limits{Memory: 1}
+	res = httpPostAndCheckResponseStatus(t, ts.URL+"/debug/tracers/call", traceCallOption, 403)
+	assert.Contains(t, res, "tracer execution limit exceeded: memory (max 1)")
+
+	// native tracers are not limited
+	traceCallOption.Name = "call"
+	httpPostAndCheckResponseStatus(t, ts.URL+"/debug/tracers/call", traceCallOption, 200)
+}
+
+func testTraceCallWithStateOverrides(t *testing.T) {
+	caller := randAddress()
+	contract := randAddress()
+	value := math.HexOrDecimal256(*big.NewInt(1e18))
+	// returns the value of slot 0
+	code := hexutil.Bytes(hexutil.MustDecode("0x60005460005260206000f3"))
+	slotValue := thor.BytesToBytes32([]byte{42})
+
+	traceCallOption := &TraceCallOption{
+		Name:   "call",
+		To:     &contract,
+		Value:  &value,
+		Caller: &caller,
+		StateOverrides: map[string]*AccountOverride{
+			caller.String(): {Balance: &value},
+			contract.String(): {
+				Code:    &code,
+				Storage: map[string]string{thor.Bytes32{}.String(): slotValue.String()},
+			},
+		},
+	}
+	res := httpPostAndCheckResponseStatus(t, ts.URL+"/debug/tracers/call", traceCallOption, 200)
+
+	var frame struct {
+		Error  string        `json:"error"`
+		Output hexutil.Bytes `json:"output"`
+	}
+	if err := json.Unmarshal([]byte(res), &frame); err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, frame.Error)
+	assert.Equal(t, slotValue.Bytes(), []byte(frame.Output))
+
+	// the state is not changed
+	traceCallOption.StateOverrides = nil
+	res = httpPostAndCheckResponseStatus(t, ts.URL+"/debug/tracers/call", traceCallOption, 200)
+	assert.Contains(t, res, "insufficient balance")
+
+	traceCallOption.StateOverrides = map[string]*AccountOverride{"0x01": {}}
+	res = httpPostAndCheckResponseStatus(t, ts.URL+"/debug/tracers/call", traceCallOption, 400)
+	assert.Contains(t, res, "stateOverrides")
+
+	traceCallOption.StateOverrides = map[string]*AccountOverride{contract.String(): {Storage: map[string]string{"0x01": "0x01"}}}
+	res = httpPostAndCheckResponseStatus(t, ts.URL+"/debug/tracers/call", traceCallOption, 400)
+	assert.Contains(t, res, "stateOverrides["+contract.String()+"].storage")
+}
+
 func testHandleTraceCall(t *testing.T) {
 	addr := randAddress()
 	provedWork := math.HexOrDecimal256(*big.NewInt(1000))
@@ -521,6 +1051,52 @@ func testStorageRangeDefaultOption(t *testing.T) {
 	assert.NotZero(t, len(storageRangeRes.Storage))
 }
 
+func testStorageRangeAtBlockAndTx(t *testing.T) {
+	storageRange := func(target string) *StorageRangeResult {
+		opt := StorageRangeOption{Address: builtin.Energy.Address, Target: target}
+		res := httpPostAndCheckResponseStatus(t, ts.URL+"/debug/storage-range", &opt, 200)
+
+		var storageRangeRes *StorageRangeResult
+		if err := json.Unmarshal([]byte(res), &storageRangeRes); err != nil {
+			t.Fatal(err)
+		}
+		return storageRangeRes
+	}
+
+	postBlock := storageRange(blk.Header().ID().String())
+	assert.NotZero(t, len(postBlock.Storage))
+	// the last tx of the block
+	assert.Equal(t, postBlock, storageRange(fmt.Sprintf("%s/1", blk.Header().ID())))
+	assert.Equal(t, postBlock, storageRange(fmt.Sprintf("%s/%s", blk.Header().ID(), transaction.ID())))
+	assert.NotZero(t, len(storageRange(fmt.Sprintf("%s/0", blk.Header().ID())).Storage))
+
+	opt := StorageRangeOption{Address: builtin.Energy.Address, Target: fmt.Sprintf("%s/2", blk.Header().ID())}
+	res := httpPostAndCheckResponseStatus(t, ts.URL+"/debug/storage-range", &opt, 403)
+	assert.Equal(t, "tx index out of range", strings.TrimSpace(res))
+
+	opt.Target = randBytes32().String()
+	res = httpPostAndCheckResponseStatus(t, ts.URL+"/debug/storage-range", &opt, 403)
+	assert.Equal(t, "block not found", strings.TrimSpace(res))
+
+	opt.Target = blk.Header().ID().String()
+	opt.HashedKeyPrefix = "0x0"
+	res = httpPostAndCheckResponseStatus(t, ts.URL+"/debug/storage-range", &opt, 400)
+	assert.Equal(t, "hashedKeyPrefix: invalid format", strings.TrimSpace(res))
+
+	for key := range postBlock.Storage {
+		opt.HashedKeyPrefix = key[:4]
+		res = httpPostAndCheckResponseStatus(t, ts.URL+"/debug/storage-range", &opt, 200)
+		var storageRangeRes *StorageRangeResult
+		if err := json.Unmarshal([]byte(res), &storageRangeRes); err != nil {
+			t.Fatal(err)
+		}
+		assert.Contains(t, storageRangeRes.Storage, key)
+		for k := range storageRangeRes.Storage {
+			assert.True(t, strings.HasPrefix(k, key[:4]))
+		}
+	}
+}
+
 func initDebugServer(t *testing.T) {
 	db := muxdb.NewMem()
 	stater := state.NewStater(db)
@@ -597,7 +1173,7 @@ func initDebugServer(t *testing.T) {
 	forkConfig := thor.GetForkConfig(b.Header().ID())
 	router := mux.NewRouter()
 	allTracersEnabled := map[string]interface{}{"all": new(interface{})}
-	debug = New(repo, stater, forkConfig, 21000, true, solo.NewBFTEngine(repo), allTracersEnabled, false)
+	debug = New(repo, stater, forkConfig, 21000, true, solo.NewBFTEngine(repo), allTracersEnabled, tracers.Limits{}, 0, nil, false)
 	debug.Mount(router, "/debug")
 	ts = httptest.NewServer(router)
 }
@@ -620,6 +1196,20 @@ func httpPostAndCheckResponseStatus(t *testing.T, url string, obj interface{}, r
 	return string(r)
 }
 
+func httpGetAndCheckResponseStatus(t *testing.T, url string, responseStatusCode int) string {
+	res, err := http.Get(url) // nolint:gosec
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, responseStatusCode, res.StatusCode)
+	r, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(r)
+}
+
 func randAddress() (addr thor.Address) {
 	rand.Read(addr[:])
 	return
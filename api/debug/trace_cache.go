@@ -0,0 +1,173 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package debug
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/vechain/thor/v2/kv"
+	"github.com/vechain/thor/v2/log"
+	"github.com/vechain/thor/v2/thor"
+)
+
+const (
+	// traceStoreVersion prefixes the keys in the store, and should be increased once the format of the key or the
+	// result changes. Results of other versions are deleted at startup.
+	traceStoreVersion = byte(2)
+	// traceStoreLimit is the max bytes of results in the store.
+	traceStoreLimit = uint64(1 << 30)
+)
+
+// traceCache caches the trace results of clauses in finalized blocks, which never change. Results are kept in an LRU
+// cache, and optionally persisted in a kv store, where results of the oldest blocks are evicted once it's full. Results
+// which may change between restarts, e.g. of JS tracers, which may be loaded from files, are kept in memory only.
+type traceCache struct {
+	cache *lru.Cache
+	store kv.Store
+
+	storeLock  sync.Mutex
+	storeSize  uint64 // bytes of the keys and results in the store
+	storeLimit uint64
+}
+
+// newTraceCache creates the trace cache of size entries in memory, with the optional store. It returns nil if both are
+// disabled.
+func newTraceCache(size int, store kv.Store) *traceCache {
+	if size <= 0 && store == nil {
+		return nil
+	}
+	c := &traceCache{storeLimit: traceStoreLimit}
+	if size > 0 {
+		c.cache, _ = lru.New(size)
+	}
+	if store != nil {
+		var err error
+		if c.store, c.storeSize, err = openTraceStore(store); err != nil {
+			log.Warn("failed to open trace cache store, disabled", "err", err)
+		}
+	}
+	return c
+}
+
+// openTraceStore deletes results of other versions in the store, and returns the store of the current version with
+// its size in bytes.
+func openTraceStore(store kv.Store) (kv.Store, uint64, error) {
+	if err := store.DeleteRange(context.Background(), kv.Range{Limit: []byte{traceStoreVersion}}); err != nil {
+		return nil, 0, err
+	}
+	if err := store.DeleteRange(context.Background(), kv.Range{Start: []byte{traceStoreVersion + 1}}); err != nil {
+		return nil, 0, err
+	}
+
+	var size uint64
+	store = kv.Bucket([]byte{traceStoreVersion}).NewStore(store)
+	iter := store.Iterate(kv.Range{})
+	defer iter.Release()
+	for iter.Next() {
+		size += uint64(len(iter.Key()) + len(iter.Value()))
+	}
+	if err := iter.Error(); err != nil {
+		return nil, 0, err
+	}
+	return store, size, nil
+}
+
+// traceCacheKey returns the key of the trace result of the clause by the tracer, whose name is the registered one. The
+// config is compacted, so that the same config in different formats, or a null one and none, shares the key.
+func traceCacheKey(blockID thor.Bytes32, txIndex uint64, clauseIndex uint32, name string, config json.RawMessage) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, config); err != nil {
+		buf.Reset()
+		buf.Write(config)
+	}
+	if buf.String() == "null" {
+		buf.Reset()
+	}
+	configHash := thor.Blake2b([]byte(name), []byte{0}, buf.Bytes())
+
+	key := make([]byte, 0, 32+8+4+32)
+	key = append(key, blockID[:]...)
+	key = binary.BigEndian.AppendUint64(key, txIndex)
+	key = binary.BigEndian.AppendUint32(key, clauseIndex)
+	return append(key, configHash[:]...)
+}
+
+// Get returns the cached result of the key, looking up the store if stored is true. Results found in the store are
+// added to the LRU cache.
+func (c *traceCache) Get(key []byte, stored bool) (json.RawMessage, bool) {
+	if c == nil {
+		return nil, false
+	}
+	if c.cache != nil {
+		if res, ok := c.cache.Get(string(key)); ok {
+			return res.(json.RawMessage), true
+		}
+	}
+	if c.store != nil && stored {
+		res, err := c.store.Get(key)
+		if err != nil {
+			if !c.store.IsNotFound(err) {
+				log.Warn("failed to read trace cache", "err", err)
+			}
+			return nil, false
+		}
+		if c.cache != nil {
+			c.cache.Add(string(key), json.RawMessage(res))
+		}
+		return res, true
+	}
+	return nil, false
+}
+
+// Set caches the result of the key, and persists it in the store if stored is true.
+func (c *traceCache) Set(key []byte, res json.RawMessage, stored bool) {
+	if c == nil {
+		return
+	}
+	if c.cache != nil {
+		c.cache.Add(string(key), res)
+	}
+	if c.store != nil && stored {
+		if err := c.put(key, res); err != nil {
+			log.Warn("failed to write trace cache", "err", err)
+		}
+	}
+}
+
+// put writes the result into the store, and evicts results of the oldest blocks down to 90% of the limit once it's
+// exceeded. Keys start with the block ID, so they are ordered by the block number.
+func (c *traceCache) put(key []byte, res json.RawMessage) error {
+	c.storeLock.Lock()
+	defer c.storeLock.Unlock()
+
+	if old, err := c.store.Get(key); err == nil {
+		c.storeSize -= uint64(len(key) + len(old))
+	} else if !c.store.IsNotFound(err) {
+		return err
+	}
+	if err := c.store.Put(key, res); err != nil {
+		return err
+	}
+	c.storeSize += uint64(len(key) + len(res))
+	if c.storeSize <= c.storeLimit {
+		return nil
+	}
+
+	iter := c.store.Iterate(kv.Range{})
+	defer iter.Release()
+	for c.storeSize > c.storeLimit/10*9 && iter.Next() {
+		if err := c.store.Delete(iter.Key()); err != nil {
+			return err
+		}
+		c.storeSize -= uint64(len(iter.Key()) + len(iter.Value()))
+	}
+	return iter.Error()
+}
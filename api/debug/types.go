@@ -8,6 +8,7 @@ package debug
 import (
 	"encoding/json"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/vechain/thor/v2/thor"
 )
@@ -18,6 +19,33 @@ type TraceClauseOption struct {
 	Config json.RawMessage `json:"config"` // Config specific to given tracer.
 }
 
+// TraceOption is the option to trace a whole transaction or block.
+type TraceOption struct {
+	Name   string          `json:"name"`
+	Config json.RawMessage `json:"config"` // Config specific to given tracer.
+}
+
+// ClauseTraceResult is the trace result of a clause.
+type ClauseTraceResult struct {
+	ClauseIndex uint32          `json:"clauseIndex"`
+	Result      json.RawMessage `json:"result"`
+}
+
+// TxTraceResult is the trace results of clauses of a transaction, with the gas accounting done when the
+// transaction is finalized. Clauses after a reverted one are not executed and have no result. Tracers whose clause
+// results add up, e.g. balanceChange, also give the result of the whole transaction, including the gas payment.
+type TxTraceResult struct {
+	TxID     thor.Bytes32          `json:"txID"`
+	TxIndex  uint64                `json:"txIndex"`
+	Clauses  []*ClauseTraceResult  `json:"clauses"`
+	Reverted bool                  `json:"reverted"`
+	GasUsed  uint64                `json:"gasUsed"`
+	GasPayer thor.Address          `json:"gasPayer"`
+	Paid     *math.HexOrDecimal256 `json:"paid"`
+	Reward   *math.HexOrDecimal256 `json:"reward"`
+	Result   json.RawMessage       `json:"result,omitempty"`
+}
+
 type TraceCallOption struct {
 	To         *thor.Address         `json:"to"`
 	Value      *math.HexOrDecimal256 `json:"value"`
@@ -31,13 +59,25 @@ type TraceCallOption struct {
 	BlockRef   string                `json:"blockRef"`
 	Name       string                `json:"name"`   // Tracer
 	Config     json.RawMessage       `json:"config"` // Config specific to given tracer.
+	// StateOverrides are applied to the state before the call, keyed by account address.
+	StateOverrides map[string]*AccountOverride `json:"stateOverrides"`
+}
+
+// AccountOverride overrides the state of an account, where nil fields are left unchanged. Storage has the overridden
+// slots only, with both keys and values in 32 bytes.
+type AccountOverride struct {
+	Balance *math.HexOrDecimal256 `json:"balance"`
+	Energy  *math.HexOrDecimal256 `json:"energy"`
+	Code    *hexutil.Bytes        `json:"code"`
+	Storage map[string]string     `json:"storage"`
 }
 
 type StorageRangeOption struct {
-	Address   thor.Address
-	KeyStart  string
-	MaxResult int
-	Target    string
+	Address         thor.Address
+	KeyStart        string
+	HashedKeyPrefix string // prefix of the hashed storage keys, not of the slots
+	MaxResult       int
+	Target          string
 }
 
 type StorageRangeResult struct {
@@ -51,3 +91,48 @@ type StorageEntry struct {
 	Key   *thor.Bytes32 `json:"key"`
 	Value *thor.Bytes32 `json:"value"`
 }
+
+// StateDiff is the state changes made by a transaction or block, keyed by account address. Pre has the changed accounts
+// before the changes, and post has the changed fields of them after.
+type StateDiff struct {
+	Pre  map[string]*AccountDiff `json:"pre"`
+	Post map[string]*AccountDiff `json:"post"`
+}
+
+// AccountDiff is the state of an account in a state diff. Storage has the changed slots only, where values are 32 bytes,
+// except that values stored by builtin contracts in RLP lists are in raw RLP. StorageReset is set in post if the storage
+// was cleared, e.g. by self-destruct, where the cleared slots not set again are not enumerated.
+type AccountDiff struct {
+	Balance *math.HexOrDecimal256 `json:"balance,omitempty"`
+	Energy  *math.HexOrDecimal256 `json:"energy,omitempty"`
+	Master  *thor.Address         `json:"master,omitempty"`
+	Code    hexutil.Bytes         `json:"code,omitempty"`
+	Storage map[string]string     `json:"storage,omitempty"`
+
+	StorageReset bool `json:"storageReset,omitempty"`
+}
+
+// VerifyBlockResult is the result of replaying a block to verify it against the stored data. Receipts has the
+// mismatched receipts only.
+type VerifyBlockResult struct {
+	BlockID      thor.Bytes32       `json:"blockID"`
+	Number       uint32             `json:"number"`
+	Valid        bool               `json:"valid"`
+	ReceiptsRoot *RootVerification  `json:"receiptsRoot"`
+	StateRoot    *RootVerification  `json:"stateRoot"`
+	Receipts     []*ReceiptMismatch `json:"receipts"`
+}
+
+// RootVerification compares the root in the block header with the one computed by replay.
+type RootVerification struct {
+	Expected thor.Bytes32 `json:"expected"`
+	Actual   thor.Bytes32 `json:"actual"`
+	Match    bool         `json:"match"`
+}
+
+// ReceiptMismatch is the unified diff of the stored receipt of a tx and the one produced by replay.
+type ReceiptMismatch struct {
+	TxID    thor.Bytes32 `json:"txID"`
+	TxIndex uint64       `json:"txIndex"`
+	Diff    string       `json:"diff"`
+}
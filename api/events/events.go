@@ -12,41 +12,82 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
+	"github.com/vechain/thor/v2/abi/registry"
 	"github.com/vechain/thor/v2/api/utils"
 	"github.com/vechain/thor/v2/chain"
 	"github.com/vechain/thor/v2/logdb"
 )
 
+// streamFlushInterval is the number of logs written between flushes in streaming mode.
+const streamFlushInterval = 100
+
 type Events struct {
-	repo  *chain.Repository
-	db    *logdb.LogDB
-	limit uint64
+	repo        *chain.Repository
+	db          *logdb.LogDB
+	limit       uint64
+	streamLimit uint64
+	abis        *registry.Registry
 }
 
-func New(repo *chain.Repository, db *logdb.LogDB, logsLimit uint64) *Events {
+func New(repo *chain.Repository, db *logdb.LogDB, logsLimit uint64, streamLimit uint64, abis *registry.Registry) *Events {
 	return &Events{
 		repo,
 		db,
 		logsLimit,
+		streamLimit,
+		abis,
 	}
 }
 
 // Filter query events with option
 func (e *Events) filter(ctx context.Context, ef *EventFilter) ([]*FilteredEvent, error) {
+	events, err := e.filterEvents(ctx, ef)
+	if err != nil {
+		return nil, err
+	}
+	fes := make([]*FilteredEvent, len(events))
+	for i, ev := range events {
+		fes[i] = convertEvent(ev, e.abis)
+	}
+	return fes, nil
+}
+
+func (e *Events) filterEvents(ctx context.Context, ef *EventFilter) ([]*logdb.Event, error) {
 	chain := e.repo.NewBestChain()
-	filter, err := convertEventFilter(chain, ef)
+	filter, err := convertEventFilter(chain, e.abis, ef)
 	if err != nil {
 		return nil, err
 	}
-	events, err := e.db.FilterEvents(ctx, filter)
+	return e.db.FilterEvents(ctx, filter)
+}
+
+// filterPage query a page of events in cursor mode.
+func (e *Events) filterPage(ctx context.Context, ef *EventFilter) (*EventPage, error) {
+	limit := ef.Options.Limit
+	if limit == 0 {
+		limit = e.limit
+	}
+	// query one more log to detect whether there is a next page
+	filter := *ef
+	filter.Options = &logdb.Options{
+		Limit:  limit + 1,
+		Cursor: ef.Options.Cursor,
+	}
+	events, err := e.filterEvents(ctx, &filter)
 	if err != nil {
 		return nil, err
 	}
-	fes := make([]*FilteredEvent, len(events))
-	for i, e := range events {
-		fes[i] = convertEvent(e)
+
+	page := &EventPage{Events: make([]*FilteredEvent, 0, len(events))}
+	if uint64(len(events)) > limit {
+		events = events[:limit]
+		last := events[len(events)-1]
+		page.NextCursor = logdb.NewCursor(last.BlockNumber, last.Index)
 	}
-	return fes, nil
+	for _, ev := range events {
+		page.Events = append(page.Events, convertEvent(ev, e.abis))
+	}
+	return page, nil
 }
 
 func (e *Events) handleFilter(w http.ResponseWriter, req *http.Request) error {
@@ -54,9 +95,22 @@ func (e *Events) handleFilter(w http.ResponseWriter, req *http.Request) error {
 	if err := utils.ParseJSON(req.Body, &filter); err != nil {
 		return utils.BadRequest(errors.WithMessage(err, "body"))
 	}
+	if utils.AcceptsNDJSON(req) {
+		return e.stream(w, req, &filter)
+	}
 	if filter.Options != nil && filter.Options.Limit > e.limit {
 		return utils.Forbidden(fmt.Errorf("options.limit exceeds the maximum allowed value of %d", e.limit))
 	}
+	if filter.Options != nil && filter.Options.Cursor != nil {
+		if filter.Options.Offset != 0 {
+			return utils.BadRequest(errors.New("options.offset must be zero when options.cursor is used"))
+		}
+		page, err := e.filterPage(req.Context(), &filter)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJSON(w, page)
+	}
 	if filter.Options == nil {
 		// if filter.Options is nil, set to the default limit +1
 		// to detect whether there are more logs than the default limit
@@ -79,6 +133,83 @@ func (e *Events) handleFilter(w http.ResponseWriter, req *http.Request) error {
 	return utils.WriteJSON(w, fes)
 }
 
+// stream writes filtered events as newline delimited JSON while they are read from the db. The number of events is
+// limited by the stream limit, and the response is aborted if more events are matched.
+func (e *Events) stream(w http.ResponseWriter, req *http.Request, ef *EventFilter) error {
+	if e.streamLimit == 0 {
+		return utils.Forbidden(errors.New("streaming is not enabled"))
+	}
+	if ef.Options != nil && ef.Options.Limit > e.streamLimit {
+		return utils.Forbidden(fmt.Errorf("options.limit exceeds the maximum allowed value of %d", e.streamLimit))
+	}
+	filter, err := convertEventFilter(e.repo.NewBestChain(), e.abis, ef)
+	if err != nil {
+		return err
+	}
+	if filter.Options == nil {
+		// query one more event to detect whether the stream limit is exceeded
+		filter.Options = &logdb.Options{Limit: e.streamLimit + 1}
+	}
+
+	nw := utils.NewNDJSONWriter(w, streamFlushInterval)
+	if err := e.db.IterateEvents(req.Context(), filter, func(ev *logdb.Event) error {
+		if uint64(nw.Count()) >= e.streamLimit {
+			return fmt.Errorf("the number of filtered logs exceeds the maximum allowed value of %d", e.streamLimit)
+		}
+		return nw.Write(convertEvent(ev, e.abis))
+	}); err != nil {
+		if nw.Count() == 0 {
+			return err
+		}
+		// the response is partially written, abort it to let the client know it's incomplete
+		panic(http.ErrAbortHandler)
+	}
+	nw.Flush()
+	return nil
+}
+
+func (e *Events) handleCount(w http.ResponseWriter, req *http.Request) error {
+	var filter EventCountFilter
+	if err := utils.ParseJSON(req.Body, &filter); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "body"))
+	}
+	switch filter.GroupBy {
+	case logdb.GroupByNone, logdb.GroupByAddress:
+	case logdb.GroupByBlock:
+		if filter.BucketSize == 0 {
+			return utils.BadRequest(errors.New("bucketSize: must be positive when grouped by block"))
+		}
+	default:
+		return utils.BadRequest(errors.New("groupBy: unsupported " + string(filter.GroupBy)))
+	}
+
+	ef, err := convertEventFilter(e.repo.NewBestChain(), e.abis, &EventFilter{
+		CriteriaSet: filter.CriteriaSet,
+		Range:       filter.Range,
+		Order:       filter.Order,
+	})
+	if err != nil {
+		return err
+	}
+	counts, err := e.db.CountEvents(req.Context(), ef, &logdb.Aggregation{
+		GroupBy:    filter.GroupBy,
+		BucketSize: filter.BucketSize,
+		Limit:      e.limit + 1,
+	})
+	if err != nil {
+		return err
+	}
+	if uint64(len(counts)) > e.limit {
+		return utils.Forbidden(fmt.Errorf("the number of groups exceeds the maximum allowed value of %d, please narrow the range", e.limit))
+	}
+
+	results := make([]*EventCount, len(counts))
+	for i, c := range counts {
+		results[i] = convertEventCount(c)
+	}
+	return utils.WriteJSON(w, results)
+}
+
 func (e *Events) Mount(root *mux.Router, pathPrefix string) {
 	sub := root.PathPrefix(pathPrefix).Subrouter()
 
@@ -86,4 +217,8 @@ func (e *Events) Mount(root *mux.Router, pathPrefix string) {
 		Methods(http.MethodPost).
 		Name("logs_filter_event").
 		HandlerFunc(utils.WrapHandlerFunc(e.handleFilter))
+	sub.Path("/count").
+		Methods(http.MethodPost).
+		Name("logs_count_event").
+		HandlerFunc(utils.WrapHandlerFunc(e.handleCount))
 }
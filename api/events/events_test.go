@@ -7,15 +7,18 @@ package events_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
+	"github.com/vechain/thor/v2/abi/registry"
 	"github.com/vechain/thor/v2/api/events"
 	"github.com/vechain/thor/v2/block"
 	"github.com/vechain/thor/v2/chain"
@@ -38,7 +41,7 @@ var (
 
 func TestEmptyEvents(t *testing.T) {
 	db := createDb(t)
-	initEventServer(t, db, defaultLogLimit)
+	initEventServer(t, db, defaultLogLimit, 0)
 	defer ts.Close()
 
 	for name, tt := range map[string]func(*testing.T){
@@ -51,7 +54,7 @@ func TestEmptyEvents(t *testing.T) {
 
 func TestEvents(t *testing.T) {
 	db := createDb(t)
-	initEventServer(t, db, defaultLogLimit)
+	initEventServer(t, db, defaultLogLimit, 0)
 	defer ts.Close()
 
 	blocksToInsert := 5
@@ -61,7 +64,7 @@ func TestEvents(t *testing.T) {
 
 func TestOption(t *testing.T) {
 	db := createDb(t)
-	initEventServer(t, db, 5)
+	initEventServer(t, db, 5, 0)
 	defer ts.Close()
 	insertBlocks(t, db, 5)
 
@@ -99,6 +102,248 @@ func TestOption(t *testing.T) {
 	assert.Equal(t, "the number of filtered logs exceeds the maximum allowed value of 5, please use pagination", strings.Trim(string(res), "\n"))
 }
 
+func TestCursor(t *testing.T) {
+	db := createDb(t)
+	initEventServer(t, db, 5, 0)
+	defer ts.Close()
+	insertBlocks(t, db, 7)
+
+	filter := events.EventFilter{
+		Options: &logdb.Options{Limit: 3, Cursor: &logdb.Cursor{}},
+		Order:   logdb.DESC,
+	}
+
+	var blockNums []uint32
+	for i := 0; i < 3; i++ {
+		res, statusCode := httpPost(t, ts.URL+"/events", filter)
+		assert.Equal(t, http.StatusOK, statusCode)
+
+		var page events.EventPage
+		if err := json.Unmarshal(res, &page); err != nil {
+			t.Fatal(err)
+		}
+		for _, ev := range page.Events {
+			blockNums = append(blockNums, ev.Meta.BlockNumber)
+		}
+		if i < 2 {
+			assert.NotNil(t, page.NextCursor)
+		} else {
+			assert.Nil(t, page.NextCursor)
+		}
+		filter.Options.Cursor = page.NextCursor
+	}
+	assert.Equal(t, []uint32{8, 7, 6, 5, 4, 3, 2}, blockNums)
+
+	// offset is not allowed in cursor mode
+	filter.Options = &logdb.Options{Offset: 1, Limit: 3, Cursor: &logdb.Cursor{}}
+	res, statusCode := httpPost(t, ts.URL+"/events", filter)
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+	assert.Equal(t, "options.offset must be zero when options.cursor is used", strings.Trim(string(res), "\n"))
+}
+
+func TestStream(t *testing.T) {
+	db := createDb(t)
+	initEventServer(t, db, 5, 7)
+	defer ts.Close()
+	insertBlocks(t, db, 7)
+
+	stream := func(filter events.EventFilter) *http.Response {
+		body, err := json.Marshal(filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/events", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", "application/x-ndjson")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	// the stream limit is applied instead of the logs limit
+	res := stream(events.EventFilter{Order: logdb.DESC})
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/x-ndjson", res.Header.Get("Content-Type"))
+
+	var blockNums []uint32
+	dec := json.NewDecoder(res.Body)
+	for dec.More() {
+		var ev events.FilteredEvent
+		if err := dec.Decode(&ev); err != nil {
+			t.Fatal(err)
+		}
+		blockNums = append(blockNums, ev.Meta.BlockNumber)
+	}
+	assert.Equal(t, []uint32{8, 7, 6, 5, 4, 3, 2}, blockNums)
+
+	res = stream(events.EventFilter{Options: &logdb.Options{Limit: 8}})
+	msg, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	assert.Equal(t, "options.limit exceeds the maximum allowed value of 7", strings.TrimSpace(string(msg)))
+
+	// the response is aborted if the stream limit is exceeded
+	insertBlocks(t, db, 8)
+	res = stream(events.EventFilter{})
+	_, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	assert.Error(t, err)
+	ts.Close()
+
+	// streaming is disabled
+	initEventServer(t, db, 5, 0)
+	res = stream(events.EventFilter{})
+	msg, _ = io.ReadAll(res.Body)
+	res.Body.Close()
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	assert.Equal(t, "streaming is not enabled", strings.TrimSpace(string(msg)))
+}
+
+func TestDecodedEvents(t *testing.T) {
+	db, err := logdb.New(filepath.Join(t.TempDir(), "logs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	abis, err := registry.New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := abis.Register("erc20", []byte(`[{"type":"event","name":"Transfer","inputs":[
+		{"indexed":true,"name":"from","type":"address"},
+		{"indexed":true,"name":"to","type":"address"},
+		{"indexed":false,"name":"value","type":"uint256"}]}]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	transferID := thor.Keccak256([]byte("Transfer(address,address,uint256)"))
+	w := db.NewWriter()
+	b := new(block.Builder).Build()
+	if err := w.Write(b, tx.Receipts{{Outputs: []*tx.Output{{Events: tx.Events{
+		{Address: addr, Topics: []thor.Bytes32{transferID, thor.BytesToBytes32(addr[:]), thor.BytesToBytes32(addr[:])}, Data: thor.BytesToBytes32([]byte{1}).Bytes()},
+		{Address: addr, Topics: []thor.Bytes32{topic}},
+	}}}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	router := mux.NewRouter()
+	muxDb := muxdb.NewMem()
+	gene, _, _, err := genesis.NewDevnet().Build(state.NewStater(muxDb))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, _ := chain.NewRepository(muxDb, gene)
+	events.New(repo, db, defaultLogLimit, 0, abis).Mount(router, "/events")
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	// all events, decoded if matched
+	res, statusCode := httpPost(t, srv.URL+"/events", events.EventFilter{})
+	assert.Equal(t, http.StatusOK, statusCode)
+	var evs []*events.FilteredEvent
+	if err := json.Unmarshal(res, &evs); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(evs))
+	assert.Equal(t, "Transfer", evs[0].Name)
+	assert.Equal(t, 3, len(evs[0].Params))
+	assert.Equal(t, "value", evs[0].Params[2].Name)
+	assert.Equal(t, "0x1", evs[0].Params[2].Value)
+	assert.Equal(t, "", evs[1].Name)
+	assert.Nil(t, evs[1].Params)
+
+	// filter by event name or signature
+	for _, event := range []string{"Transfer", "Transfer(address,address,uint256)"} {
+		res, statusCode = httpPost(t, srv.URL+"/events", events.EventFilter{
+			CriteriaSet: []*events.EventCriteria{{Address: &addr, Event: event}},
+		})
+		assert.Equal(t, http.StatusOK, statusCode)
+		evs = nil
+		if err := json.Unmarshal(res, &evs); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 1, len(evs))
+		assert.Equal(t, transferID, *evs[0].Topics[0])
+	}
+
+	// unknown event name
+	res, statusCode = httpPost(t, srv.URL+"/events", events.EventFilter{
+		CriteriaSet: []*events.EventCriteria{{Event: "Approval"}},
+	})
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+	assert.Equal(t, `criteriaSet: event "Approval" not found in registered ABIs`, strings.TrimSpace(string(res)))
+
+	// conflicts with topic0
+	res, statusCode = httpPost(t, srv.URL+"/events", events.EventFilter{
+		CriteriaSet: []*events.EventCriteria{{Event: "Transfer", TopicSet: events.TopicSet{Topic0: &topic}}},
+	})
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+	assert.Equal(t, "criteriaSet: event conflicts with topic0", strings.TrimSpace(string(res)))
+}
+
+func TestCount(t *testing.T) {
+	db := createDb(t)
+	initEventServer(t, db, 3, 0)
+	defer ts.Close()
+	insertBlocks(t, db, 5)
+
+	// the in-memory db may be shared with other tests
+	all, err := db.FilterEvents(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buckets []*events.EventCount
+	for _, ev := range all {
+		from := ev.BlockNumber / 4 * 4
+		if len(buckets) == 0 || buckets[len(buckets)-1].Range.From != from {
+			buckets = append(buckets, &events.EventCount{Range: &events.BlockRange{From: from, To: from + 3}})
+		}
+		buckets[len(buckets)-1].Count++
+	}
+
+	res, statusCode := httpPost(t, ts.URL+"/events/count", events.EventCountFilter{})
+	assert.Equal(t, http.StatusOK, statusCode)
+	var counts []*events.EventCount
+	if err := json.Unmarshal(res, &counts); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []*events.EventCount{{Count: uint64(len(all))}}, counts)
+
+	res, statusCode = httpPost(t, ts.URL+"/events/count", events.EventCountFilter{GroupBy: logdb.GroupByAddress})
+	assert.Equal(t, http.StatusOK, statusCode)
+	counts = nil
+	if err := json.Unmarshal(res, &counts); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []*events.EventCount{{Address: &addr, Count: uint64(len(all))}}, counts)
+
+	res, statusCode = httpPost(t, ts.URL+"/events/count", events.EventCountFilter{GroupBy: logdb.GroupByBlock, BucketSize: 4})
+	assert.Equal(t, http.StatusOK, statusCode)
+	counts = nil
+	if err := json.Unmarshal(res, &counts); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, buckets, counts)
+
+	// the number of groups exceeds the limit
+	res, statusCode = httpPost(t, ts.URL+"/events/count", events.EventCountFilter{GroupBy: logdb.GroupByBlock, BucketSize: 1})
+	assert.Equal(t, http.StatusForbidden, statusCode)
+	assert.Equal(t, "the number of groups exceeds the maximum allowed value of 3, please narrow the range", strings.Trim(string(res), "\n"))
+
+	_, statusCode = httpPost(t, ts.URL+"/events/count", events.EventCountFilter{GroupBy: logdb.GroupByBlock})
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+	_, statusCode = httpPost(t, ts.URL+"/events/count", events.EventCountFilter{GroupBy: logdb.GroupBySender})
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+}
+
 // Test functions
 func testEventsBadRequest(t *testing.T) {
 	badBody := []byte{0x00, 0x01, 0x02}
@@ -174,7 +419,7 @@ func testEventWithBlocks(t *testing.T, expectedBlocks int) {
 }
 
 // Init functions
-func initEventServer(t *testing.T, logDb *logdb.LogDB, limit uint64) {
+func initEventServer(t *testing.T, logDb *logdb.LogDB, limit uint64, streamLimit uint64) {
 	router := mux.NewRouter()
 
 	muxDb := muxdb.NewMem()
@@ -188,7 +433,7 @@ func initEventServer(t *testing.T, logDb *logdb.LogDB, limit uint64) {
 
 	repo, _ := chain.NewRepository(muxDb, b)
 
-	events.New(repo, logDb, limit).Mount(router, "/events")
+	events.New(repo, logDb, limit, streamLimit, nil).Mount(router, "/events")
 	ts = httptest.NewServer(router)
 }
 
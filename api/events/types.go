@@ -10,6 +10,9 @@ import (
 	"math"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	"github.com/vechain/thor/v2/abi/registry"
+	"github.com/vechain/thor/v2/api/utils"
 	"github.com/vechain/thor/v2/block"
 	"github.com/vechain/thor/v2/chain"
 	"github.com/vechain/thor/v2/logdb"
@@ -35,14 +38,16 @@ type TopicSet struct {
 
 // FilteredEvent only comes from one contract
 type FilteredEvent struct {
-	Address thor.Address    `json:"address"`
-	Topics  []*thor.Bytes32 `json:"topics"`
-	Data    string          `json:"data"`
-	Meta    LogMeta         `json:"meta"`
+	Address thor.Address      `json:"address"`
+	Topics  []*thor.Bytes32   `json:"topics"`
+	Data    string            `json:"data"`
+	Name    string            `json:"name,omitempty"`   // decoded with registered ABIs
+	Params  []*registry.Param `json:"params,omitempty"` // decoded with registered ABIs
+	Meta    LogMeta           `json:"meta"`
 }
 
-// convert a logdb.Event into a json format Event
-func convertEvent(event *logdb.Event) *FilteredEvent {
+// convert a logdb.Event into a json format Event, decoded if it matches a registered ABI
+func convertEvent(event *logdb.Event, abis *registry.Registry) *FilteredEvent {
 	fe := FilteredEvent{
 		Address: event.Address,
 		Data:    hexutil.Encode(event.Data),
@@ -56,14 +61,26 @@ func convertEvent(event *logdb.Event) *FilteredEvent {
 		},
 	}
 	fe.Topics = make([]*thor.Bytes32, 0)
+	topics := make([]thor.Bytes32, 0, 5)
 	for i := 0; i < 5; i++ {
 		if event.Topics[i] != nil {
 			fe.Topics = append(fe.Topics, event.Topics[i])
+			topics = append(topics, *event.Topics[i])
 		}
 	}
+	if decoded := abis.DecodeEvent(event.Address, topics, event.Data); decoded != nil {
+		fe.Name = decoded.Name
+		fe.Params = decoded.Params
+	}
 	return &fe
 }
 
+// EventPage is a page of events returned in cursor mode.
+type EventPage struct {
+	Events     []*FilteredEvent `json:"events"`
+	NextCursor *logdb.Cursor    `json:"nextCursor"` // nil if there are no more events
+}
+
 func (e *FilteredEvent) String() string {
 	return fmt.Sprintf(`
 		Event(
@@ -91,6 +108,9 @@ func (e *FilteredEvent) String() string {
 
 type EventCriteria struct {
 	Address *thor.Address `json:"address"`
+	// Event is the event signature like Transfer(address,address,uint256), or the event name in registered ABIs,
+	// which is converted to topic0.
+	Event string `json:"event,omitempty"`
 	TopicSet
 }
 
@@ -101,7 +121,7 @@ type EventFilter struct {
 	Order       logdb.Order      `json:"order"`
 }
 
-func convertEventFilter(chain *chain.Chain, filter *EventFilter) (*logdb.EventFilter, error) {
+func convertEventFilter(chain *chain.Chain, abis *registry.Registry, filter *EventFilter) (*logdb.EventFilter, error) {
 	rng, err := ConvertRange(chain, filter.Range)
 	if err != nil {
 		return nil, err
@@ -116,6 +136,16 @@ func convertEventFilter(chain *chain.Chain, filter *EventFilter) (*logdb.EventFi
 		for i, criterion := range filter.CriteriaSet {
 			var topics [5]*thor.Bytes32
 			topics[0] = criterion.Topic0
+			if criterion.Event != "" {
+				id, err := abis.EventID(criterion.Address, criterion.Event)
+				if err != nil {
+					return nil, utils.BadRequest(errors.WithMessage(err, "criteriaSet"))
+				}
+				if topics[0] != nil && *topics[0] != id {
+					return nil, utils.BadRequest(errors.New("criteriaSet: event conflicts with topic0"))
+				}
+				topics[0] = &id
+			}
 			topics[1] = criterion.Topic1
 			topics[2] = criterion.Topic2
 			topics[3] = criterion.Topic3
@@ -129,6 +159,44 @@ func convertEventFilter(chain *chain.Chain, filter *EventFilter) (*logdb.EventFi
 	return f, nil
 }
 
+// EventCountFilter is the filter of event count queries.
+type EventCountFilter struct {
+	CriteriaSet []*EventCriteria `json:"criteriaSet"`
+	Range       *Range           `json:"range"`
+	Order       logdb.Order      `json:"order"`
+	GroupBy     logdb.GroupBy    `json:"groupBy"`
+	BucketSize  uint32           `json:"bucketSize"`
+}
+
+// BlockRange is the block range of a group in aggregate query results.
+type BlockRange struct {
+	From uint32 `json:"from"`
+	To   uint32 `json:"to"`
+}
+
+// ConvertBlockRange converts a logdb.Range into a json format BlockRange.
+func ConvertBlockRange(rng *logdb.Range) *BlockRange {
+	if rng == nil {
+		return nil
+	}
+	return &BlockRange{From: rng.From, To: rng.To}
+}
+
+// EventCount is the number of events in a group.
+type EventCount struct {
+	Range   *BlockRange   `json:"range,omitempty"`
+	Address *thor.Address `json:"address,omitempty"`
+	Count   uint64        `json:"count"`
+}
+
+func convertEventCount(c *logdb.EventCount) *EventCount {
+	return &EventCount{
+		Range:   ConvertBlockRange(c.Range),
+		Address: c.Address,
+		Count:   c.Count,
+	}
+}
+
 type RangeType string
 
 const (
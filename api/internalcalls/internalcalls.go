@@ -0,0 +1,207 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package internalcalls
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/vechain/thor/v2/api/events"
+	"github.com/vechain/thor/v2/api/utils"
+	"github.com/vechain/thor/v2/chain"
+	"github.com/vechain/thor/v2/logdb"
+)
+
+// streamFlushInterval is the number of logs written between flushes in streaming mode.
+const streamFlushInterval = 100
+
+type InternalCalls struct {
+	repo        *chain.Repository
+	db          *logdb.LogDB
+	limit       uint64
+	streamLimit uint64
+}
+
+func New(repo *chain.Repository, db *logdb.LogDB, logsLimit uint64, streamLimit uint64) *InternalCalls {
+	return &InternalCalls{
+		repo,
+		db,
+		logsLimit,
+		streamLimit,
+	}
+}
+
+// filter query internal calls with option
+func (ic *InternalCalls) filter(ctx context.Context, filter *InternalCallFilter) ([]*FilteredInternalCall, error) {
+	calls, err := ic.filterInternalCalls(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	filtered := make([]*FilteredInternalCall, len(calls))
+	for i, call := range calls {
+		filtered[i] = convertInternalCall(call)
+	}
+	return filtered, nil
+}
+
+// convertRange converts the range, which must not start before the first block indexed by the internal call table.
+func (ic *InternalCalls) convertRange(r *events.Range) (*logdb.Range, error) {
+	rng, err := events.ConvertRange(ic.repo.NewBestChain(), r)
+	if err != nil {
+		return nil, err
+	}
+	from, err := ic.db.IndexedFrom(logdb.InternalCallTable)
+	if err != nil {
+		return nil, err
+	}
+	if from == math.MaxUint32 {
+		return nil, utils.Forbidden(errors.New("internal calls are not indexed by the node"))
+	}
+	if from > 0 && (rng == nil || rng.From < from) {
+		return nil, utils.BadRequest(fmt.Errorf("range: internal calls are indexed from block %d, until the log db is rebuilt", from))
+	}
+	return rng, nil
+}
+
+func (ic *InternalCalls) filterInternalCalls(ctx context.Context, filter *InternalCallFilter) ([]*logdb.InternalCall, error) {
+	rng, err := ic.convertRange(filter.Range)
+	if err != nil {
+		return nil, err
+	}
+
+	return ic.db.FilterInternalCalls(ctx, &logdb.InternalCallFilter{
+		CriteriaSet: filter.CriteriaSet,
+		Range:       rng,
+		Options:     filter.Options,
+		Order:       filter.Order,
+	})
+}
+
+// filterPage query a page of internal calls in cursor mode.
+func (ic *InternalCalls) filterPage(ctx context.Context, cf *InternalCallFilter) (*InternalCallPage, error) {
+	limit := cf.Options.Limit
+	if limit == 0 {
+		limit = ic.limit
+	}
+	// query one more log to detect whether there is a next page
+	filter := *cf
+	filter.Options = &logdb.Options{
+		Limit:  limit + 1,
+		Cursor: cf.Options.Cursor,
+	}
+	calls, err := ic.filterInternalCalls(ctx, &filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &InternalCallPage{Calls: make([]*FilteredInternalCall, 0, len(calls))}
+	if uint64(len(calls)) > limit {
+		calls = calls[:limit]
+		last := calls[len(calls)-1]
+		page.NextCursor = logdb.NewCursor(last.BlockNumber, last.Index)
+	}
+	for _, call := range calls {
+		page.Calls = append(page.Calls, convertInternalCall(call))
+	}
+	return page, nil
+}
+
+func (ic *InternalCalls) handleFilterInternalCalls(w http.ResponseWriter, req *http.Request) error {
+	var filter InternalCallFilter
+	if err := utils.ParseJSON(req.Body, &filter); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "body"))
+	}
+	if utils.AcceptsNDJSON(req) {
+		return ic.stream(w, req, &filter)
+	}
+	if filter.Options != nil && filter.Options.Limit > ic.limit {
+		return utils.Forbidden(fmt.Errorf("options.limit exceeds the maximum allowed value of %d", ic.limit))
+	}
+	if filter.Options != nil && filter.Options.Cursor != nil {
+		if filter.Options.Offset != 0 {
+			return utils.BadRequest(errors.New("options.offset must be zero when options.cursor is used"))
+		}
+		page, err := ic.filterPage(req.Context(), &filter)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJSON(w, page)
+	}
+	if filter.Options == nil {
+		// if filter.Options is nil, set to the default limit +1
+		// to detect whether there are more logs than the default limit
+		filter.Options = &logdb.Options{
+			Offset: 0,
+			Limit:  ic.limit + 1,
+		}
+	}
+
+	calls, err := ic.filter(req.Context(), &filter)
+	if err != nil {
+		return err
+	}
+
+	// ensure the result size is less than the configured limit
+	if len(calls) > int(ic.limit) {
+		return utils.Forbidden(fmt.Errorf("the number of filtered logs exceeds the maximum allowed value of %d, please use pagination", ic.limit))
+	}
+
+	return utils.WriteJSON(w, calls)
+}
+
+// stream writes filtered calls as newline delimited JSON while they are read from the db. The number of calls
+// is limited by the stream limit, and the response is aborted if more calls are matched.
+func (ic *InternalCalls) stream(w http.ResponseWriter, req *http.Request, cf *InternalCallFilter) error {
+	if ic.streamLimit == 0 {
+		return utils.Forbidden(errors.New("streaming is not enabled"))
+	}
+	if cf.Options != nil && cf.Options.Limit > ic.streamLimit {
+		return utils.Forbidden(fmt.Errorf("options.limit exceeds the maximum allowed value of %d", ic.streamLimit))
+	}
+	rng, err := ic.convertRange(cf.Range)
+	if err != nil {
+		return err
+	}
+	options := cf.Options
+	if options == nil {
+		// query one more log to detect whether the stream limit is exceeded
+		options = &logdb.Options{Limit: ic.streamLimit + 1}
+	}
+
+	nw := utils.NewNDJSONWriter(w, streamFlushInterval)
+	if err := ic.db.IterateInternalCalls(req.Context(), &logdb.InternalCallFilter{
+		CriteriaSet: cf.CriteriaSet,
+		Range:       rng,
+		Options:     options,
+		Order:       cf.Order,
+	}, func(call *logdb.InternalCall) error {
+		if uint64(nw.Count()) >= ic.streamLimit {
+			return fmt.Errorf("the number of filtered logs exceeds the maximum allowed value of %d", ic.streamLimit)
+		}
+		return nw.Write(convertInternalCall(call))
+	}); err != nil {
+		if nw.Count() == 0 {
+			return err
+		}
+		// the response is partially written, abort it to let the client know it's incomplete
+		panic(http.ErrAbortHandler)
+	}
+	nw.Flush()
+	return nil
+}
+
+func (ic *InternalCalls) Mount(root *mux.Router, pathPrefix string) {
+	sub := root.PathPrefix(pathPrefix).Subrouter()
+
+	sub.Path("").
+		Methods(http.MethodPost).
+		Name("logs_filter_internal_call").
+		HandlerFunc(utils.WrapHandlerFunc(ic.handleFilterInternalCalls))
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package internalcalls_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/vechain/thor/v2/api/internalcalls"
+	"github.com/vechain/thor/v2/block"
+	"github.com/vechain/thor/v2/chain"
+	"github.com/vechain/thor/v2/genesis"
+	"github.com/vechain/thor/v2/logdb"
+	"github.com/vechain/thor/v2/muxdb"
+	"github.com/vechain/thor/v2/state"
+	"github.com/vechain/thor/v2/thor"
+)
+
+var (
+	ts       *httptest.Server
+	caller   = thor.BytesToAddress([]byte("caller"))
+	callee   = thor.BytesToAddress([]byte("callee"))
+	selector = []byte{0xa9, 0x05, 0x9c, 0xbb}
+)
+
+func TestInternalCalls(t *testing.T) {
+	db := createDb(t)
+	initInternalCallServer(t, db, 5, 6)
+	defer ts.Close()
+	insertBlocks(t, db, 3)
+
+	// bad request
+	res, err := http.Post(ts.URL+"/internal-calls", "application/x-www-form-urlencoded", strings.NewReader("{\"criteriaSet\": 1}")) // nolint:gosec
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	res.Body.Close()
+
+	filter := internalcalls.InternalCallFilter{
+		CriteriaSet: []*logdb.InternalCallCriteria{{To: &callee, Selector: selector}},
+		Order:       logdb.DESC,
+	}
+	body, statusCode := httpPost(t, ts.URL+"/internal-calls", filter)
+	assert.Equal(t, http.StatusOK, statusCode)
+	var calls []*internalcalls.FilteredInternalCall
+	if err := json.Unmarshal(body, &calls); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, len(calls))
+	for i, call := range calls {
+		assert.Equal(t, uint32(4-i), call.Meta.BlockNumber)
+		assert.Equal(t, uint32(1), call.Depth)
+		assert.Equal(t, "CALL", call.Type)
+		assert.Equal(t, caller, call.From)
+		assert.Equal(t, &callee, call.To)
+		assert.Equal(t, big.NewInt(1), (*big.Int)(call.Value))
+		assert.Equal(t, selector, []byte(*call.Selector))
+		assert.Equal(t, "", call.Error)
+	}
+
+	// calls without value and selector
+	filter = internalcalls.InternalCallFilter{
+		CriteriaSet: []*logdb.InternalCallCriteria{{From: &callee}},
+	}
+	body, statusCode = httpPost(t, ts.URL+"/internal-calls", filter)
+	assert.Equal(t, http.StatusOK, statusCode)
+	calls = nil
+	if err := json.Unmarshal(body, &calls); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, len(calls))
+	assert.Equal(t, uint32(2), calls[0].Depth)
+	assert.Nil(t, calls[0].Value)
+	assert.Nil(t, calls[0].Selector)
+	assert.Equal(t, "execution reverted", calls[0].Error)
+
+	// limit exceeded
+	filter.Options = &logdb.Options{Limit: 6}
+	body, statusCode = httpPost(t, ts.URL+"/internal-calls", filter)
+	assert.Equal(t, http.StatusForbidden, statusCode)
+	assert.Equal(t, "options.limit exceeds the maximum allowed value of 5", strings.Trim(string(body), "\n"))
+
+	// too many results without pagination
+	filter.CriteriaSet = nil
+	filter.Options = nil
+	_, statusCode = httpPost(t, ts.URL+"/internal-calls", filter)
+	assert.Equal(t, http.StatusForbidden, statusCode)
+
+	// cursor
+	filter.Options = &logdb.Options{Limit: 4, Cursor: &logdb.Cursor{}}
+	var count int
+	for {
+		body, statusCode = httpPost(t, ts.URL+"/internal-calls", filter)
+		assert.Equal(t, http.StatusOK, statusCode)
+		var page internalcalls.InternalCallPage
+		if err := json.Unmarshal(body, &page); err != nil {
+			t.Fatal(err)
+		}
+		count += len(page.Calls)
+		if page.NextCursor == nil {
+			break
+		}
+		filter.Options.Cursor = page.NextCursor
+	}
+	assert.Equal(t, 6, count)
+
+	// stream
+	data, _ := json.Marshal(internalcalls.InternalCallFilter{})
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/internal-calls", bytes.NewReader(data))
+	req.Header.Set("Accept", "application/x-ndjson")
+	res, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	count = 0
+	for scanner := bufio.NewScanner(res.Body); scanner.Scan(); count++ {
+		var call internalcalls.FilteredInternalCall
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), &call))
+	}
+	assert.Equal(t, 6, count)
+}
+
+func TestIndexedFrom(t *testing.T) {
+	db := createDb(t)
+	initInternalCallServer(t, db, 5, 6)
+	defer ts.Close()
+	insertBlocks(t, db, 3)
+
+	// blocks failed to trace
+	w := db.NewWriter()
+	assert.Nil(t, w.MarkIndexedFrom(logdb.InternalCallTable, 3))
+	assert.Nil(t, w.Commit())
+	body, statusCode := httpPost(t, ts.URL+"/internal-calls", internalcalls.InternalCallFilter{})
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+	assert.Equal(t, "range: internal calls are indexed from block 3, until the log db is rebuilt", strings.TrimSpace(string(body)))
+
+	// not indexed at all
+	assert.Nil(t, w.MarkIndexedFrom(logdb.InternalCallTable, math.MaxUint32))
+	assert.Nil(t, w.Commit())
+	body, statusCode = httpPost(t, ts.URL+"/internal-calls", internalcalls.InternalCallFilter{})
+	assert.Equal(t, http.StatusForbidden, statusCode)
+	assert.Equal(t, "internal calls are not indexed by the node", strings.TrimSpace(string(body)))
+}
+
+func insertBlocks(t *testing.T, db *logdb.LogDB, n int) {
+	b := new(block.Builder).Build()
+	for i := 0; i < n; i++ {
+		b = new(block.Builder).
+			ParentID(b.Header().ID()).
+			Build()
+
+		w := db.NewWriter()
+		if err := w.WriteInternalCalls(b, []*logdb.InternalCall{
+			{Depth: 1, Type: "CALL", From: caller, To: &callee, Value: big.NewInt(1), Selector: selector},
+			{Depth: 2, Type: "STATICCALL", From: callee, To: &caller, Error: "execution reverted"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func initInternalCallServer(t *testing.T, logDb *logdb.LogDB, limit uint64, streamLimit uint64) {
+	router := mux.NewRouter()
+
+	muxDb := muxdb.NewMem()
+	stater := state.NewStater(muxDb)
+	gene := genesis.NewDevnet()
+
+	b, _, _, err := gene.Build(stater)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo, _ := chain.NewRepository(muxDb, b)
+
+	internalcalls.New(repo, logDb, limit, streamLimit).Mount(router, "/internal-calls")
+	ts = httptest.NewServer(router)
+}
+
+func createDb(t *testing.T) *logdb.LogDB {
+	logDb, err := logdb.New(filepath.Join(t.TempDir(), "logs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { logDb.Close() })
+	return logDb
+}
+
+func httpPost(t *testing.T, url string, body interface{}) ([]byte, int) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.Post(url, "application/x-www-form-urlencoded", bytes.NewReader(data)) // nolint: gosec
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, res.StatusCode
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package internalcalls
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/vechain/thor/v2/api/events"
+	"github.com/vechain/thor/v2/api/transfers"
+	"github.com/vechain/thor/v2/logdb"
+	"github.com/vechain/thor/v2/thor"
+)
+
+type FilteredInternalCall struct {
+	Depth    uint32                `json:"depth"`
+	Type     string                `json:"type"`
+	From     thor.Address          `json:"from"`
+	To       *thor.Address         `json:"to"`
+	Value    *math.HexOrDecimal256 `json:"value"`
+	Selector *hexutil.Bytes        `json:"selector"`
+	Error    string                `json:"error,omitempty"`
+	Meta     transfers.LogMeta     `json:"meta"`
+}
+
+func convertInternalCall(call *logdb.InternalCall) *FilteredInternalCall {
+	c := &FilteredInternalCall{
+		Depth: call.Depth,
+		Type:  call.Type,
+		From:  call.From,
+		To:    call.To,
+		Error: call.Error,
+		Meta: transfers.LogMeta{
+			BlockID:        call.BlockID,
+			BlockNumber:    call.BlockNumber,
+			BlockTimestamp: call.BlockTime,
+			TxID:           call.TxID,
+			TxOrigin:       call.TxOrigin,
+			ClauseIndex:    call.ClauseIndex,
+		},
+	}
+	if call.Value != nil {
+		v := math.HexOrDecimal256(*call.Value)
+		c.Value = &v
+	}
+	if call.Selector != nil {
+		s := hexutil.Bytes(call.Selector)
+		c.Selector = &s
+	}
+	return c
+}
+
+// InternalCallPage is a page of internal calls returned in cursor mode.
+type InternalCallPage struct {
+	Calls      []*FilteredInternalCall `json:"calls"`
+	NextCursor *logdb.Cursor           `json:"nextCursor"` // nil if there are no more calls
+}
+
+type InternalCallFilter struct {
+	CriteriaSet []*logdb.InternalCallCriteria
+	Range       *events.Range
+	Options     *logdb.Options
+	Order       logdb.Order //default asc
+}
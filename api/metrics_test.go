@@ -120,7 +120,7 @@ func TestWebsocketMetrics(t *testing.T) {
 	repo, _ := chain.NewRepository(db, b)
 
 	router := mux.NewRouter()
-	sub := subscriptions.New(repo, []string{"*"}, 10, txpool.New(repo, stater, txpool.Options{}))
+	sub := subscriptions.New(repo, []string{"*"}, 10, txpool.New(repo, stater, txpool.Options{}), nil)
 	sub.Mount(router, "/subscriptions")
 	router.PathPrefix("/metrics").Handler(metrics.HTTPHandler())
 	router.Use(metricsMiddleware)
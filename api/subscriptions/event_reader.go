@@ -6,6 +6,7 @@
 package subscriptions
 
 import (
+	"github.com/vechain/thor/v2/abi/registry"
 	"github.com/vechain/thor/v2/chain"
 	"github.com/vechain/thor/v2/thor"
 )
@@ -14,13 +15,15 @@ type eventReader struct {
 	repo        *chain.Repository
 	filter      *EventFilter
 	blockReader chain.BlockReader
+	abis        *registry.Registry
 }
 
-func newEventReader(repo *chain.Repository, position thor.Bytes32, filter *EventFilter) *eventReader {
+func newEventReader(repo *chain.Repository, position thor.Bytes32, filter *EventFilter, abis *registry.Registry) *eventReader {
 	return &eventReader{
 		repo:        repo,
 		filter:      filter,
 		blockReader: repo.NewBlockReader(position),
+		abis:        abis,
 	}
 }
 
@@ -44,6 +47,10 @@ func (er *eventReader) Read() ([]interface{}, bool, error) {
 						if err != nil {
 							return nil, false, err
 						}
+						if decoded := er.abis.DecodeEvent(event.Address, event.Topics, event.Data); decoded != nil {
+							msg.Name = decoded.Name
+							msg.Params = decoded.Params
+						}
 						msgs = append(msgs, msg)
 					}
 				}
@@ -30,7 +30,7 @@ func TestEventReader_Read(t *testing.T) {
 	assert.False(t, ok)
 
 	// Test case 2: Events are available to read
-	er = newEventReader(repo, genesisBlk.Header().ID(), &EventFilter{})
+	er = newEventReader(repo, genesisBlk.Header().ID(), &EventFilter{}, nil)
 
 	events, ok, err = er.Read()
 
@@ -13,6 +13,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
+	"github.com/vechain/thor/v2/abi/registry"
 	"github.com/vechain/thor/v2/api/utils"
 	"github.com/vechain/thor/v2/block"
 	"github.com/vechain/thor/v2/chain"
@@ -29,6 +30,7 @@ type Subscriptions struct {
 	repo           *chain.Repository
 	upgrader       *websocket.Upgrader
 	pendingTx      *pendingTx
+	abis           *registry.Registry
 	done           chan struct{}
 	wg             sync.WaitGroup
 }
@@ -48,7 +50,7 @@ const (
 	pingPeriod = (pongWait * 7) / 10
 )
 
-func New(repo *chain.Repository, allowedOrigins []string, backtraceLimit uint32, txpool *txpool.TxPool) *Subscriptions {
+func New(repo *chain.Repository, allowedOrigins []string, backtraceLimit uint32, txpool *txpool.TxPool, abis *registry.Registry) *Subscriptions {
 	sub := &Subscriptions{
 		backtraceLimit: backtraceLimit,
 		repo:           repo,
@@ -68,6 +70,7 @@ func New(repo *chain.Repository, allowedOrigins []string, backtraceLimit uint32,
 			},
 		},
 		pendingTx: newPendingTx(txpool),
+		abis:      abis,
 		done:      make(chan struct{}),
 	}
 
@@ -117,6 +120,16 @@ func (s *Subscriptions) handleEventReader(w http.ResponseWriter, req *http.Reque
 	if err != nil {
 		return nil, utils.BadRequest(errors.WithMessage(err, "t4"))
 	}
+	if event := req.URL.Query().Get("event"); event != "" {
+		id, err := s.abis.EventID(address, event)
+		if err != nil {
+			return nil, utils.BadRequest(errors.WithMessage(err, "event"))
+		}
+		if t0 != nil && *t0 != id {
+			return nil, utils.BadRequest(errors.New("event: conflicts with t0"))
+		}
+		t0 = &id
+	}
 	eventFilter := &EventFilter{
 		Address: address,
 		Topic0:  t0,
@@ -125,7 +138,7 @@ func (s *Subscriptions) handleEventReader(w http.ResponseWriter, req *http.Reque
 		Topic3:  t3,
 		Topic4:  t4,
 	}
-	return newEventReader(s.repo, position, eventFilter), nil
+	return newEventReader(s.repo, position, eventFilter, s.abis), nil
 }
 
 func (s *Subscriptions) handleTransferReader(w http.ResponseWriter, req *http.Request) (*transferReader, error) {
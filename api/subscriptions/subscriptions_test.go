@@ -41,6 +41,7 @@ func TestSubscriptions(t *testing.T) {
 		"testHandleSubjectWithBeat":             testHandleSubjectWithBeat,
 		"testHandleSubjectWithBeat2":            testHandleSubjectWithBeat2,
 		"testHandleSubjectWithNonValidArgument": testHandleSubjectWithNonValidArgument,
+		"testHandleSubjectWithUnknownEvent":     testHandleSubjectWithUnknownEvent,
 	} {
 		t.Run(name, tt)
 	}
@@ -201,6 +202,18 @@ func testHandleSubjectWithNonValidArgument(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
 }
 
+func testHandleSubjectWithUnknownEvent(t *testing.T) {
+	genesisBlock := blocks[0]
+	queryArg := fmt.Sprintf("pos=%s&event=Transfer", genesisBlock.Header().ID().String())
+	u := url.URL{Scheme: "ws", Host: strings.TrimPrefix(ts.URL, "http://"), Path: "/subscriptions/event", RawQuery: queryArg}
+
+	conn, resp, err := websocket.DefaultDialer.Dial(u.String(), nil)
+
+	assert.Error(t, err)
+	assert.Nil(t, conn)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
 func TestParseAddress(t *testing.T) {
 	addrStr := "0x0123456789abcdef0123456789abcdef01234567"
 	expectedAddr := thor.MustParseAddress(addrStr)
@@ -217,7 +230,7 @@ func initSubscriptionsServer(t *testing.T) {
 	txPool = pool
 	blocks = generatedBlocks
 	router := mux.NewRouter()
-	sub = New(repo, []string{}, 5, txPool)
+	sub = New(repo, []string{}, 5, txPool, nil)
 	sub.Mount(router, "/subscriptions")
 	ts = httptest.NewServer(router)
 	client = &http.Client{}
@@ -8,6 +8,7 @@ package subscriptions
 import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/vechain/thor/v2/abi/registry"
 	"github.com/vechain/thor/v2/block"
 	"github.com/vechain/thor/v2/chain"
 	"github.com/vechain/thor/v2/thor"
@@ -110,11 +111,13 @@ func convertTransfer(header *block.Header, tx *tx.Transaction, clauseIndex uint3
 
 // EventMessage event piped by websocket
 type EventMessage struct {
-	Address  thor.Address   `json:"address"`
-	Topics   []thor.Bytes32 `json:"topics"`
-	Data     string         `json:"data"`
-	Meta     LogMeta        `json:"meta"`
-	Obsolete bool           `json:"obsolete"`
+	Address  thor.Address      `json:"address"`
+	Topics   []thor.Bytes32    `json:"topics"`
+	Data     string            `json:"data"`
+	Name     string            `json:"name,omitempty"`   // decoded with registered ABIs
+	Params   []*registry.Param `json:"params,omitempty"` // decoded with registered ABIs
+	Meta     LogMeta           `json:"meta"`
+	Obsolete bool              `json:"obsolete"`
 }
 
 func convertEvent(header *block.Header, tx *tx.Transaction, clauseIndex uint32, event *tx.Event, obsolete bool) (*EventMessage, error) {
@@ -0,0 +1,219 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package tokentransfers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/vechain/thor/v2/api/events"
+	"github.com/vechain/thor/v2/api/utils"
+	"github.com/vechain/thor/v2/chain"
+	"github.com/vechain/thor/v2/logdb"
+)
+
+// streamFlushInterval is the number of logs written between flushes in streaming mode.
+const streamFlushInterval = 100
+
+type TokenTransfers struct {
+	repo        *chain.Repository
+	db          *logdb.LogDB
+	limit       uint64
+	streamLimit uint64
+}
+
+func New(repo *chain.Repository, db *logdb.LogDB, logsLimit uint64, streamLimit uint64) *TokenTransfers {
+	return &TokenTransfers{
+		repo,
+		db,
+		logsLimit,
+		streamLimit,
+	}
+}
+
+// filter query token transfers with option
+func (t *TokenTransfers) filter(ctx context.Context, filter *TokenTransferFilter) ([]*FilteredTokenTransfer, error) {
+	transfers, err := t.filterTokenTransfers(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	tLogs := make([]*FilteredTokenTransfer, len(transfers))
+	for i, trans := range transfers {
+		tLogs[i] = convertTokenTransfer(trans)
+	}
+	return tLogs, nil
+}
+
+// convertRange converts the range, which must not start before the first block indexed by the token transfer table.
+func (t *TokenTransfers) convertRange(r *events.Range) (*logdb.Range, error) {
+	rng, err := events.ConvertRange(t.repo.NewBestChain(), r)
+	if err != nil {
+		return nil, err
+	}
+	from, err := t.db.IndexedFrom(logdb.TokenTransferTable)
+	if err != nil {
+		return nil, err
+	}
+	if from > 0 && (rng == nil || rng.From < from) {
+		return nil, utils.BadRequest(fmt.Errorf("range: token transfers are indexed from block %d, until the log db is rebuilt", from))
+	}
+	return rng, nil
+}
+
+func (t *TokenTransfers) filterTokenTransfers(ctx context.Context, filter *TokenTransferFilter) ([]*logdb.TokenTransfer, error) {
+	rng, err := t.convertRange(filter.Range)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.db.FilterTokenTransfers(ctx, &logdb.TokenTransferFilter{
+		CriteriaSet: filter.CriteriaSet,
+		Range:       rng,
+		Options:     filter.Options,
+		Order:       filter.Order,
+	})
+}
+
+// filterPage query a page of token transfers in cursor mode.
+func (t *TokenTransfers) filterPage(ctx context.Context, tf *TokenTransferFilter) (*TokenTransferPage, error) {
+	limit := tf.Options.Limit
+	if limit == 0 {
+		limit = t.limit
+	}
+	// query one more log to detect whether there is a next page
+	filter := *tf
+	filter.Options = &logdb.Options{
+		Limit:  limit + 1,
+		Cursor: tf.Options.Cursor,
+	}
+	transfers, err := t.filterTokenTransfers(ctx, &filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &TokenTransferPage{Transfers: make([]*FilteredTokenTransfer, 0, len(transfers))}
+	if uint64(len(transfers)) > limit {
+		transfers = transfers[:limit]
+		last := transfers[len(transfers)-1]
+		page.NextCursor = logdb.NewCursor(last.BlockNumber, last.Index)
+	}
+	for _, trans := range transfers {
+		page.Transfers = append(page.Transfers, convertTokenTransfer(trans))
+	}
+	return page, nil
+}
+
+// validateCriteriaSet checks the amount bounds of each criteria.
+func validateCriteriaSet(criteriaSet []*logdb.TokenTransferCriteria) error {
+	for i, c := range criteriaSet {
+		if c == nil {
+			continue
+		}
+		if err := c.Validate(); err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("criteriaSet[%d]", i))
+		}
+	}
+	return nil
+}
+
+func (t *TokenTransfers) handleFilterTokenTransferLogs(w http.ResponseWriter, req *http.Request) error {
+	var filter TokenTransferFilter
+	if err := utils.ParseJSON(req.Body, &filter); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "body"))
+	}
+	if err := validateCriteriaSet(filter.CriteriaSet); err != nil {
+		return utils.BadRequest(err)
+	}
+	if utils.AcceptsNDJSON(req) {
+		return t.stream(w, req, &filter)
+	}
+	if filter.Options != nil && filter.Options.Limit > t.limit {
+		return utils.Forbidden(fmt.Errorf("options.limit exceeds the maximum allowed value of %d", t.limit))
+	}
+	if filter.Options != nil && filter.Options.Cursor != nil {
+		if filter.Options.Offset != 0 {
+			return utils.BadRequest(errors.New("options.offset must be zero when options.cursor is used"))
+		}
+		page, err := t.filterPage(req.Context(), &filter)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJSON(w, page)
+	}
+	if filter.Options == nil {
+		// if filter.Options is nil, set to the default limit +1
+		// to detect whether there are more logs than the default limit
+		filter.Options = &logdb.Options{
+			Offset: 0,
+			Limit:  t.limit + 1,
+		}
+	}
+
+	tLogs, err := t.filter(req.Context(), &filter)
+	if err != nil {
+		return err
+	}
+
+	// ensure the result size is less than the configured limit
+	if len(tLogs) > int(t.limit) {
+		return utils.Forbidden(fmt.Errorf("the number of filtered logs exceeds the maximum allowed value of %d, please use pagination", t.limit))
+	}
+
+	return utils.WriteJSON(w, tLogs)
+}
+
+// stream writes filtered transfers as newline delimited JSON while they are read from the db. The number of transfers
+// is limited by the stream limit, and the response is aborted if more transfers are matched.
+func (t *TokenTransfers) stream(w http.ResponseWriter, req *http.Request, tf *TokenTransferFilter) error {
+	if t.streamLimit == 0 {
+		return utils.Forbidden(errors.New("streaming is not enabled"))
+	}
+	if tf.Options != nil && tf.Options.Limit > t.streamLimit {
+		return utils.Forbidden(fmt.Errorf("options.limit exceeds the maximum allowed value of %d", t.streamLimit))
+	}
+	rng, err := t.convertRange(tf.Range)
+	if err != nil {
+		return err
+	}
+	options := tf.Options
+	if options == nil {
+		// query one more log to detect whether the stream limit is exceeded
+		options = &logdb.Options{Limit: t.streamLimit + 1}
+	}
+
+	nw := utils.NewNDJSONWriter(w, streamFlushInterval)
+	if err := t.db.IterateTokenTransfers(req.Context(), &logdb.TokenTransferFilter{
+		CriteriaSet: tf.CriteriaSet,
+		Range:       rng,
+		Options:     options,
+		Order:       tf.Order,
+	}, func(tr *logdb.TokenTransfer) error {
+		if uint64(nw.Count()) >= t.streamLimit {
+			return fmt.Errorf("the number of filtered logs exceeds the maximum allowed value of %d", t.streamLimit)
+		}
+		return nw.Write(convertTokenTransfer(tr))
+	}); err != nil {
+		if nw.Count() == 0 {
+			return err
+		}
+		// the response is partially written, abort it to let the client know it's incomplete
+		panic(http.ErrAbortHandler)
+	}
+	nw.Flush()
+	return nil
+}
+
+func (t *TokenTransfers) Mount(root *mux.Router, pathPrefix string) {
+	sub := root.PathPrefix(pathPrefix).Subrouter()
+
+	sub.Path("").
+		Methods(http.MethodPost).
+		Name("logs_filter_token_transfer").
+		HandlerFunc(utils.WrapHandlerFunc(t.handleFilterTokenTransferLogs))
+}
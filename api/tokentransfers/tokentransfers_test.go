@@ -0,0 +1,245 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package tokentransfers_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/vechain/thor/v2/api/tokentransfers"
+	"github.com/vechain/thor/v2/block"
+	"github.com/vechain/thor/v2/chain"
+	"github.com/vechain/thor/v2/genesis"
+	"github.com/vechain/thor/v2/logdb"
+	"github.com/vechain/thor/v2/muxdb"
+	"github.com/vechain/thor/v2/state"
+	"github.com/vechain/thor/v2/thor"
+	"github.com/vechain/thor/v2/tx"
+)
+
+var (
+	ts     *httptest.Server
+	token1 = randAddress()
+	token2 = randAddress()
+)
+
+func TestTokenTransfers(t *testing.T) {
+	db := createDb(t)
+	initTokenTransferServer(t, db, 5, 0)
+	defer ts.Close()
+	insertBlocks(t, db, 3)
+
+	// bad request
+	res, err := http.Post(ts.URL+"/token-transfers", "application/x-www-form-urlencoded", strings.NewReader("{\"criteriaSet\": 1}")) // nolint:gosec
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	res.Body.Close()
+
+	// the in-memory db may be shared with other tests
+	all, err := db.FilterTokenTransfers(context.Background(), &logdb.TokenTransferFilter{
+		CriteriaSet: []*logdb.TokenTransferCriteria{{Token: &token1}},
+		Order:       logdb.DESC,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, len(all))
+
+	filter := tokentransfers.TokenTransferFilter{
+		CriteriaSet: []*logdb.TokenTransferCriteria{{Token: &token1}},
+		Order:       logdb.DESC,
+	}
+	body, statusCode := httpPost(t, ts.URL+"/token-transfers", filter)
+	assert.Equal(t, http.StatusOK, statusCode)
+	var tLogs []*tokentransfers.FilteredTokenTransfer
+	if err := json.Unmarshal(body, &tLogs); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(all), len(tLogs))
+	for i, tr := range tLogs {
+		assert.Equal(t, token1, tr.Token)
+		assert.Equal(t, all[i].Sender, tr.Sender)
+		assert.Equal(t, all[i].Recipient, tr.Recipient)
+		assert.Equal(t, all[i].BlockNumber, tr.Meta.BlockNumber)
+		assert.Zero(t, all[i].Amount.Cmp((*big.Int)(tr.Amount)))
+	}
+
+	// limit exceeded
+	filter.Options = &logdb.Options{Limit: 6}
+	body, statusCode = httpPost(t, ts.URL+"/token-transfers", filter)
+	assert.Equal(t, http.StatusForbidden, statusCode)
+	assert.Equal(t, "options.limit exceeds the maximum allowed value of 5", strings.Trim(string(body), "\n"))
+
+	// negative amount bound
+	_, statusCode = httpPost(t, ts.URL+"/token-transfers", json.RawMessage(`{"criteriaSet": [{"maxAmount": "-1"}]}`))
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+
+	// cursor
+	filter.Options = &logdb.Options{Limit: 2, Cursor: &logdb.Cursor{}}
+	var blockNums []uint32
+	for {
+		body, statusCode = httpPost(t, ts.URL+"/token-transfers", filter)
+		assert.Equal(t, http.StatusOK, statusCode)
+		var page tokentransfers.TokenTransferPage
+		if err := json.Unmarshal(body, &page); err != nil {
+			t.Fatal(err)
+		}
+		for _, tr := range page.Transfers {
+			blockNums = append(blockNums, tr.Meta.BlockNumber)
+		}
+		if page.NextCursor == nil {
+			break
+		}
+		filter.Options.Cursor = page.NextCursor
+	}
+	assert.Equal(t, []uint32{all[0].BlockNumber, all[1].BlockNumber, all[2].BlockNumber}, blockNums)
+}
+
+func TestIndexedFrom(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.db")
+	db, err := logdb.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	insertBlocks(t, db, 3)
+	db.Close()
+
+	// the token transfer table is added to a log db with logs written
+	sdb, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sdb.Exec("DROP TABLE token_transfer"); err != nil {
+		t.Fatal(err)
+	}
+	sdb.Close()
+
+	db, err = logdb.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	initTokenTransferServer(t, db, 5, 0)
+	defer ts.Close()
+
+	body, statusCode := httpPost(t, ts.URL+"/token-transfers", tokentransfers.TokenTransferFilter{})
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+	assert.Equal(t, "range: token transfers are indexed from block 5, until the log db is rebuilt", strings.TrimSpace(string(body)))
+
+	body, statusCode = httpPost(t, ts.URL+"/token-transfers", json.RawMessage(`{"range": {"unit": "block", "from": 5, "to": 10}}`))
+	assert.Equal(t, http.StatusOK, statusCode, string(body))
+}
+
+func insertBlocks(t *testing.T, db *logdb.LogDB, n int) {
+	b := new(block.Builder).Build()
+	for i := 0; i < n; i++ {
+		b = new(block.Builder).
+			ParentID(b.Header().ID()).
+			Build()
+		receipts := tx.Receipts{newReceipt()}
+
+		w := db.NewWriter()
+		if err := w.Write(b, receipts); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := w.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func initTokenTransferServer(t *testing.T, logDb *logdb.LogDB, limit uint64, streamLimit uint64) {
+	router := mux.NewRouter()
+
+	muxDb := muxdb.NewMem()
+	stater := state.NewStater(muxDb)
+	gene := genesis.NewDevnet()
+
+	b, _, _, err := gene.Build(stater)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo, _ := chain.NewRepository(muxDb, b)
+
+	tokentransfers.New(repo, logDb, limit, streamLimit).Mount(router, "/token-transfers")
+	ts = httptest.NewServer(router)
+}
+
+func createDb(t *testing.T) *logdb.LogDB {
+	logDb, err := logdb.NewMem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return logDb
+}
+
+// Utilities functions
+func randAddress() (addr thor.Address) {
+	rand.Read(addr[:])
+	return
+}
+
+func transferEvent(token, from, to thor.Address, amount *big.Int) *tx.Event {
+	var fromTopic, toTopic thor.Bytes32
+	copy(fromTopic[12:], from[:])
+	copy(toTopic[12:], to[:])
+	return &tx.Event{
+		Address: token,
+		Topics: []thor.Bytes32{
+			thor.MustParseBytes32("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"),
+			fromTopic,
+			toTopic,
+		},
+		Data: math.PaddedBigBytes(amount, 32),
+	}
+}
+
+func newReceipt() *tx.Receipt {
+	return &tx.Receipt{
+		Outputs: []*tx.Output{
+			{
+				Events: tx.Events{
+					transferEvent(token1, randAddress(), randAddress(), new(big.Int).SetBytes(randAddress().Bytes())),
+					transferEvent(token2, randAddress(), randAddress(), new(big.Int).SetBytes(randAddress().Bytes())),
+				},
+			},
+		},
+	}
+}
+
+func httpPost(t *testing.T, url string, body interface{}) ([]byte, int) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.Post(url, "application/x-www-form-urlencoded", bytes.NewReader(data)) // nolint: gosec
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, res.StatusCode
+}
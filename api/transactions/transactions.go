@@ -6,27 +6,37 @@
 package transactions
 
 import (
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
+	"github.com/vechain/thor/v2/api/events"
 	"github.com/vechain/thor/v2/api/utils"
 	"github.com/vechain/thor/v2/chain"
+	"github.com/vechain/thor/v2/logdb"
 	"github.com/vechain/thor/v2/thor"
 	"github.com/vechain/thor/v2/txpool"
 )
 
 type Transactions struct {
-	repo *chain.Repository
-	pool *txpool.TxPool
+	repo  *chain.Repository
+	pool  *txpool.TxPool
+	db    *logdb.LogDB
+	limit uint64
 }
 
-func New(repo *chain.Repository, pool *txpool.TxPool) *Transactions {
+// New creates the transactions API. The transactions filter is not mounted if db is nil.
+func New(repo *chain.Repository, pool *txpool.TxPool, db *logdb.LogDB, logsLimit uint64) *Transactions {
 	return &Transactions{
 		repo,
 		pool,
+		db,
+		logsLimit,
 	}
 }
 
@@ -203,6 +213,99 @@ func (t *Transactions) handleGetTransactionReceiptByID(w http.ResponseWriter, re
 	return utils.WriteJSON(w, receipt)
 }
 
+func (t *Transactions) handleFilterTransactions(w http.ResponseWriter, req *http.Request) error {
+	var (
+		query    = req.URL.Query()
+		criteria logdb.TxCriteria
+		err      error
+	)
+	if criteria.Origin, err = parseAddress(query.Get("origin")); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "origin"))
+	}
+	if criteria.Recipient, err = parseAddress(query.Get("to")); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "to"))
+	}
+	if criteria.Delegator, err = parseAddress(query.Get("delegator")); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "delegator"))
+	}
+	if criteria.Origin == nil && criteria.Recipient == nil && criteria.Delegator == nil {
+		return utils.BadRequest(errors.New("at least one of origin, to and delegator is required"))
+	}
+
+	rng, err := parseRange(query.Get("unit"), query.Get("start"), query.Get("end"))
+	if err != nil {
+		return utils.BadRequest(err)
+	}
+
+	order := logdb.Order(query.Get("order"))
+	if order != "" && order != logdb.ASC && order != logdb.DESC {
+		return utils.BadRequest(errors.WithMessage(errors.New("should be asc or desc"), "order"))
+	}
+
+	offset, err := parseUint(query.Get("offset"), 0)
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "offset"))
+	}
+	limit, err := parseUint(query.Get("limit"), 0)
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "limit"))
+	}
+	if limit == 0 {
+		limit = t.limit
+	} else if limit > t.limit {
+		return utils.Forbidden(fmt.Errorf("limit exceeds the maximum allowed value of %d", t.limit))
+	}
+
+	var cursor *logdb.Cursor
+	if query.Has("cursor") {
+		if offset != 0 {
+			return utils.BadRequest(errors.New("offset must be zero when cursor is used"))
+		}
+		cursor = &logdb.Cursor{}
+		if err := cursor.UnmarshalText([]byte(query.Get("cursor"))); err != nil {
+			return utils.BadRequest(errors.WithMessage(err, "cursor"))
+		}
+	}
+
+	logRange, err := events.ConvertRange(t.repo.NewBestChain(), rng)
+	if err != nil {
+		return err
+	}
+	// txs of blocks written before the tx table is added are missing until the log db is rebuilt
+	indexedFrom, err := t.db.IndexedFrom(logdb.TxTable)
+	if err != nil {
+		return err
+	}
+	if indexedFrom > 0 && (logRange == nil || logRange.From < indexedFrom) {
+		return utils.BadRequest(fmt.Errorf("start: transactions are indexed from block %d, until the log db is rebuilt", indexedFrom))
+	}
+	// query one more tx to detect whether there is a next page
+	txs, err := t.db.FilterTxs(req.Context(), &logdb.TxFilter{
+		CriteriaSet: []*logdb.TxCriteria{&criteria},
+		Range:       logRange,
+		Options: &logdb.Options{
+			Offset: offset,
+			Limit:  limit + 1,
+			Cursor: cursor,
+		},
+		Order: order,
+	})
+	if err != nil {
+		return err
+	}
+
+	page := &TransactionPage{Transactions: make([]*FilteredTransaction, 0, len(txs))}
+	if uint64(len(txs)) > limit {
+		txs = txs[:limit]
+		last := txs[len(txs)-1]
+		page.NextCursor = logdb.NewCursor(last.BlockNumber, last.Index)
+	}
+	for _, tx := range txs {
+		page.Transactions = append(page.Transactions, convertFilteredTransaction(tx))
+	}
+	return utils.WriteJSON(w, page)
+}
+
 func (t *Transactions) parseHead(head string) (thor.Bytes32, error) {
 	if head == "" {
 		return t.repo.BestBlockSummary().Header.ID(), nil
@@ -221,6 +324,12 @@ func (t *Transactions) Mount(root *mux.Router, pathPrefix string) {
 		Methods(http.MethodPost).
 		Name("transactions_send_tx").
 		HandlerFunc(utils.WrapHandlerFunc(t.handleSendTransaction))
+	if t.db != nil {
+		sub.Path("").
+			Methods(http.MethodGet).
+			Name("transactions_filter_tx").
+			HandlerFunc(utils.WrapHandlerFunc(t.handleFilterTransactions))
+	}
 	sub.Path("/{id}").
 		Methods(http.MethodGet).
 		Name("transactions_get_tx").
@@ -230,3 +339,51 @@ func (t *Transactions) Mount(root *mux.Router, pathPrefix string) {
 		Name("transactions_get_receipt").
 		HandlerFunc(utils.WrapHandlerFunc(t.handleGetTransactionReceiptByID))
 }
+
+func parseAddress(s string) (*thor.Address, error) {
+	if s == "" {
+		return nil, nil
+	}
+	addr, err := thor.ParseAddress(s)
+	if err != nil {
+		return nil, err
+	}
+	return &addr, nil
+}
+
+func parseUint(s string, def uint64) (uint64, error) {
+	if s == "" {
+		return def, nil
+	}
+	return strconv.ParseUint(s, 0, 64)
+}
+
+// parseRange parses the range of the transactions filter, it's nil if both start and end are absent.
+func parseRange(unit, start, end string) (*events.Range, error) {
+	if start == "" && end == "" {
+		if unit != "" {
+			return nil, errors.WithMessage(errors.New("requires start or end"), "unit")
+		}
+		return nil, nil
+	}
+	rng := &events.Range{Unit: events.RangeType(unit)}
+	switch rng.Unit {
+	case "":
+		rng.Unit = events.BlockRangeType
+	case events.BlockRangeType, events.TimeRangeType:
+	default:
+		return nil, errors.WithMessage(errors.New("should be block or time"), "unit")
+	}
+
+	var err error
+	if rng.From, err = parseUint(start, 0); err != nil {
+		return nil, errors.WithMessage(err, "start")
+	}
+	if rng.To, err = parseUint(end, math.MaxUint64); err != nil {
+		return nil, errors.WithMessage(err, "end")
+	}
+	if rng.From > rng.To {
+		return nil, errors.New("start must not be greater than end")
+	}
+	return rng, nil
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/vechain/thor/v2/api/transactions"
 	"github.com/vechain/thor/v2/chain"
 	"github.com/vechain/thor/v2/genesis"
+	"github.com/vechain/thor/v2/logdb"
 	"github.com/vechain/thor/v2/muxdb"
 	"github.com/vechain/thor/v2/packer"
 	"github.com/vechain/thor/v2/state"
@@ -67,6 +68,14 @@ func TestTransaction(t *testing.T) {
 		t.Run(name, tt)
 	}
 
+	// Filter txs
+	for name, tt := range map[string]func(*testing.T){
+		"filterTxs":              filterTxs,
+		"filterTxsWithBadParams": filterTxsWithBadParams,
+	} {
+		t.Run(name, tt)
+	}
+
 	// Get tx receipt
 	for name, tt := range map[string]func(*testing.T){
 		"getTxReceipt":        getTxReceipt,
@@ -97,6 +106,65 @@ func getTx(t *testing.T) {
 	assert.Equal(t, hexutil.Encode(rlpTx), rawTx["raw"], "should be equal raw")
 }
 
+func filterTxs(t *testing.T) {
+	origin := genesis.DevAccounts()[0].Address
+	to := thor.BytesToAddress([]byte("to"))
+	for _, query := range []string{
+		"origin=" + origin.String(),
+		"to=" + to.String(),
+		"origin=" + origin.String() + "&to=" + to.String() + "&order=desc&unit=block&start=0&end=1",
+		"to=" + to.String() + "&cursor=",
+	} {
+		res := httpGetAndCheckResponseStatus(t, ts.URL+"/transactions?"+query, 200)
+		var page transactions.TransactionPage
+		if err := json.Unmarshal(res, &page); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 1, len(page.Transactions), query)
+		assert.Nil(t, page.NextCursor)
+		assert.Equal(t, transaction.ID(), page.Transactions[0].ID)
+		assert.Equal(t, origin, page.Transactions[0].Origin)
+		assert.Nil(t, page.Transactions[0].Delegator)
+		assert.Equal(t, uint32(1), page.Transactions[0].Meta.BlockNumber)
+	}
+
+	for _, query := range []string{
+		"delegator=" + origin.String(),
+		"to=" + origin.String(),
+		"to=" + to.String() + "&start=2",
+	} {
+		res := httpGetAndCheckResponseStatus(t, ts.URL+"/transactions?"+query, 200)
+		var page transactions.TransactionPage
+		if err := json.Unmarshal(res, &page); err != nil {
+			t.Fatal(err)
+		}
+		assert.Empty(t, page.Transactions, query)
+	}
+}
+
+func filterTxsWithBadParams(t *testing.T) {
+	to := thor.BytesToAddress([]byte("to")).String()
+	tests := []struct {
+		query  string
+		status int
+		body   string
+	}{
+		{"", 400, "at least one of origin, to and delegator is required"},
+		{"origin=0x1", 400, "origin: "},
+		{"to=" + to + "&unit=day&start=1", 400, "unit: should be block or time"},
+		{"to=" + to + "&unit=block", 400, "unit: requires start or end"},
+		{"to=" + to + "&start=2&end=1", 400, "start must not be greater than end"},
+		{"to=" + to + "&order=up", 400, "order: should be asc or desc"},
+		{"to=" + to + "&limit=11", 403, "limit exceeds the maximum allowed value of 10"},
+		{"to=" + to + "&offset=1&cursor=", 400, "offset must be zero when cursor is used"},
+		{"to=" + to + "&cursor=zz", 400, "cursor: "},
+	}
+	for _, tt := range tests {
+		res := httpGetAndCheckResponseStatus(t, ts.URL+"/transactions?"+tt.query, tt.status)
+		assert.Contains(t, string(res), tt.body, tt.query)
+	}
+}
+
 func getTxReceipt(t *testing.T) {
 	r := httpGetAndCheckResponseStatus(t, ts.URL+"/transactions/"+transaction.ID().String()+"/receipt", 200)
 	var receipt *transactions.Receipt
@@ -349,7 +417,19 @@ func initTransactionServer(t *testing.T) {
 		t.Fatal(e)
 	}
 
-	transactions.New(repo, mempool).Mount(router, "/transactions")
+	logDB, err := logdb.NewMem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := logDB.NewWriter()
+	if err := w.Write(b, receipts); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	transactions.New(repo, mempool, logDB, 10).Mount(router, "/transactions")
 
 	ts = httptest.NewServer(router)
 }
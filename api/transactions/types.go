@@ -12,6 +12,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/vechain/thor/v2/block"
+	"github.com/vechain/thor/v2/logdb"
 	"github.com/vechain/thor/v2/thor"
 	"github.com/vechain/thor/v2/tx"
 )
@@ -125,6 +126,35 @@ type TxMeta struct {
 	BlockTimestamp uint64       `json:"blockTimestamp"`
 }
 
+// FilteredTransaction is a transaction matched by the transactions filter.
+type FilteredTransaction struct {
+	ID        thor.Bytes32  `json:"id"`
+	Origin    thor.Address  `json:"origin"`
+	Delegator *thor.Address `json:"delegator"`
+	Index     uint32        `json:"index"` // index of the tx in the block
+	Meta      TxMeta        `json:"meta"`
+}
+
+func convertFilteredTransaction(tx *logdb.Tx) *FilteredTransaction {
+	return &FilteredTransaction{
+		ID:        tx.TxID,
+		Origin:    tx.Origin,
+		Delegator: tx.Delegator,
+		Index:     tx.Index,
+		Meta: TxMeta{
+			BlockID:        tx.BlockID,
+			BlockNumber:    tx.BlockNumber,
+			BlockTimestamp: tx.BlockTime,
+		},
+	}
+}
+
+// TransactionPage is a page of transactions returned by the transactions filter.
+type TransactionPage struct {
+	Transactions []*FilteredTransaction `json:"transactions"`
+	NextCursor   *logdb.Cursor          `json:"nextCursor"` // nil if there are no more transactions
+}
+
 type ReceiptMeta struct {
 	BlockID        thor.Bytes32 `json:"blockID"`
 	BlockNumber    uint32       `json:"blockNumber"`
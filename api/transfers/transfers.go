@@ -18,41 +18,92 @@ import (
 	"github.com/vechain/thor/v2/logdb"
 )
 
+// streamFlushInterval is the number of logs written between flushes in streaming mode.
+const streamFlushInterval = 100
+
 type Transfers struct {
-	repo  *chain.Repository
-	db    *logdb.LogDB
-	limit uint64
+	repo        *chain.Repository
+	db          *logdb.LogDB
+	limit       uint64
+	streamLimit uint64
 }
 
-func New(repo *chain.Repository, db *logdb.LogDB, logsLimit uint64) *Transfers {
+func New(repo *chain.Repository, db *logdb.LogDB, logsLimit uint64, streamLimit uint64) *Transfers {
 	return &Transfers{
 		repo,
 		db,
 		logsLimit,
+		streamLimit,
 	}
 }
 
 // Filter query logs with option
 func (t *Transfers) filter(ctx context.Context, filter *TransferFilter) ([]*FilteredTransfer, error) {
+	transfers, err := t.filterTransfers(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	tLogs := make([]*FilteredTransfer, len(transfers))
+	for i, trans := range transfers {
+		tLogs[i] = convertTransfer(trans)
+	}
+	return tLogs, nil
+}
+
+func (t *Transfers) filterTransfers(ctx context.Context, filter *TransferFilter) ([]*logdb.Transfer, error) {
 	rng, err := events.ConvertRange(t.repo.NewBestChain(), filter.Range)
 	if err != nil {
 		return nil, err
 	}
 
-	transfers, err := t.db.FilterTransfers(ctx, &logdb.TransferFilter{
+	return t.db.FilterTransfers(ctx, &logdb.TransferFilter{
 		CriteriaSet: filter.CriteriaSet,
 		Range:       rng,
 		Options:     filter.Options,
 		Order:       filter.Order,
 	})
+}
+
+// filterPage query a page of transfers in cursor mode.
+func (t *Transfers) filterPage(ctx context.Context, tf *TransferFilter) (*TransferPage, error) {
+	limit := tf.Options.Limit
+	if limit == 0 {
+		limit = t.limit
+	}
+	// query one more log to detect whether there is a next page
+	filter := *tf
+	filter.Options = &logdb.Options{
+		Limit:  limit + 1,
+		Cursor: tf.Options.Cursor,
+	}
+	transfers, err := t.filterTransfers(ctx, &filter)
 	if err != nil {
 		return nil, err
 	}
-	tLogs := make([]*FilteredTransfer, len(transfers))
-	for i, trans := range transfers {
-		tLogs[i] = convertTransfer(trans)
+
+	page := &TransferPage{Transfers: make([]*FilteredTransfer, 0, len(transfers))}
+	if uint64(len(transfers)) > limit {
+		transfers = transfers[:limit]
+		last := transfers[len(transfers)-1]
+		page.NextCursor = logdb.NewCursor(last.BlockNumber, last.Index)
 	}
-	return tLogs, nil
+	for _, trans := range transfers {
+		page.Transfers = append(page.Transfers, convertTransfer(trans))
+	}
+	return page, nil
+}
+
+// validateCriteriaSet checks the amount bounds of each criteria.
+func validateCriteriaSet(criteriaSet []*logdb.TransferCriteria) error {
+	for i, c := range criteriaSet {
+		if c == nil {
+			continue
+		}
+		if err := c.Validate(); err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("criteriaSet[%d]", i))
+		}
+	}
+	return nil
 }
 
 func (t *Transfers) handleFilterTransferLogs(w http.ResponseWriter, req *http.Request) error {
@@ -60,9 +111,25 @@ func (t *Transfers) handleFilterTransferLogs(w http.ResponseWriter, req *http.Re
 	if err := utils.ParseJSON(req.Body, &filter); err != nil {
 		return utils.BadRequest(errors.WithMessage(err, "body"))
 	}
+	if err := validateCriteriaSet(filter.CriteriaSet); err != nil {
+		return utils.BadRequest(err)
+	}
+	if utils.AcceptsNDJSON(req) {
+		return t.stream(w, req, &filter)
+	}
 	if filter.Options != nil && filter.Options.Limit > t.limit {
 		return utils.Forbidden(fmt.Errorf("options.limit exceeds the maximum allowed value of %d", t.limit))
 	}
+	if filter.Options != nil && filter.Options.Cursor != nil {
+		if filter.Options.Offset != 0 {
+			return utils.BadRequest(errors.New("options.offset must be zero when options.cursor is used"))
+		}
+		page, err := t.filterPage(req.Context(), &filter)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJSON(w, page)
+	}
 	if filter.Options == nil {
 		// if filter.Options is nil, set to the default limit +1
 		// to detect whether there are more logs than the default limit
@@ -85,6 +152,92 @@ func (t *Transfers) handleFilterTransferLogs(w http.ResponseWriter, req *http.Re
 	return utils.WriteJSON(w, tLogs)
 }
 
+// stream writes filtered transfers as newline delimited JSON while they are read from the db. The number of transfers
+// is limited by the stream limit, and the response is aborted if more transfers are matched.
+func (t *Transfers) stream(w http.ResponseWriter, req *http.Request, tf *TransferFilter) error {
+	if t.streamLimit == 0 {
+		return utils.Forbidden(errors.New("streaming is not enabled"))
+	}
+	if tf.Options != nil && tf.Options.Limit > t.streamLimit {
+		return utils.Forbidden(fmt.Errorf("options.limit exceeds the maximum allowed value of %d", t.streamLimit))
+	}
+	rng, err := events.ConvertRange(t.repo.NewBestChain(), tf.Range)
+	if err != nil {
+		return err
+	}
+	options := tf.Options
+	if options == nil {
+		// query one more transfer to detect whether the stream limit is exceeded
+		options = &logdb.Options{Limit: t.streamLimit + 1}
+	}
+
+	nw := utils.NewNDJSONWriter(w, streamFlushInterval)
+	if err := t.db.IterateTransfers(req.Context(), &logdb.TransferFilter{
+		CriteriaSet: tf.CriteriaSet,
+		Range:       rng,
+		Options:     options,
+		Order:       tf.Order,
+	}, func(tr *logdb.Transfer) error {
+		if uint64(nw.Count()) >= t.streamLimit {
+			return fmt.Errorf("the number of filtered logs exceeds the maximum allowed value of %d", t.streamLimit)
+		}
+		return nw.Write(convertTransfer(tr))
+	}); err != nil {
+		if nw.Count() == 0 {
+			return err
+		}
+		// the response is partially written, abort it to let the client know it's incomplete
+		panic(http.ErrAbortHandler)
+	}
+	nw.Flush()
+	return nil
+}
+
+func (t *Transfers) handleStats(w http.ResponseWriter, req *http.Request) error {
+	var filter TransferStatsFilter
+	if err := utils.ParseJSON(req.Body, &filter); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "body"))
+	}
+	if err := validateCriteriaSet(filter.CriteriaSet); err != nil {
+		return utils.BadRequest(err)
+	}
+	switch filter.GroupBy {
+	case logdb.GroupByNone, logdb.GroupBySender, logdb.GroupByRecipient:
+	case logdb.GroupByBlock:
+		if filter.BucketSize == 0 {
+			return utils.BadRequest(errors.New("bucketSize: must be positive when grouped by block"))
+		}
+	default:
+		return utils.BadRequest(errors.New("groupBy: unsupported " + string(filter.GroupBy)))
+	}
+
+	rng, err := events.ConvertRange(t.repo.NewBestChain(), filter.Range)
+	if err != nil {
+		return err
+	}
+	stats, err := t.db.TransferStatistics(req.Context(), &logdb.TransferFilter{
+		CriteriaSet: filter.CriteriaSet,
+		Range:       rng,
+		Order:       filter.Order,
+	}, &logdb.Aggregation{
+		GroupBy:    filter.GroupBy,
+		BucketSize: filter.BucketSize,
+		Limit:      t.limit + 1,
+	})
+	if err != nil {
+		return err
+	}
+	if uint64(len(stats)) > t.limit {
+		return utils.Forbidden(fmt.Errorf("the number of groups exceeds the maximum allowed value of %d, please narrow the range", t.limit))
+	}
+
+	results := make([]*TransferStats, len(stats))
+	for i, st := range stats {
+		results[i] = convertTransferStats(st)
+	}
+	return utils.WriteJSON(w, results)
+}
+
 func (t *Transfers) Mount(root *mux.Router, pathPrefix string) {
 	sub := root.PathPrefix(pathPrefix).Subrouter()
 
@@ -92,4 +245,8 @@ func (t *Transfers) Mount(root *mux.Router, pathPrefix string) {
 		Methods(http.MethodPost).
 		Name("logs_filter_transfer").
 		HandlerFunc(utils.WrapHandlerFunc(t.handleFilterTransferLogs))
+	sub.Path("/stats").
+		Methods(http.MethodPost).
+		Name("logs_stats_transfer").
+		HandlerFunc(utils.WrapHandlerFunc(t.handleStats))
 }
@@ -7,8 +7,10 @@ package transfers_test
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/json"
+	"fmt"
 	"io"
 	"math/big"
 	"net/http"
@@ -16,6 +18,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/vechain/thor/v2/api/events"
@@ -36,7 +39,7 @@ var ts *httptest.Server
 
 func TestEmptyTransfers(t *testing.T) {
 	db := createDb(t)
-	initTransferServer(t, db, defaultLogLimit)
+	initTransferServer(t, db, defaultLogLimit, 0)
 	defer ts.Close()
 
 	testTransferBadRequest(t)
@@ -45,7 +48,7 @@ func TestEmptyTransfers(t *testing.T) {
 
 func TestTransfers(t *testing.T) {
 	db := createDb(t)
-	initTransferServer(t, db, defaultLogLimit)
+	initTransferServer(t, db, defaultLogLimit, 0)
 	defer ts.Close()
 
 	blocksToInsert := 5
@@ -56,7 +59,7 @@ func TestTransfers(t *testing.T) {
 
 func TestOption(t *testing.T) {
 	db := createDb(t)
-	initTransferServer(t, db, 5)
+	initTransferServer(t, db, 5, 0)
 	defer ts.Close()
 	insertBlocks(t, db, 5)
 
@@ -94,6 +97,171 @@ func TestOption(t *testing.T) {
 	assert.Equal(t, "the number of filtered logs exceeds the maximum allowed value of 5, please use pagination", strings.Trim(string(res), "\n"))
 }
 
+func TestCursor(t *testing.T) {
+	db := createDb(t)
+	initTransferServer(t, db, 5, 0)
+	defer ts.Close()
+	insertBlocks(t, db, 7)
+
+	filter := transfers.TransferFilter{
+		Options: &logdb.Options{Limit: 3, Cursor: &logdb.Cursor{}},
+	}
+
+	var blockNums []uint32
+	for i := 0; i < 3; i++ {
+		res, statusCode := httpPost(t, ts.URL+"/transfers", filter)
+		assert.Equal(t, http.StatusOK, statusCode)
+
+		var page transfers.TransferPage
+		if err := json.Unmarshal(res, &page); err != nil {
+			t.Fatal(err)
+		}
+		for _, tr := range page.Transfers {
+			blockNums = append(blockNums, tr.Meta.BlockNumber)
+		}
+		if i < 2 {
+			assert.NotNil(t, page.NextCursor)
+		} else {
+			assert.Nil(t, page.NextCursor)
+		}
+		filter.Options.Cursor = page.NextCursor
+	}
+	assert.Equal(t, []uint32{2, 3, 4, 5, 6, 7, 8}, blockNums)
+}
+
+func TestStream(t *testing.T) {
+	db := createDb(t)
+	initTransferServer(t, db, 5, 10)
+	defer ts.Close()
+	insertBlocks(t, db, 7)
+
+	// the stream limit is applied instead of the logs limit
+	body, err := json.Marshal(transfers.TransferFilter{Options: &logdb.Options{Offset: 1, Limit: 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/transfers", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "application/x-ndjson")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/x-ndjson", res.Header.Get("Content-Type"))
+
+	var blockNums []uint32
+	dec := json.NewDecoder(res.Body)
+	for dec.More() {
+		var tr transfers.FilteredTransfer
+		if err := dec.Decode(&tr); err != nil {
+			t.Fatal(err)
+		}
+		blockNums = append(blockNums, tr.Meta.BlockNumber)
+	}
+	assert.Equal(t, []uint32{3, 4, 5, 6, 7, 8}, blockNums)
+}
+
+func TestStats(t *testing.T) {
+	db := createDb(t)
+	initTransferServer(t, db, defaultLogLimit, 0)
+	defer ts.Close()
+	insertBlocks(t, db, 5)
+
+	// the in-memory db may be shared with other tests
+	all, err := db.FilterTransfers(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum, min, max := new(big.Int), all[0].Amount, all[0].Amount
+	for _, tr := range all {
+		sum.Add(sum, tr.Amount)
+		if tr.Amount.Cmp(min) < 0 {
+			min = tr.Amount
+		}
+		if tr.Amount.Cmp(max) > 0 {
+			max = tr.Amount
+		}
+	}
+
+	res, statusCode := httpPost(t, ts.URL+"/transfers/stats", transfers.TransferStatsFilter{})
+	assert.Equal(t, http.StatusOK, statusCode)
+	var stats []*transfers.TransferStats
+	if err := json.Unmarshal(res, &stats); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(stats))
+	assert.Equal(t, uint64(len(all)), stats[0].Count)
+	assert.Equal(t, sum, (*big.Int)(stats[0].Sum))
+	assert.Equal(t, min, (*big.Int)(stats[0].Min))
+	assert.Equal(t, max, (*big.Int)(stats[0].Max))
+
+	res, statusCode = httpPost(t, ts.URL+"/transfers/stats", transfers.TransferStatsFilter{GroupBy: logdb.GroupByRecipient})
+	assert.Equal(t, http.StatusOK, statusCode)
+	stats = nil
+	if err := json.Unmarshal(res, &stats); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(all), len(stats))
+	for _, st := range stats {
+		assert.Equal(t, uint64(1), st.Count)
+		assert.NotNil(t, st.Address)
+	}
+
+	_, statusCode = httpPost(t, ts.URL+"/transfers/stats", transfers.TransferStatsFilter{GroupBy: logdb.GroupByAddress})
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+}
+
+func TestAmountCriteria(t *testing.T) {
+	db := createDb(t)
+	initTransferServer(t, db, defaultLogLimit, 0)
+	defer ts.Close()
+	insertBlocks(t, db, 5)
+
+	all, err := db.FilterTransfers(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	min := all[0].Amount
+	var want int
+	for _, tr := range all {
+		if tr.Amount.Cmp(min) >= 0 {
+			want++
+		}
+	}
+
+	body := fmt.Sprintf(`{"criteriaSet": [{"minAmount": "%v", "clauseIndex": 0}]}`, hexutil.EncodeBig(min))
+	res, err := http.Post(ts.URL+"/transfers", "application/json", strings.NewReader(body)) // nolint:gosec
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	var tLogs []*transfers.FilteredTransfer
+	if err := json.NewDecoder(res.Body).Decode(&tLogs); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, want, len(tLogs))
+	for _, tLog := range tLogs {
+		assert.True(t, (*big.Int)(tLog.Amount).Cmp(min) >= 0)
+	}
+
+	for _, bad := range []string{
+		`{"criteriaSet": [{"minAmount": "-1"}]}`,
+		`{"criteriaSet": [{"maxAmount": "-1"}]}`,
+		`{"criteriaSet": [{"minAmount": "2", "maxAmount": "1"}]}`,
+	} {
+		_, statusCode := httpPost(t, ts.URL+"/transfers", json.RawMessage(bad))
+		assert.Equal(t, http.StatusBadRequest, statusCode, bad)
+		_, statusCode = httpPost(t, ts.URL+"/transfers/stats", json.RawMessage(bad))
+		assert.Equal(t, http.StatusBadRequest, statusCode, bad)
+	}
+}
+
 // Test functions
 func testTransferBadRequest(t *testing.T) {
 	badBody := []byte{0x00, 0x01, 0x02}
@@ -163,7 +331,7 @@ func insertBlocks(t *testing.T, db *logdb.LogDB, n int) {
 	}
 }
 
-func initTransferServer(t *testing.T, logDb *logdb.LogDB, limit uint64) {
+func initTransferServer(t *testing.T, logDb *logdb.LogDB, limit uint64, streamLimit uint64) {
 	router := mux.NewRouter()
 
 	muxDb := muxdb.NewMem()
@@ -177,7 +345,7 @@ func initTransferServer(t *testing.T, logDb *logdb.LogDB, limit uint64) {
 
 	repo, _ := chain.NewRepository(muxDb, b)
 
-	transfers.New(repo, logDb, limit).Mount(router, "/transfers")
+	transfers.New(repo, logDb, limit, streamLimit).Mount(router, "/transfers")
 	ts = httptest.NewServer(router)
 }
 
@@ -45,9 +45,45 @@ func convertTransfer(transfer *logdb.Transfer) *FilteredTransfer {
 	}
 }
 
+// TransferPage is a page of transfers returned in cursor mode.
+type TransferPage struct {
+	Transfers  []*FilteredTransfer `json:"transfers"`
+	NextCursor *logdb.Cursor       `json:"nextCursor"` // nil if there are no more transfers
+}
+
 type TransferFilter struct {
 	CriteriaSet []*logdb.TransferCriteria
 	Range       *events.Range
 	Options     *logdb.Options
 	Order       logdb.Order //default asc
 }
+
+// TransferStatsFilter is the filter of transfer statistics queries.
+type TransferStatsFilter struct {
+	CriteriaSet []*logdb.TransferCriteria
+	Range       *events.Range
+	Order       logdb.Order //default asc
+	GroupBy     logdb.GroupBy
+	BucketSize  uint32
+}
+
+// TransferStats is the statistics of transfers in a group.
+type TransferStats struct {
+	Range   *events.BlockRange    `json:"range,omitempty"`
+	Address *thor.Address         `json:"address,omitempty"`
+	Count   uint64                `json:"count"`
+	Sum     *math.HexOrDecimal256 `json:"sum"`
+	Min     *math.HexOrDecimal256 `json:"min"`
+	Max     *math.HexOrDecimal256 `json:"max"`
+}
+
+func convertTransferStats(st *logdb.TransferStats) *TransferStats {
+	return &TransferStats{
+		Range:   events.ConvertBlockRange(st.Range),
+		Address: st.Address,
+		Count:   st.Count,
+		Sum:     (*math.HexOrDecimal256)(st.Sum),
+		Min:     (*math.HexOrDecimal256)(st.Min),
+		Max:     (*math.HexOrDecimal256)(st.Max),
+	}
+}
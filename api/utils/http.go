@@ -8,7 +8,9 @@ package utils
 import (
 	"encoding/json"
 	"io"
+	"mime"
 	"net/http"
+	"strings"
 )
 
 type httpError struct {
@@ -69,7 +71,8 @@ func WrapHandlerFunc(f HandlerFunc) http.HandlerFunc {
 
 // content types
 const (
-	JSONContentType = "application/json; charset=utf-8"
+	JSONContentType   = "application/json; charset=utf-8"
+	NDJSONContentType = "application/x-ndjson"
 )
 
 // ParseJSON parse a JSON object using strict mode.
@@ -85,5 +88,55 @@ func WriteJSON(w http.ResponseWriter, obj interface{}) error {
 	return json.NewEncoder(w).Encode(obj)
 }
 
+// AcceptsNDJSON returns whether the request accepts newline delimited JSON responses.
+func AcceptsNDJSON(req *http.Request) bool {
+	for _, accept := range req.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(accept, ",") {
+			if t, _, err := mime.ParseMediaType(mediaType); err == nil && t == NDJSONContentType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NDJSONWriter streams objects in newline delimited JSON encoding.
+type NDJSONWriter struct {
+	w       http.ResponseWriter
+	enc     *json.Encoder
+	count   int
+	flushAt int
+}
+
+// NewNDJSONWriter creates a NDJSONWriter which flushes the response every flushAt objects.
+func NewNDJSONWriter(w http.ResponseWriter, flushAt int) *NDJSONWriter {
+	w.Header().Set("Content-Type", NDJSONContentType)
+	return &NDJSONWriter{w: w, enc: json.NewEncoder(w), flushAt: flushAt}
+}
+
+// Write writes an object as a single line.
+func (nw *NDJSONWriter) Write(obj interface{}) error {
+	if err := nw.enc.Encode(obj); err != nil {
+		return err
+	}
+	nw.count++
+	if nw.flushAt > 0 && nw.count%nw.flushAt == 0 {
+		nw.Flush()
+	}
+	return nil
+}
+
+// Flush sends buffered data to the client.
+func (nw *NDJSONWriter) Flush() {
+	if f, ok := nw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Count returns the number of objects written.
+func (nw *NDJSONWriter) Count() int {
+	return nw.count
+}
+
 // M shortcut for type map[string]interface{}.
 type M map[string]interface{}
@@ -125,3 +125,35 @@ func TestWriteJSON(t *testing.T) {
 	assert.Equal(t, body.Id, respObj.Id)
 	assert.Equal(t, body.Body, respObj.Body)
 }
+
+func TestAcceptsNDJSON(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"application/json", false},
+		{"application/x-ndjson", true},
+		{"application/json, application/x-ndjson;q=0.9", true},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "http://localhost", nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		assert.Equal(t, tt.want, utils.AcceptsNDJSON(req), tt.accept)
+	}
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	rr := httptest.NewRecorder()
+	nw := utils.NewNDJSONWriter(rr, 2)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, nw.Write(utils.M{"i": i}))
+	}
+
+	assert.Equal(t, 3, nw.Count())
+	assert.True(t, rr.Flushed)
+	assert.Equal(t, utils.NDJSONContentType, rr.Header().Get("Content-Type"))
+	assert.Equal(t, "{\"i\":0}\n{\"i\":1}\n{\"i\":2}\n", rr.Body.String())
+}
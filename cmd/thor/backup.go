@@ -0,0 +1,166 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"github.com/vechain/thor/v2/chain"
+	"github.com/vechain/thor/v2/cmd/thor/node"
+	"github.com/vechain/thor/v2/log"
+	"github.com/vechain/thor/v2/logdb"
+	"github.com/vechain/thor/v2/muxdb"
+	"github.com/vechain/thor/v2/thor"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var backupCommand = cli.Command{
+	Name:  "backup",
+	Usage: "back up databases of a running node through its admin service",
+	Flags: []cli.Flag{
+		adminAddrFlag,
+		backupDirFlag,
+	},
+	Action: backupAction,
+}
+
+// databaseBackup backs up the main db and the log db of a running node at the same best block.
+// The backup directory is laid out as a data dir, so it can be used in place of the data dir to restore.
+type databaseBackup struct {
+	instanceName string
+	mainDB       *muxdb.MuxDB
+	logDB        *logdb.LogDB
+	node         atomic.Pointer[node.Node]
+	lock         sync.Mutex
+}
+
+func newDatabaseBackup(instanceDir string) *databaseBackup {
+	return &databaseBackup{instanceName: filepath.Base(instanceDir)}
+}
+
+// Attach enables backups of the given node. Databases are pinned while the node holds block processing.
+func (b *databaseBackup) Attach(n *node.Node, mainDB *muxdb.MuxDB, logDB *logdb.LogDB) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.mainDB = mainDB
+	b.logDB = logDB
+	b.node.Store(n)
+}
+
+// Backup implements api.BackupFunc.
+func (b *databaseBackup) Backup(ctx context.Context, dir string) (thor.Bytes32, error) {
+	n := b.node.Load()
+	if n == nil {
+		return thor.Bytes32{}, errors.New("node is not running")
+	}
+
+	// one backup at a time
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	instanceDir := filepath.Join(dir, b.instanceName)
+	if _, err := os.Stat(instanceDir); err == nil {
+		return thor.Bytes32{}, fmt.Errorf("instance dir already exists [%v]", instanceDir)
+	} else if !os.IsNotExist(err) {
+		return thor.Bytes32{}, err
+	}
+
+	var (
+		mainBackup *muxdb.Backup
+		logBackup  *logdb.Backup
+		bestID     thor.Bytes32
+	)
+	if err := n.Checkpoint(func(best *chain.BlockSummary) (err error) {
+		if mainBackup, err = b.mainDB.NewBackup(); err != nil {
+			return errors.Wrap(err, "pin main database")
+		}
+		if logBackup, err = b.logDB.NewBackup(ctx); err != nil {
+			mainBackup.Release()
+			return errors.Wrap(err, "pin log database")
+		}
+		bestID = best.Header.ID()
+		return nil
+	}); err != nil {
+		return thor.Bytes32{}, err
+	}
+	defer mainBackup.Release()
+	defer logBackup.Release()
+
+	if err := os.MkdirAll(instanceDir, 0700); err != nil {
+		return thor.Bytes32{}, errors.Wrapf(err, "create instance dir [%v]", instanceDir)
+	}
+
+	log.Info("backing up databases", "dir", instanceDir, "block", bestID)
+	err := func() error {
+		if err := mainBackup.SaveTo(ctx, filepath.Join(instanceDir, "main.db")); err != nil {
+			return errors.Wrap(err, "save main database")
+		}
+		if err := logBackup.SaveTo(ctx, filepath.Join(instanceDir, "logs.db")); err != nil {
+			return errors.Wrap(err, "save log database")
+		}
+		return nil
+	}()
+	if err != nil {
+		// don't leave a partial backup
+		_ = os.RemoveAll(instanceDir)
+		return thor.Bytes32{}, err
+	}
+	log.Info("databases backed up", "dir", instanceDir, "block", bestID)
+	return bestID, nil
+}
+
+func backupAction(ctx *cli.Context) error {
+	dir := ctx.String(backupDirFlag.Name)
+	if dir == "" {
+		return fmt.Errorf("backup dir not specified, use -%s to specify", backupDirFlag.Name)
+	}
+	// the dir is resolved by the node
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(map[string]string{"dir": dir})
+	if err != nil {
+		return err
+	}
+	url := "http://" + ctx.String(adminAddrFlag.Name) + "/admin/backup"
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "request backup")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp struct {
+			ErrorMessage string `json:"errorMessage"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&errResp)
+		return fmt.Errorf("backup failed (%v): %v", resp.Status, errResp.ErrorMessage)
+	}
+
+	var result struct {
+		Dir         string       `json:"dir"`
+		BlockID     thor.Bytes32 `json:"blockID"`
+		BlockNumber uint32       `json:"blockNumber"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return errors.Wrap(err, "decode backup response")
+	}
+	fmt.Println("Dir:  ", result.Dir)
+	fmt.Println("Block:", result.BlockNumber, result.BlockID)
+	return nil
+}
@@ -69,6 +69,39 @@ var (
 		Value: 1000,
 		Usage: "limit the number of logs returned by /logs API",
 	}
+	apiLogsStreamLimitFlag = cli.Uint64Flag{
+		Name:  "api-logs-stream-limit",
+		Usage: "limit the number of logs streamed as NDJSON by /logs API (0 to disable streaming)",
+	}
+	apiABIDirFlag = cli.StringFlag{
+		Name:  "api-abi-dir",
+		Usage: "directory of contract ABI files (<address|name>.json) to decode events served by API, also managed by admin API",
+	}
+	apiTracersDirFlag = cli.StringFlag{
+		Name:  "api-tracers-dir",
+		Usage: "directory of JS tracer files (<name>.js) compiled at startup and callable by name in tracer API",
+	}
+	apiTracerStepLimitFlag = cli.Uint64Flag{
+		Name:  "api-tracer-step-limit",
+		Usage: "limit the number of calls into the code of a JS tracer (0 for unlimited)",
+	}
+	apiTracerMemoryLimitFlag = cli.Uint64Flag{
+		Name:  "api-tracer-memory-limit",
+		Usage: "limit the megabytes read from the EVM and returned as the result by a JS tracer for a clause (0 for unlimited)",
+	}
+	apiTracerTimeoutFlag = cli.Uint64Flag{
+		Name:  "api-tracer-timeout",
+		Usage: "limit the execution time in milliseconds of a JS tracer for a clause (0 for unlimited)",
+	}
+	apiTraceCacheSizeFlag = cli.IntFlag{
+		Name:  "api-trace-cache-size",
+		Value: 256,
+		Usage: "number of trace results of finalized blocks cached in memory (0 to disable)",
+	}
+	apiTraceCacheDiskFlag = cli.BoolFlag{
+		Name:  "api-trace-cache-disk",
+		Usage: "persist trace results of finalized blocks in the main database, up to 1 GiB",
+	}
 	enableAPILogsFlag = cli.BoolFlag{
 		Name:  "enable-api-logs",
 		Usage: "enables API requests logging",
@@ -127,6 +160,18 @@ var (
 		Name:  "skip-logs",
 		Usage: "skip writing event|transfer logs (/logs API will be disabled)",
 	}
+	indexInternalCallsFlag = cli.BoolFlag{
+		Name:  "index-internal-calls",
+		Usage: "index internal calls by tracing blocks, recent blocks in state history only on first sync with pruner enabled (/logs/internal-call API)",
+	}
+	logsRetentionBlocksFlag = cli.Uint64Flag{
+		Name:  "logs-retention-blocks",
+		Usage: "keep logs of the most recent blocks only, older logs are pruned in background (0 keeps all)",
+	}
+	logsRetentionDaysFlag = cli.Uint64Flag{
+		Name:  "logs-retention-days",
+		Usage: "keep logs of the blocks in the most recent days only, older logs are pruned in background (0 keeps all)",
+	}
 	verifyLogsFlag = cli.BoolFlag{
 		Name:   "verify-logs",
 		Usage:  "verify log db at startup",
@@ -200,4 +245,41 @@ var (
 		Name:  "genesis",
 		Usage: "path to genesis file, if not set, the default devnet genesis will be used",
 	}
+
+	// logdb command only flags
+	fromBlockFlag = cli.Uint64Flag{
+		Name:  "from",
+		Value: 1,
+		Usage: "first block number of the range",
+	}
+	toBlockFlag = cli.Uint64Flag{
+		Name:  "to",
+		Usage: "last block number of the range, the best block if not set",
+	}
+
+	// backup command only flags
+	backupDirFlag = cli.StringFlag{
+		Name:  "dir",
+		Usage: "directory to save the backup, laid out as a data dir",
+	}
+
+	// logdb export command only flags
+	exportKindFlag = cli.StringFlag{
+		Name:  "kind",
+		Value: "events",
+		Usage: "kind of logs to export, events or transfers",
+	}
+	exportFormatFlag = cli.StringFlag{
+		Name:  "format",
+		Value: "csv",
+		Usage: "file format, csv or parquet",
+	}
+	exportCriteriaFlag = cli.StringFlag{
+		Name:  "criteria",
+		Usage: "criteria set in JSON, the same as the one of the events or transfers API",
+	}
+	exportOutFlag = cli.StringFlag{
+		Name:  "out",
+		Usage: "path of the file to write, which must not exist",
+	}
 )
@@ -0,0 +1,309 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/vechain/thor/v2/block"
+	"github.com/vechain/thor/v2/chain"
+	"github.com/vechain/thor/v2/log"
+	"github.com/vechain/thor/v2/logdb"
+	"github.com/vechain/thor/v2/logdb/internalcalls"
+	"github.com/vechain/thor/v2/logdb/logexport"
+	"github.com/vechain/thor/v2/muxdb"
+	"github.com/vechain/thor/v2/state"
+	"github.com/vechain/thor/v2/thor"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var logDBFlags = []cli.Flag{
+	networkFlag,
+	dataDirFlag,
+	cacheFlag,
+	disablePrunerFlag,
+	verbosityFlag,
+}
+
+var logDBCommand = cli.Command{
+	Name:  "logdb",
+	Usage: "log database maintenance, the node must be stopped",
+	Subcommands: []cli.Command{
+		{
+			Name:   "rebuild",
+			Usage:  "rebuild logs of the given block range, internal calls are kept unless re-traced with --" + indexInternalCallsFlag.Name,
+			Flags:  append([]cli.Flag{fromBlockFlag, toBlockFlag, indexInternalCallsFlag, disablePrunerFlag}, logDBFlags...),
+			Action: logDBRebuildAction,
+		},
+		{
+			Name:   "verify",
+			Usage:  "verify logs of the given block range against blocks and receipts",
+			Flags:  append([]cli.Flag{fromBlockFlag, toBlockFlag}, logDBFlags...),
+			Action: logDBVerifyAction,
+		},
+		{
+			Name:   "vacuum",
+			Usage:  "rebuild the log database file to reclaim free space, and enable shrinking it as logs are pruned",
+			Flags:  logDBFlags,
+			Action: logDBVacuumAction,
+		},
+		{
+			Name:   "stats",
+			Usage:  "print statistics of the log database",
+			Flags:  logDBFlags,
+			Action: logDBStatsAction,
+		},
+		{
+			Name:  "export",
+			Usage: "export events or transfers of the given block range and criteria to a CSV or Parquet file",
+			Flags: append([]cli.Flag{
+				fromBlockFlag,
+				toBlockFlag,
+				exportKindFlag,
+				exportFormatFlag,
+				exportCriteriaFlag,
+				exportOutFlag,
+			}, logDBFlags...),
+			Action: logDBExportAction,
+		},
+	},
+}
+
+// logDBEnv holds databases of an existing instance.
+type logDBEnv struct {
+	dir        string
+	mainDB     *muxdb.MuxDB
+	logDB      *logdb.LogDB
+	repo       *chain.Repository
+	forkConfig thor.ForkConfig
+}
+
+func (env *logDBEnv) Close() {
+	env.logDB.Close()
+	env.mainDB.Close()
+}
+
+// openLogDBEnv opens databases of the instance specified by flags. Unlike the node, it never creates a new instance.
+// The main database is locked while it's open, so it fails if the node is still running.
+func openLogDBEnv(ctx *cli.Context) (*logDBEnv, error) {
+	lvl, err := readIntFromUInt64Flag(ctx.Uint64(verbosityFlag.Name))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse verbosity flag")
+	}
+	initLogger(lvl, false)
+
+	gene, forkConfig, err := selectGenesis(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := instanceDirPath(ctx, gene)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(dir, "main.db")); err != nil {
+		return nil, errors.Wrapf(err, "instance not found [%v]", dir)
+	}
+
+	mainDB, err := openMainDB(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	logDB, err := openLogDB(dir)
+	if err != nil {
+		mainDB.Close()
+		return nil, err
+	}
+	repo, err := initChainRepository(gene, mainDB, logDB)
+	if err != nil {
+		logDB.Close()
+		mainDB.Close()
+		return nil, err
+	}
+	return &logDBEnv{dir, mainDB, logDB, repo, forkConfig}, nil
+}
+
+// blockRange returns the block range specified by flags, which defaults to [1, best].
+func (env *logDBEnv) blockRange(ctx *cli.Context) (uint32, uint32, error) {
+	bestNum := env.repo.BestBlockSummary().Header.Number()
+
+	from := ctx.Uint64(fromBlockFlag.Name)
+	if from == 0 {
+		// genesis logs are written when the repository is initialized
+		from = 1
+	}
+	to := uint64(bestNum)
+	if ctx.IsSet(toBlockFlag.Name) {
+		to = ctx.Uint64(toBlockFlag.Name)
+	}
+
+	if to > math.MaxUint32 || to > uint64(bestNum) {
+		return 0, 0, fmt.Errorf("%s exceeds the best block number %v", toBlockFlag.Name, bestNum)
+	}
+	if from > to {
+		return 0, 0, fmt.Errorf("%s must not be greater than %s", fromBlockFlag.Name, toBlockFlag.Name)
+	}
+	return uint32(from), uint32(to), nil
+}
+
+func logDBRebuildAction(ctx *cli.Context) error {
+	exitSignal := handleExitSignal()
+
+	env, err := openLogDBEnv(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { log.Info("closing databases..."); env.Close() }()
+
+	from, to, err := env.blockRange(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf(">> Rebuilding log db [%v, %v] <<\n", from, to)
+	w := env.logDB.NewWriterSyncOff()
+	if err := w.DeleteRange(from, to); err != nil {
+		return errors.Wrap(err, "delete logs")
+	}
+	var writeCalls func(b *block.Block) error
+	if ctx.Bool(indexInternalCallsFlag.Name) {
+		// calls of blocks whose states are pruned are kept
+		traceFrom := max(from, callTraceFrom(env.repo.BestBlockSummary().Header.Number(), !ctx.Bool(disablePrunerFlag.Name)))
+		if traceFrom <= to {
+			if err := w.DeleteInternalCalls(traceFrom, to); err != nil {
+				return errors.Wrap(err, "delete internal calls")
+			}
+			if err := w.MarkCallsRebuilt(traceFrom, to); err != nil {
+				return errors.Wrap(err, "mark rebuilt")
+			}
+		}
+		callTracer := internalcalls.NewTracer(env.repo, state.NewStater(env.mainDB), env.forkConfig)
+		writeCalls = internalCallsWriter(w, callTracer, traceFrom)
+	}
+	if err := writeLogDB(exitSignal, env.repo, w, env.repo.BestBlockSummary().Header.ID(), from, to, writeCalls); err != nil {
+		return errors.Wrap(err, "rebuild log db")
+	}
+	if err := w.MarkRebuilt(from, to); err != nil {
+		return errors.Wrap(err, "mark rebuilt")
+	}
+	return w.Commit()
+}
+
+func logDBVerifyAction(ctx *cli.Context) error {
+	exitSignal := handleExitSignal()
+
+	env, err := openLogDBEnv(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { log.Info("closing databases..."); env.Close() }()
+
+	from, to, err := env.blockRange(ctx)
+	if err != nil {
+		return err
+	}
+	return verifyLogDB(exitSignal, from, to, env.repo, env.logDB)
+}
+
+func logDBVacuumAction(ctx *cli.Context) error {
+	exitSignal := handleExitSignal()
+
+	env, err := openLogDBEnv(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { log.Info("closing databases..."); env.Close() }()
+
+	fmt.Println(">> Vacuuming log db, it may take a long time <<")
+	if err := env.logDB.EnableAutoVacuum(exitSignal); err != nil {
+		return errors.Wrap(err, "vacuum log db")
+	}
+	return nil
+}
+
+func logDBStatsAction(ctx *cli.Context) error {
+	exitSignal := handleExitSignal()
+
+	env, err := openLogDBEnv(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { log.Info("closing databases..."); env.Close() }()
+
+	stats, err := env.logDB.Stats(exitSignal)
+	if err != nil {
+		return errors.Wrap(err, "count logs")
+	}
+	oldest, err := env.logDB.OldestBlockNumber()
+	if err != nil {
+		return errors.Wrap(err, "get oldest block number")
+	}
+	newestID, err := env.logDB.NewestBlockID()
+	if err != nil {
+		return errors.Wrap(err, "get newest block id")
+	}
+	info, err := os.Stat(filepath.Join(env.dir, "logs.db"))
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Path:           ", filepath.Join(env.dir, "logs.db"))
+	fmt.Println("Size(bytes):    ", info.Size())
+	fmt.Println("Best block:     ", env.repo.BestBlockSummary().Header.Number())
+	fmt.Println("Oldest block:   ", oldest)
+	fmt.Println("Newest block:   ", block.Number(newestID), newestID)
+	fmt.Println("Events:         ", stats.Events)
+	fmt.Println("Transfers:      ", stats.Transfers)
+	fmt.Println("Token transfers:", stats.TokenTransfers)
+	fmt.Println("Transactions:   ", stats.Txs)
+	fmt.Println("Internal calls: ", stats.InternalCalls)
+	fmt.Println("Refs:           ", stats.Refs)
+	return nil
+}
+
+func logDBExportAction(ctx *cli.Context) error {
+	exitSignal := handleExitSignal()
+
+	out := ctx.String(exportOutFlag.Name)
+	if out == "" {
+		return fmt.Errorf("output file not specified, use -%s to specify", exportOutFlag.Name)
+	}
+	opts := &logexport.Options{
+		Kind:   logexport.Kind(ctx.String(exportKindFlag.Name)),
+		Format: logexport.Format(ctx.String(exportFormatFlag.Name)),
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if criteria := ctx.String(exportCriteriaFlag.Name); criteria != "" {
+		if err := opts.UnmarshalCriteriaSet([]byte(criteria)); err != nil {
+			return errors.Wrapf(err, "parse %s flag", exportCriteriaFlag.Name)
+		}
+	}
+
+	env, err := openLogDBEnv(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { log.Info("closing databases..."); env.Close() }()
+
+	from, to, err := env.blockRange(ctx)
+	if err != nil {
+		return err
+	}
+	opts.Range = &logdb.Range{From: from, To: to}
+
+	fmt.Printf(">> Exporting %v [%v, %v] to %v <<\n", opts.Kind, from, to, out)
+	count, err := logexport.ExportFile(exitSignal, env.logDB, opts, out)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Exported:", count)
+	return nil
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package main
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"github.com/vechain/thor/v2/log"
+	"github.com/vechain/thor/v2/logdb"
+	"github.com/vechain/thor/v2/logdb/logexport"
+)
+
+// logExporter exports logs of the node through the admin service, once the log db is attached.
+type logExporter struct {
+	logDB atomic.Pointer[logdb.LogDB]
+}
+
+// Attach enables exports from the given log db.
+func (e *logExporter) Attach(logDB *logdb.LogDB) {
+	e.logDB.Store(logDB)
+}
+
+// Export implements api.LogExportFunc.
+func (e *logExporter) Export(ctx context.Context, opts *logexport.Options, path string) (uint64, error) {
+	logDB := e.logDB.Load()
+	if logDB == nil {
+		return 0, errors.New("log database is not open")
+	}
+
+	log.Info("exporting logs", "kind", opts.Kind, "format", opts.Format, "file", path)
+	count, err := logexport.ExportFile(ctx, logDB, opts, path)
+	if err != nil {
+		return 0, err
+	}
+	log.Info("logs exported", "file", path, "count", count)
+	return count, nil
+}
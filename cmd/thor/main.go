@@ -19,6 +19,7 @@ import (
 	"github.com/mattn/go-isatty"
 	"github.com/pborman/uuid"
 	"github.com/pkg/errors"
+	"github.com/vechain/thor/v2/abi/registry"
 	"github.com/vechain/thor/v2/api"
 	"github.com/vechain/thor/v2/bft"
 	"github.com/vechain/thor/v2/cmd/thor/node"
@@ -27,6 +28,7 @@ import (
 	"github.com/vechain/thor/v2/genesis"
 	"github.com/vechain/thor/v2/log"
 	"github.com/vechain/thor/v2/logdb"
+	"github.com/vechain/thor/v2/logdb/internalcalls"
 	"github.com/vechain/thor/v2/metrics"
 	"github.com/vechain/thor/v2/muxdb"
 	"github.com/vechain/thor/v2/state"
@@ -82,6 +84,14 @@ func main() {
 			apiAllowCustomTracerFlag,
 			enableAPILogsFlag,
 			apiLogsLimitFlag,
+			apiLogsStreamLimitFlag,
+			apiABIDirFlag,
+			apiTracersDirFlag,
+			apiTracerStepLimitFlag,
+			apiTracerMemoryLimitFlag,
+			apiTracerTimeoutFlag,
+			apiTraceCacheSizeFlag,
+			apiTraceCacheDiskFlag,
 			verbosityFlag,
 			jsonLogsFlag,
 			maxPeersFlag,
@@ -90,6 +100,9 @@ func main() {
 			bootNodeFlag,
 			allowedPeersFlag,
 			skipLogsFlag,
+			indexInternalCallsFlag,
+			logsRetentionBlocksFlag,
+			logsRetentionDaysFlag,
 			pprofFlag,
 			verifyLogsFlag,
 			disablePrunerFlag,
@@ -117,6 +130,14 @@ func main() {
 					apiAllowCustomTracerFlag,
 					enableAPILogsFlag,
 					apiLogsLimitFlag,
+					apiLogsStreamLimitFlag,
+					apiABIDirFlag,
+					apiTracersDirFlag,
+					apiTracerStepLimitFlag,
+					apiTracerMemoryLimitFlag,
+					apiTracerTimeoutFlag,
+					apiTraceCacheSizeFlag,
+					apiTraceCacheDiskFlag,
 					onDemandFlag,
 					blockInterval,
 					persistFlag,
@@ -147,6 +168,8 @@ func main() {
 				},
 				Action: masterKeyAction,
 			},
+			logDBCommand,
+			backupCommand,
 		},
 	}
 
@@ -179,16 +202,6 @@ func defaultAction(ctx *cli.Context) error {
 		defer func() { log.Info("stopping metrics server..."); close() }()
 	}
 
-	adminURL := ""
-	if ctx.Bool(enableAdminFlag.Name) {
-		url, close, err := api.StartAdminServer(ctx.String(adminAddrFlag.Name), logLevel)
-		if err != nil {
-			return fmt.Errorf("unable to start admin server - %w", err)
-		}
-		adminURL = url
-		defer func() { log.Info("stopping admin server..."); close() }()
-	}
-
 	gene, forkConfig, err := selectGenesis(ctx)
 	if err != nil {
 		return err
@@ -198,6 +211,27 @@ func defaultAction(ctx *cli.Context) error {
 		return err
 	}
 
+	abis, err := registry.New(ctx.String(apiABIDirFlag.Name))
+	if err != nil {
+		return errors.Wrap(err, "load abis")
+	}
+	if err := registerTracers(ctx.String(apiTracersDirFlag.Name)); err != nil {
+		return errors.Wrap(err, "load tracers")
+	}
+
+	// backups are enabled once the node is created, and log exports once the log db is open
+	backup := newDatabaseBackup(instanceDir)
+	var exporter logExporter
+	adminURL := ""
+	if ctx.Bool(enableAdminFlag.Name) {
+		url, close, err := api.StartAdminServer(ctx.String(adminAddrFlag.Name), logLevel, backup.Backup, exporter.Export, abis)
+		if err != nil {
+			return fmt.Errorf("unable to start admin server - %w", err)
+		}
+		adminURL = url
+		defer func() { log.Info("stopping admin server..."); close() }()
+	}
+
 	mainDB, err := openMainDB(ctx, instanceDir)
 	if err != nil {
 		return err
@@ -205,12 +239,17 @@ func defaultAction(ctx *cli.Context) error {
 	defer func() { log.Info("closing main database..."); mainDB.Close() }()
 
 	skipLogs := ctx.Bool(skipLogsFlag.Name)
+	logRetention, err := parseLogRetention(ctx)
+	if err != nil {
+		return err
+	}
 
 	logDB, err := openLogDB(instanceDir)
 	if err != nil {
 		return err
 	}
 	defer func() { log.Info("closing log database..."); logDB.Close() }()
+	exporter.Attach(logDB)
 
 	repo, err := initChainRepository(gene, mainDB, logDB)
 	if err != nil {
@@ -224,10 +263,22 @@ func defaultAction(ctx *cli.Context) error {
 
 	printStartupMessage1(gene, repo, master, instanceDir, forkConfig)
 
+	var callTracer *internalcalls.Tracer
+	if !skipLogs && ctx.Bool(indexInternalCallsFlag.Name) {
+		callTracer = internalcalls.NewTracer(repo, state.NewStater(mainDB), forkConfig)
+	}
+
 	if !skipLogs {
-		if err := syncLogDB(exitSignal, repo, logDB, ctx.Bool(verifyLogsFlag.Name)); err != nil {
+		if err := syncLogDB(exitSignal, repo, logDB, callTracer, !ctx.Bool(disablePrunerFlag.Name), ctx.Bool(verifyLogsFlag.Name), logRetention); err != nil {
 			return err
 		}
+		if logRetention != nil {
+			if enabled, err := logDB.AutoVacuum(); err != nil {
+				return err
+			} else if !enabled {
+				log.Warn("log db file doesn't shrink as logs are pruned, stop the node and run 'thor logdb vacuum' to enable it")
+			}
+		}
 	}
 
 	txpoolOpt := defaultTxPoolOptions
@@ -253,6 +304,7 @@ func defaultAction(ctx *cli.Context) error {
 		state.NewStater(mainDB),
 		txPool,
 		logDB,
+		abis,
 		bftEngine,
 		p2pCommunicator.Communicator(),
 		forkConfig,
@@ -265,7 +317,11 @@ func defaultAction(ctx *cli.Context) error {
 		ctx.Bool(enableAPILogsFlag.Name),
 		ctx.Bool(enableMetricsFlag.Name),
 		ctx.Uint64(apiLogsLimitFlag.Name),
+		ctx.Uint64(apiLogsStreamLimitFlag.Name),
 		parseTracerList(strings.TrimSpace(ctx.String(allowedTracersFlag.Name))),
+		tracerLimits(ctx),
+		ctx.Int(apiTraceCacheSizeFlag.Name),
+		traceCacheStore(ctx, mainDB),
 		false,
 	)
 	defer func() { log.Info("closing API..."); apiCloser() }()
@@ -286,18 +342,22 @@ func defaultAction(ctx *cli.Context) error {
 	optimizer := optimizer.New(mainDB, repo, !ctx.Bool(disablePrunerFlag.Name))
 	defer func() { log.Info("stopping optimizer..."); optimizer.Stop() }()
 
-	return node.New(
+	n := node.New(
 		master,
 		repo,
 		bftEngine,
 		state.NewStater(mainDB),
 		logDB,
+		callTracer,
 		txPool,
 		filepath.Join(instanceDir, "tx.stash"),
 		p2pCommunicator.Communicator(),
 		ctx.Uint64(targetGasLimitFlag.Name),
 		skipLogs,
-		forkConfig).Run(exitSignal)
+		logRetention,
+		forkConfig)
+	backup.Attach(n, mainDB, logDB)
+	return n.Run(exitSignal)
 }
 
 func soloAction(ctx *cli.Context) error {
@@ -323,9 +383,19 @@ func soloAction(ctx *cli.Context) error {
 		defer func() { log.Info("stopping metrics server..."); close() }()
 	}
 
+	abis, err := registry.New(ctx.String(apiABIDirFlag.Name))
+	if err != nil {
+		return errors.Wrap(err, "load abis")
+	}
+	if err := registerTracers(ctx.String(apiTracersDirFlag.Name)); err != nil {
+		return errors.Wrap(err, "load tracers")
+	}
+
+	// log exports are enabled once the log db is open
+	var exporter logExporter
 	adminURL := ""
 	if ctx.Bool(enableAdminFlag.Name) {
-		url, close, err := api.StartAdminServer(ctx.String(adminAddrFlag.Name), logLevel)
+		url, close, err := api.StartAdminServer(ctx.String(adminAddrFlag.Name), logLevel, nil, exporter.Export, abis)
 		if err != nil {
 			return fmt.Errorf("unable to start admin server - %w", err)
 		}
@@ -371,6 +441,7 @@ func soloAction(ctx *cli.Context) error {
 		mainDB = openMemMainDB()
 		logDB = openMemLogDB()
 	}
+	exporter.Attach(logDB)
 
 	repo, err := initChainRepository(gene, mainDB, logDB)
 	if err != nil {
@@ -380,7 +451,7 @@ func soloAction(ctx *cli.Context) error {
 	skipLogs := ctx.Bool(skipLogsFlag.Name)
 
 	if !skipLogs {
-		if err := syncLogDB(exitSignal, repo, logDB, ctx.Bool(verifyLogsFlag.Name)); err != nil {
+		if err := syncLogDB(exitSignal, repo, logDB, nil, !ctx.Bool(disablePrunerFlag.Name), ctx.Bool(verifyLogsFlag.Name), nil); err != nil {
 			return err
 		}
 	}
@@ -404,6 +475,7 @@ func soloAction(ctx *cli.Context) error {
 		state.NewStater(mainDB),
 		txPool,
 		logDB,
+		abis,
 		bftEngine,
 		&solo.Communicator{},
 		forkConfig,
@@ -416,7 +488,11 @@ func soloAction(ctx *cli.Context) error {
 		ctx.Bool(enableAPILogsFlag.Name),
 		ctx.Bool(enableMetricsFlag.Name),
 		ctx.Uint64(apiLogsLimitFlag.Name),
+		ctx.Uint64(apiLogsStreamLimitFlag.Name),
 		parseTracerList(strings.TrimSpace(ctx.String(allowedTracersFlag.Name))),
+		tracerLimits(ctx),
+		ctx.Int(apiTraceCacheSizeFlag.Name),
+		traceCacheStore(ctx, mainDB),
 		true,
 	)
 	defer func() { log.Info("closing API..."); apiCloser() }()
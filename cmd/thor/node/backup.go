@@ -0,0 +1,23 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package node
+
+import (
+	"github.com/vechain/thor/v2/chain"
+)
+
+// Checkpoint calls pin with the best block while block processing is held, so the chain and logs are consistent
+// at the best block during the call. Pin should return quickly, e.g. by taking snapshots of databases.
+func (n *Node) Checkpoint(pin func(best *chain.BlockSummary) error) error {
+	n.processLock.Lock()
+	defer n.processLock.Unlock()
+
+	// logs are synced before the process lock released, unless the log db failed
+	if !n.skipLogs && n.logDBFailed {
+		return errLogDBFailed
+	}
+	return pin(n.repo.BestBlockSummary())
+}
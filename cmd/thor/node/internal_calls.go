@@ -0,0 +1,235 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package node
+
+import (
+	"context"
+	"sync"
+
+	"github.com/vechain/thor/v2/block"
+	"github.com/vechain/thor/v2/logdb"
+	"github.com/vechain/thor/v2/thor"
+)
+
+// callQueueSize is the number of blocks waiting to be traced, beyond which synced blocks wait to be processed.
+const callQueueSize = 1024
+
+// blockQueue is the FIFO queue of block ids, bounded by the consumer through Wait.
+type blockQueue struct {
+	lock   sync.Mutex
+	ids    []thor.Bytes32
+	limit  int
+	closed bool
+	signal chan struct{} // notifies the consumer of pushes
+	popped chan struct{} // notifies producers of pops
+	done   chan struct{} // closed along with the queue
+}
+
+func newBlockQueue(limit int) *blockQueue {
+	return &blockQueue{
+		limit:  limit,
+		signal: make(chan struct{}, 1),
+		popped: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+}
+
+// Push appends ids to the queue, and notifies the consumer. It never blocks, and returns false if the queue is closed.
+func (q *blockQueue) Push(ids ...thor.Bytes32) bool {
+	if len(ids) == 0 {
+		return true
+	}
+	q.lock.Lock()
+	if q.closed {
+		q.lock.Unlock()
+		return false
+	}
+	q.ids = append(q.ids, ids...)
+	q.lock.Unlock()
+
+	select {
+	case q.signal <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// Pop removes and returns the first id. ok is false if the queue is empty.
+func (q *blockQueue) Pop() (id thor.Bytes32, ok bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if len(q.ids) == 0 {
+		return thor.Bytes32{}, false
+	}
+	id = q.ids[0]
+	q.ids = q.ids[1:]
+	if len(q.ids) == 0 {
+		// release the underlying array
+		q.ids = nil
+	}
+
+	select {
+	case q.popped <- struct{}{}:
+	default:
+	}
+	return id, true
+}
+
+// Wait blocks until the queue is under the limit, or closed.
+func (q *blockQueue) Wait() {
+	for {
+		q.lock.Lock()
+		full := !q.closed && len(q.ids) >= q.limit
+		q.lock.Unlock()
+		if !full {
+			return
+		}
+		select {
+		case <-q.popped:
+		case <-q.done:
+		}
+	}
+}
+
+// Close closes the queue, and returns the ids left.
+func (q *blockQueue) Close() []thor.Bytes32 {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if !q.closed {
+		q.closed = true
+		close(q.done)
+	}
+	ids := q.ids
+	q.ids = nil
+	return ids
+}
+
+// internalCallLoop traces blocks whose logs are written, and writes their internal calls. Tracing is slow and may fail,
+// e.g. the state is pruned, so it's decoupled from block processing. Blocks failed to trace, and blocks left in the
+// queue on exit, are recorded as missing in the log db.
+func (n *Node) internalCallLoop(ctx context.Context) {
+	logger.Debug("enter internal call loop")
+	defer logger.Debug("leave internal call loop")
+
+	defer func() {
+		ids := n.callQueue.Close()
+
+		n.processLock.Lock()
+		defer n.processLock.Unlock()
+		if len(ids) > 0 {
+			_ = n.markCallsMissing(ids...)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-n.callQueue.signal:
+		}
+		for {
+			id, ok := n.callQueue.Pop()
+			if !ok {
+				break
+			}
+			if err := n.writeInternalCalls(id); err != nil {
+				if err == errLogDBFailed {
+					return
+				}
+				logger.Warn("failed to index internal calls", "block", block.Number(id), "err", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+		}
+	}
+}
+
+// queueInternalCalls queues blocks whose logs are written to index their internal calls, or records them as missing
+// if the queue is closed. It must be called with the process lock held.
+func (n *Node) queueInternalCalls(ids []thor.Bytes32) {
+	if !n.callQueue.Push(ids...) {
+		_ = n.markCallsMissing(ids...)
+	}
+}
+
+// markCallsMissing records that internal calls of the given blocks are missing, so the calls are indexed from the
+// block next to them. It must be called with the process lock held.
+func (n *Node) markCallsMissing(ids ...thor.Bytes32) error {
+	if n.logDBFailed {
+		return errLogDBFailed
+	}
+	var last uint32
+	for _, id := range ids {
+		last = max(last, block.Number(id))
+	}
+
+	w := n.logDB.NewWriter()
+	n.logWorker.Run(func() error {
+		err := w.MarkIndexedFrom(logdb.InternalCallTable, last+1)
+		if err == nil {
+			err = w.Commit()
+		}
+		if err != nil {
+			_ = w.Rollback()
+		}
+		return err
+	})
+	if err := n.logWorker.Sync(); err != nil {
+		logger.Warn("failed to write logs", "err", err)
+		n.logDBFailed = true
+		return errLogDBFailed
+	}
+	return nil
+}
+
+// writeInternalCalls traces the block and writes its internal calls, or records them as missing if it fails to trace.
+// The write task is serialized with log writing tasks by the process lock, to avoid interleaving with uncommitted logs.
+func (n *Node) writeInternalCalls(id thor.Bytes32) error {
+	b, err := n.repo.GetBlock(id)
+	if err != nil {
+		return err
+	}
+	calls, traceErr := n.callTracer.TraceBlock(b)
+
+	n.processLock.Lock()
+	defer n.processLock.Unlock()
+
+	if n.logDBFailed {
+		return errLogDBFailed
+	}
+	// logs of the block are truncated if it's no longer on the best chain
+	if has, err := n.repo.NewBestChain().HasBlock(id); err != nil || !has {
+		return err
+	}
+	if traceErr != nil {
+		logger.Warn("failed to trace internal calls", "block", b.Header().Number(), "err", traceErr)
+		return n.markCallsMissing(id)
+	}
+
+	w := n.logDB.NewWriter()
+	n.logWorker.Run(func() error {
+		if err = w.WriteInternalCalls(b, calls); err == nil {
+			err = w.Commit()
+		}
+		if err != nil {
+			_ = w.Rollback()
+		}
+		// the failure of writing internal calls should not stop writing logs
+		return nil
+	})
+	if werr := n.logWorker.Sync(); werr != nil {
+		logger.Warn("failed to write logs", "err", werr)
+		n.logDBFailed = true
+		return errLogDBFailed
+	}
+	return err
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vechain/thor/v2/thor"
+)
+
+func TestBlockQueue(t *testing.T) {
+	q := newBlockQueue(2)
+
+	_, ok := q.Pop()
+	assert.False(t, ok)
+
+	q.Push()
+	select {
+	case <-q.signal:
+		t.Fatal("notified without ids pushed")
+	default:
+	}
+
+	ids := []thor.Bytes32{{1}, {2}, {3}}
+	q.Push(ids[:2]...)
+	q.Push(ids[2])
+	// notified once for pending pushes
+	<-q.signal
+	select {
+	case <-q.signal:
+		t.Fatal("notified twice")
+	default:
+	}
+
+	for _, want := range ids {
+		id, ok := q.Pop()
+		assert.True(t, ok)
+		assert.Equal(t, want, id)
+	}
+	_, ok = q.Pop()
+	assert.False(t, ok)
+
+	// waits while the queue is full
+	q.Push(ids...)
+	waited := make(chan struct{})
+	go func() {
+		q.Wait()
+		close(waited)
+	}()
+	q.Pop()
+	select {
+	case <-waited:
+		t.Fatal("not waited")
+	case <-time.After(10 * time.Millisecond):
+	}
+	q.Pop()
+	<-waited
+
+	// closing returns ids left, and fails later pushes
+	assert.Equal(t, []thor.Bytes32{ids[2]}, q.Close())
+	assert.False(t, q.Push(ids[0]))
+	q.Wait()
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package node
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vechain/thor/v2/block"
+	"github.com/vechain/thor/v2/chain"
+)
+
+const (
+	logPruneInterval = time.Minute
+	logPruneStep     = 100  // number of blocks pruned in a round
+	logVacuumPages   = 1000 // number of free pages removed in a round
+)
+
+var errLogDBFailed = errors.New("log db failed")
+
+// LogRetention specifies the window of recent blocks whose logs are kept in the log db.
+// If both are set, logs out of either window are pruned.
+type LogRetention struct {
+	Blocks uint32        // number of recent blocks, 0 means unlimited
+	Period time.Duration // period of recent blocks, 0 means unlimited
+}
+
+// Horizon returns the number of the oldest block whose logs are kept, as of the given head.
+func (r *LogRetention) Horizon(repo *chain.Repository, head *block.Header) (uint32, error) {
+	if r == nil {
+		return 0, nil
+	}
+	var horizon uint32
+	if r.Blocks > 0 && head.Number() >= r.Blocks {
+		horizon = head.Number() - r.Blocks + 1
+	}
+	if period := uint64(r.Period / time.Second); period > 0 && head.Timestamp() > period {
+		header, err := repo.NewChain(head.ID()).FindBlockHeaderByTimestamp(head.Timestamp()-period, 1)
+		if err != nil {
+			return 0, err
+		}
+		if header.Number() > horizon {
+			horizon = header.Number()
+		}
+	}
+	return horizon, nil
+}
+
+// logPruneLoop periodically prunes logs out of the retention window.
+func (n *Node) logPruneLoop(ctx context.Context) {
+	logger.Debug("enter log prune loop")
+	defer logger.Debug("leave log prune loop")
+
+	// logs before pruned are all deleted
+	pruned, err := n.logDB.OldestBlockNumber()
+	if err != nil {
+		logger.Warn("failed to query oldest block of logs", "err", err)
+		return
+	}
+
+	ticker := time.NewTicker(logPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			horizon, err := n.logRetention.Horizon(n.repo, n.repo.BestBlockSummary().Header)
+			if err != nil {
+				logger.Warn("failed to compute log retention horizon", "err", err)
+				continue
+			}
+			if pruned < horizon {
+				logger.Debug("pruning logs", "from", pruned, "to", horizon)
+			}
+			for pruned < horizon {
+				to := pruned + logPruneStep
+				if to > horizon {
+					to = horizon
+				}
+				if err := n.pruneLogs(to); err != nil {
+					if err == errLogDBFailed {
+						return
+					}
+					logger.Warn("failed to prune logs", "err", err)
+					break
+				}
+				pruned = to
+
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+			}
+		}
+	}
+}
+
+// pruneLogs deletes logs before the given block number. The prune task is serialized with
+// log writing tasks by the process lock, to avoid interleaving with uncommitted logs.
+func (n *Node) pruneLogs(blockNum uint32) error {
+	n.processLock.Lock()
+	defer n.processLock.Unlock()
+
+	if n.logDBFailed {
+		return errLogDBFailed
+	}
+
+	var (
+		w   = n.logDB.NewWriter()
+		err error
+	)
+	n.logWorker.Run(func() error {
+		if err = w.Prune(blockNum); err == nil {
+			err = w.Vacuum(logVacuumPages)
+		}
+		if err != nil {
+			_ = w.Rollback()
+		}
+		// the failure of pruning should not stop writing logs
+		return nil
+	})
+	if werr := n.logWorker.Sync(); werr != nil {
+		logger.Warn("failed to write logs", "err", werr)
+		n.logDBFailed = true
+		return errLogDBFailed
+	}
+	return err
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vechain/thor/v2/block"
+	"github.com/vechain/thor/v2/chain"
+	"github.com/vechain/thor/v2/genesis"
+	"github.com/vechain/thor/v2/muxdb"
+	"github.com/vechain/thor/v2/state"
+)
+
+func TestLogRetentionHorizon(t *testing.T) {
+	db := muxdb.NewMem()
+	gene, _, _, err := genesis.NewDevnet().Build(state.NewStater(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := chain.NewRepository(db, gene)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// one block per hour
+	head := gene.Header()
+	for i := 0; i < 100; i++ {
+		b := new(block.Builder).
+			ParentID(head.ID()).
+			Timestamp(head.Timestamp() + 3600).
+			Build()
+		if err := repo.AddBlock(b, nil, 0); err != nil {
+			t.Fatal(err)
+		}
+		head = b.Header()
+	}
+
+	tests := []struct {
+		name      string
+		retention *LogRetention
+		want      uint32
+	}{
+		{"unlimited", nil, 0},
+		{"blocks", &LogRetention{Blocks: 10}, 91},
+		{"blocks more than chain", &LogRetention{Blocks: 1000}, 0},
+		{"period", &LogRetention{Period: 24 * time.Hour}, 76},
+		{"period not aligned", &LogRetention{Period: 24*time.Hour - time.Second}, 77},
+		{"period longer than chain", &LogRetention{Period: 1000 * 24 * time.Hour}, 0},
+		{"both", &LogRetention{Blocks: 10, Period: 24 * time.Hour}, 91},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			horizon, err := tt.retention.Horizon(repo, head)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, horizon)
+		})
+	}
+
+	horizon, err := (&LogRetention{Blocks: 10}).Horizon(repo, gene.Header())
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(0), horizon)
+}
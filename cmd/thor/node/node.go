@@ -28,6 +28,7 @@ import (
 	"github.com/vechain/thor/v2/consensus"
 	"github.com/vechain/thor/v2/log"
 	"github.com/vechain/thor/v2/logdb"
+	"github.com/vechain/thor/v2/logdb/internalcalls"
 	"github.com/vechain/thor/v2/packer"
 	"github.com/vechain/thor/v2/state"
 	"github.com/vechain/thor/v2/thor"
@@ -52,11 +53,13 @@ type Node struct {
 	repo           *chain.Repository
 	bft            *bft.BFTEngine
 	logDB          *logdb.LogDB
+	callTracer     *internalcalls.Tracer
 	txPool         *txpool.TxPool
 	txStashPath    string
 	comm           *comm.Communicator
 	targetGasLimit uint64
 	skipLogs       bool
+	logRetention   *LogRetention
 	forkConfig     thor.ForkConfig
 
 	logDBFailed bool
@@ -64,6 +67,7 @@ type Node struct {
 	maxBlockNum uint32
 	processLock sync.Mutex
 	logWorker   *worker
+	callQueue   *blockQueue // blocks to index internal calls
 }
 
 func New(
@@ -72,11 +76,13 @@ func New(
 	bft *bft.BFTEngine,
 	stater *state.Stater,
 	logDB *logdb.LogDB,
+	callTracer *internalcalls.Tracer,
 	txPool *txpool.TxPool,
 	txStashPath string,
 	comm *comm.Communicator,
 	targetGasLimit uint64,
 	skipLogs bool,
+	logRetention *LogRetention,
 	forkConfig thor.ForkConfig,
 ) *Node {
 	return &Node{
@@ -86,12 +92,15 @@ func New(
 		repo:           repo,
 		bft:            bft,
 		logDB:          logDB,
+		callTracer:     callTracer,
 		txPool:         txPool,
 		txStashPath:    txStashPath,
 		comm:           comm,
 		targetGasLimit: targetGasLimit,
 		skipLogs:       skipLogs,
+		logRetention:   logRetention,
 		forkConfig:     forkConfig,
+		callQueue:      newBlockQueue(callQueueSize),
 	}
 }
 
@@ -112,6 +121,12 @@ func (n *Node) Run(ctx context.Context) error {
 	goes.Go(func() { n.houseKeeping(ctx) })
 	goes.Go(func() { n.txStashLoop(ctx) })
 	goes.Go(func() { n.packerLoop(ctx) })
+	if !n.skipLogs && n.logRetention != nil {
+		goes.Go(func() { n.logPruneLoop(ctx) })
+	}
+	if !n.skipLogs && n.callTracer != nil {
+		goes.Go(func() { n.internalCallLoop(ctx) })
+	}
 
 	goes.Wait()
 	return nil
@@ -296,6 +311,11 @@ func (n *Node) guardBlockProcessing(blockNum uint32, process func(conflicts uint
 func (n *Node) processBlock(newBlock *block.Block, stats *blockStats) (bool, error) {
 	var isTrunk *bool
 
+	// tracing internal calls is slower than processing blocks, which waits for it to avoid unbounded queueing
+	if !n.skipLogs && n.callTracer != nil {
+		n.callQueue.Wait()
+	}
+
 	if err := n.guardBlockProcessing(newBlock.Header().Number(), func(conflicts uint32) error {
 		// Check whether the block was already there.
 		// It can be skipped if no conflicts.
@@ -349,8 +369,9 @@ func (n *Node) processBlock(newBlock *block.Block, stats *blockStats) (bool, err
 		execElapsed := mclock.Now() - startTime
 
 		// write logs
+		var logBlocks []thor.Bytes32
 		if logEnabled {
-			if err := n.writeLogs(newBlock, receipts, oldBest.Header.ID()); err != nil {
+			if logBlocks, err = n.writeLogs(newBlock, receipts, oldBest.Header.ID()); err != nil {
 				return errors.Wrap(err, "write logs")
 			}
 		}
@@ -379,6 +400,8 @@ func (n *Node) processBlock(newBlock *block.Block, stats *blockStats) (bool, err
 			if err := n.logWorker.Sync(); err != nil {
 				logger.Warn("failed to write logs", "err", err)
 				n.logDBFailed = true
+			} else if n.callTracer != nil {
+				n.queueInternalCalls(logBlocks)
 			}
 		}
 
@@ -423,7 +446,9 @@ func (n *Node) processBlock(newBlock *block.Block, stats *blockStats) (bool, err
 	return *isTrunk, nil
 }
 
-func (n *Node) writeLogs(newBlock *block.Block, newReceipts tx.Receipts, oldBestBlockID thor.Bytes32) (err error) {
+// writeLogs writes logs of the new block, and blocks of the new branch if there is a fork.
+// It returns ids of the blocks whose logs are written.
+func (n *Node) writeLogs(newBlock *block.Block, newReceipts tx.Receipts, oldBestBlockID thor.Bytes32) (written []thor.Bytes32, err error) {
 	var w *logdb.Writer
 	if int64(newBlock.Header().Timestamp()) < time.Now().Unix()-24*3600 {
 		// turn off log sync to quickly catch up
@@ -442,7 +467,7 @@ func (n *Node) writeLogs(newBlock *block.Block, newReceipts tx.Receipts, oldBest
 
 	oldBranch, err := oldTrunk.Exclude(newTrunk)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// to clear logs on the old branch.
@@ -454,17 +479,17 @@ func (n *Node) writeLogs(newBlock *block.Block, newReceipts tx.Receipts, oldBest
 
 	newBranch, err := newTrunk.Exclude(oldTrunk)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	// write logs on the new branch.
 	for _, id := range newBranch {
 		block, err := n.repo.GetBlock(id)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		receipts, err := n.repo.GetBlockReceipts(id)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		n.logWorker.Run(func() error {
 			return w.Write(block, receipts)
@@ -477,7 +502,7 @@ func (n *Node) writeLogs(newBlock *block.Block, newReceipts tx.Receipts, oldBest
 		}
 		return w.Commit()
 	})
-	return nil
+	return append(newBranch, newBlock.Header().ID()), nil
 }
 
 func (n *Node) processFork(newBlock *block.Block, oldBestBlockID thor.Bytes32) {
@@ -157,8 +157,9 @@ func (n *Node) pack(flow *packer.Flow) (err error) {
 		execElapsed := mclock.Now() - startTime
 
 		// write logs
+		var logBlocks []thor.Bytes32
 		if logEnabled {
-			if err := n.writeLogs(newBlock, receipts, oldBest.Header.ID()); err != nil {
+			if logBlocks, err = n.writeLogs(newBlock, receipts, oldBest.Header.ID()); err != nil {
 				return errors.Wrap(err, "write logs")
 			}
 		}
@@ -186,6 +187,8 @@ func (n *Node) pack(flow *packer.Flow) (err error) {
 			if err := n.logWorker.Sync(); err != nil {
 				logger.Warn("failed to write logs", "err", err)
 				n.logDBFailed = true
+			} else if n.callTracer != nil {
+				n.queueInternalCalls(logBlocks)
 			}
 		}
 
@@ -6,54 +6,76 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"reflect"
+	"slices"
 
 	"github.com/pkg/errors"
 	"github.com/pmezard/go-difflib/difflib"
 	"github.com/vechain/thor/v2/block"
 	"github.com/vechain/thor/v2/chain"
+	"github.com/vechain/thor/v2/cmd/thor/node"
 	"github.com/vechain/thor/v2/co"
+	"github.com/vechain/thor/v2/log"
 	"github.com/vechain/thor/v2/logdb"
+	"github.com/vechain/thor/v2/logdb/internalcalls"
 	"github.com/vechain/thor/v2/thor"
 	"github.com/vechain/thor/v2/tx"
 	"gopkg.in/cheggaaa/pb.v1"
 )
 
-func syncLogDB(ctx context.Context, repo *chain.Repository, logDB *logdb.LogDB, verify bool) error {
+func syncLogDB(ctx context.Context, repo *chain.Repository, logDB *logdb.LogDB, callTracer *internalcalls.Tracer, pruner bool, verify bool, retention *node.LogRetention) error {
 	startPos, err := seekLogDBSyncPosition(repo, logDB)
 	if err != nil {
 		return errors.Wrap(err, "seek log db sync position")
 	}
-	if verify && startPos > 0 {
-		if err := verifyLogDB(ctx, startPos-1, repo, logDB); err != nil {
-			return errors.Wrap(err, "verify log db")
-		}
-	}
 
 	best := repo.BestBlockSummary()
 
 	bestNum := best.Header.Number()
 
+	// logs out of the retention window are neither verified nor synced
+	horizon, err := retention.Horizon(repo, best.Header)
+	if err != nil {
+		return errors.Wrap(err, "log retention horizon")
+	}
+	if horizon < 1 {
+		horizon = 1 // block 0 can be skipped
+	}
+
+	if verify && startPos > horizon {
+		if err := verifyLogDB(ctx, horizon, startPos-1, repo, logDB); err != nil {
+			return errors.Wrap(err, "verify log db")
+		}
+	}
+
+	if callTracer == nil {
+		// internal calls of blocks written from now on are missing
+		w := logDB.NewWriter()
+		if err := w.MarkIndexedFrom(logdb.InternalCallTable, math.MaxUint32); err != nil {
+			return err
+		}
+		if err := w.Commit(); err != nil {
+			return err
+		}
+	}
+
 	if bestNum == startPos {
 		return nil
 	}
 
 	if startPos == 0 {
 		fmt.Println(">> Rebuilding log db <<")
-		startPos = 1 // block 0 can be skipped
 	} else {
 		fmt.Println(">> Syncing log db <<")
 	}
-
-	pb := pb.New64(int64(bestNum)).
-		Set64(int64(startPos - 1)).
-		SetMaxWidth(90).
-		Start()
-
-	defer func() { pb.NotPrint = true }()
+	if startPos < horizon {
+		startPos = horizon
+	}
 
 	w := logDB.NewWriterSyncOff()
 
@@ -61,6 +83,59 @@ func syncLogDB(ctx context.Context, repo *chain.Repository, logDB *logdb.LogDB,
 		return err
 	}
 
+	var writeCalls func(b *block.Block) error
+	if callTracer != nil {
+		traceFrom := max(startPos, callTraceFrom(bestNum, pruner))
+		if err := w.MarkCallsRebuilt(traceFrom, math.MaxUint32); err != nil {
+			return err
+		}
+		// internal calls of blocks before traceFrom are truncated but not traced
+		if traceFrom > startPos {
+			if err := w.MarkIndexedFrom(logdb.InternalCallTable, traceFrom); err != nil {
+				return err
+			}
+		}
+		writeCalls = internalCallsWriter(w, callTracer, traceFrom)
+	}
+	return writeLogDB(ctx, repo, w, best.Header.ID(), startPos, bestNum, writeCalls)
+}
+
+// callTraceFrom returns the first block which can be traced for internal calls. States older than the guaranteed
+// state history are deleted if the pruner is enabled.
+func callTraceFrom(bestNum uint32, pruner bool) uint32 {
+	if pruner && bestNum > thor.MaxStateHistory {
+		return bestNum - thor.MaxStateHistory
+	}
+	return 0
+}
+
+// internalCallsWriter returns the function to trace the block and write its internal calls. Blocks before traceFrom
+// are skipped. Blocks failed to trace are recorded as missing in the log db, so the calls are indexed from the next
+// block, until they are rebuilt.
+func internalCallsWriter(w *logdb.Writer, callTracer *internalcalls.Tracer, traceFrom uint32) func(b *block.Block) error {
+	return func(b *block.Block) error {
+		if b.Header().Number() < traceFrom {
+			return nil
+		}
+		calls, err := callTracer.TraceBlock(b)
+		if err != nil {
+			log.Warn("failed to trace internal calls", "block", b.Header().Number(), "err", err)
+			return w.MarkIndexedFrom(logdb.InternalCallTable, b.Header().Number()+1)
+		}
+		return w.WriteInternalCalls(b, calls)
+	}
+}
+
+// writeLogDB writes logs of blocks in range [from, to] on the chain of the given head.
+// If writeCalls is not nil, it's called after logs of each block written.
+func writeLogDB(ctx context.Context, repo *chain.Repository, w *logdb.Writer, headID thor.Bytes32, from, to uint32, writeCalls func(b *block.Block) error) error {
+	pb := pb.New64(int64(to)).
+		Set64(int64(from - 1)).
+		SetMaxWidth(90).
+		Start()
+
+	defer func() { pb.NotPrint = true }()
+
 	var (
 		goes    co.Goes
 		pumpErr error
@@ -72,7 +147,7 @@ func syncLogDB(ctx context.Context, repo *chain.Repository, logDB *logdb.LogDB,
 	defer goes.Wait()
 	goes.Go(func() {
 		defer close(ch)
-		pumpErr = pumpBlockAndReceipts(ctx, repo, best.Header.ID(), startPos, bestNum, ch)
+		pumpErr = pumpBlockAndReceipts(ctx, repo, headID, from, to, ch)
 	})
 
 	defer cancel()
@@ -85,6 +160,11 @@ func syncLogDB(ctx context.Context, repo *chain.Repository, logDB *logdb.LogDB,
 		if err := w.Write(b, receipts); err != nil {
 			return err
 		}
+		if writeCalls != nil {
+			if err := writeCalls(b); err != nil {
+				return err
+			}
+		}
 		if w.UncommittedCount() > 2048 {
 			if err := w.Commit(); err != nil {
 				return err
@@ -154,10 +234,20 @@ func seekLogDBSyncPosition(repo *chain.Repository, logDB *logdb.LogDB) (uint32,
 	return block.Number(header.ID()) + 1, nil
 }
 
-func verifyLogDB(ctx context.Context, endBlockNum uint32, repo *chain.Repository, logDB *logdb.LogDB) error {
+func verifyLogDB(ctx context.Context, startBlockNum, endBlockNum uint32, repo *chain.Repository, logDB *logdb.LogDB) error {
 	fmt.Println(">> Verifying log db <<")
+	// tables added after logs were written are verified from their first indexed block
+	ttFrom, err := logDB.IndexedFrom(logdb.TokenTransferTable)
+	if err != nil {
+		return err
+	}
+	txFrom, err := logDB.IndexedFrom(logdb.TxTable)
+	if err != nil {
+		return err
+	}
+
 	pb := pb.New64(int64(endBlockNum)).
-		Set64(0).
+		Set64(int64(startBlockNum - 1)).
 		SetMaxWidth(90).
 		Start()
 	defer func() { pb.NotPrint = true }()
@@ -165,44 +255,13 @@ func verifyLogDB(ctx context.Context, endBlockNum uint32, repo *chain.Repository
 	const logStep = uint32(100)
 
 	var (
-		best        = repo.BestBlockSummary()
-		evLogs      []*logdb.Event
-		trLogs      []*logdb.Transfer
-		logLimit    = uint32(0)
-		splitEvLogs = func(id thor.Bytes32) (logs []*logdb.Event) {
-			if len(evLogs) == 0 {
-				return
-			}
-			for i, log := range evLogs {
-				if log.BlockID != id {
-					if i > 0 {
-						logs = evLogs[:i]
-						evLogs = evLogs[i:]
-					}
-					return
-				}
-			}
-			logs = evLogs
-			evLogs = nil
-			return
-		}
-		splitTrLogs = func(id thor.Bytes32) (logs []*logdb.Transfer) {
-			if len(trLogs) == 0 {
-				return
-			}
-			for i, log := range trLogs {
-				if log.BlockID != id {
-					if i > 0 {
-						logs = trLogs[:i]
-						trLogs = trLogs[i:]
-					}
-					return
-				}
-			}
-			logs = trLogs
-			trLogs = nil
-			return
-		}
+		best     = repo.BestBlockSummary()
+		evLogs   []*logdb.Event
+		trLogs   []*logdb.Transfer
+		ttLogs   []*logdb.TokenTransfer
+		txLogs   []*logdb.Tx
+		rcLogs   []*logdb.TxRecipient
+		logLimit = uint32(0)
 	)
 
 	var (
@@ -216,7 +275,7 @@ func verifyLogDB(ctx context.Context, endBlockNum uint32, repo *chain.Repository
 	defer goes.Wait()
 	goes.Go(func() {
 		defer close(ch)
-		pumpErr = pumpBlockAndReceipts(ctx, repo, best.Header.ID(), 1, endBlockNum, ch)
+		pumpErr = pumpBlockAndReceipts(ctx, repo, best.Header.ID(), startBlockNum, endBlockNum, ch)
 	})
 
 	defer cancel()
@@ -226,22 +285,28 @@ func verifyLogDB(ctx context.Context, endBlockNum uint32, repo *chain.Repository
 		num := b.Header().Number()
 		if num > logLimit {
 			var err error
-			logLimit += logStep
-			evLogs, err = logDB.FilterEvents(context.TODO(), &logdb.EventFilter{
-				Range: &logdb.Range{
-					From: num,
-					To:   logLimit,
-				},
-			})
+			logLimit = num + logStep - 1
+			rng := &logdb.Range{
+				From: num,
+				To:   logLimit,
+			}
+			evLogs, err = logDB.FilterEvents(context.TODO(), &logdb.EventFilter{Range: rng})
 			if err != nil {
 				return err
 			}
-			trLogs, err = logDB.FilterTransfers(context.TODO(), &logdb.TransferFilter{
-				Range: &logdb.Range{
-					From: num,
-					To:   logLimit,
-				},
-			})
+			trLogs, err = logDB.FilterTransfers(context.TODO(), &logdb.TransferFilter{Range: rng})
+			if err != nil {
+				return err
+			}
+			ttLogs, err = logDB.FilterTokenTransfers(context.TODO(), &logdb.TokenTransferFilter{Range: rng})
+			if err != nil {
+				return err
+			}
+			txLogs, err = logDB.FilterTxs(context.TODO(), &logdb.TxFilter{Range: rng})
+			if err != nil {
+				return err
+			}
+			rcLogs, err = logDB.FilterTxRecipients(context.TODO(), rng)
 			if err != nil {
 				return err
 			}
@@ -252,9 +317,32 @@ func verifyLogDB(ctx context.Context, endBlockNum uint32, repo *chain.Repository
 			return err
 		}
 
-		if err := verifyLogDBPerBlock(b, receipts, splitEvLogs(id), splitTrLogs(id)); err != nil {
+		var (
+			evs = splitBlockLogs(&evLogs, id, func(ev *logdb.Event) thor.Bytes32 { return ev.BlockID })
+			trs = splitBlockLogs(&trLogs, id, func(tr *logdb.Transfer) thor.Bytes32 { return tr.BlockID })
+			tts = splitBlockLogs(&ttLogs, id, func(tt *logdb.TokenTransfer) thor.Bytes32 { return tt.BlockID })
+			txs = splitBlockLogs(&txLogs, id, func(tx *logdb.Tx) thor.Bytes32 { return tx.BlockID })
+			// recipients have no block id, but the block number is unique on the chain
+			rcs = splitBlockLogs(&rcLogs, id, func(rc *logdb.TxRecipient) thor.Bytes32 {
+				if rc.BlockNumber == num {
+					return id
+				}
+				return thor.Bytes32{}
+			})
+		)
+		if err := verifyLogDBPerBlock(b, receipts, evs, trs); err != nil {
 			return err
 		}
+		if num >= ttFrom {
+			if err := verifyTokenTransfersPerBlock(evs, tts); err != nil {
+				return err
+			}
+		}
+		if num >= txFrom {
+			if err := verifyTxsPerBlock(b, txs, rcs); err != nil {
+				return err
+			}
+		}
 		pb.Add64(1)
 
 		select {
@@ -268,6 +356,21 @@ func verifyLogDB(ctx context.Context, endBlockNum uint32, repo *chain.Repository
 	return pumpErr
 }
 
+// splitBlockLogs splits the leading logs of the given block id from logs, which are sorted by block.
+func splitBlockLogs[T any](logs *[]T, id thor.Bytes32, blockID func(T) thor.Bytes32) (split []T) {
+	for i, l := range *logs {
+		if blockID(l) != id {
+			split, *logs = (*logs)[:i:i], (*logs)[i:]
+			if len(split) == 0 {
+				split = nil
+			}
+			return
+		}
+	}
+	split, *logs = *logs, nil
+	return
+}
+
 func verifyLogDBPerBlock(
 	block *block.Block,
 	receipts tx.Receipts,
@@ -341,6 +444,90 @@ func verifyLogDBPerBlock(
 	return nil
 }
 
+// verifyTokenTransfersPerBlock verifies token transfers against the verified events of the block.
+func verifyTokenTransfersPerBlock(eventLogs []*logdb.Event, tokenTransferLogs []*logdb.TokenTransfer) error {
+	var expected []*logdb.TokenTransfer
+	for _, ev := range eventLogs {
+		var topics []thor.Bytes32
+		for _, topic := range ev.Topics {
+			if topic != nil {
+				topics = append(topics, *topic)
+			}
+		}
+		sender, recipient, amount, ok := logdb.DecodeTokenTransfer(topics, ev.Data)
+		if !ok {
+			continue
+		}
+		expected = append(expected, &logdb.TokenTransfer{
+			BlockNumber: ev.BlockNumber,
+			Index:       ev.Index,
+			BlockID:     ev.BlockID,
+			BlockTime:   ev.BlockTime,
+			TxID:        ev.TxID,
+			TxOrigin:    ev.TxOrigin,
+			ClauseIndex: ev.ClauseIndex,
+			Token:       ev.Address,
+			Sender:      sender,
+			Recipient:   recipient,
+			Amount:      amount,
+		})
+	}
+	if !reflect.DeepEqual(tokenTransferLogs, expected) {
+		fmt.Println("\nDiff token transfer logs")
+		fmt.Println(jsonDiff(expected, tokenTransferLogs))
+		return errors.New("incorrect logs")
+	}
+	return nil
+}
+
+// verifyTxsPerBlock verifies txs and their clause recipients against the block.
+func verifyTxsPerBlock(block *block.Block, txLogs []*logdb.Tx, recipientLogs []*logdb.TxRecipient) error {
+	var (
+		expectedTxs        []*logdb.Tx
+		expectedRecipients []*logdb.TxRecipient
+	)
+	for i, tx := range block.Transactions() {
+		origin, _ := tx.Origin()
+		delegator, _ := tx.Delegator()
+		expectedTxs = append(expectedTxs, &logdb.Tx{
+			BlockNumber: block.Header().Number(),
+			Index:       uint32(i),
+			BlockID:     block.Header().ID(),
+			BlockTime:   block.Header().Timestamp(),
+			TxID:        tx.ID(),
+			Origin:      origin,
+			Delegator:   delegator,
+		})
+
+		// recipients are distinct and sorted
+		var recipients []thor.Address
+		for _, clause := range tx.Clauses() {
+			if to := clause.To(); to != nil && !slices.Contains(recipients, *to) {
+				recipients = append(recipients, *to)
+			}
+		}
+		slices.SortFunc(recipients, func(a, b thor.Address) int { return bytes.Compare(a[:], b[:]) })
+		for _, recipient := range recipients {
+			expectedRecipients = append(expectedRecipients, &logdb.TxRecipient{
+				BlockNumber: block.Header().Number(),
+				Index:       uint32(i),
+				Recipient:   recipient,
+			})
+		}
+	}
+	if !reflect.DeepEqual(txLogs, expectedTxs) {
+		fmt.Println("\nDiff tx logs")
+		fmt.Println(jsonDiff(expectedTxs, txLogs))
+		return errors.New("incorrect logs")
+	}
+	if !reflect.DeepEqual(recipientLogs, expectedRecipients) {
+		fmt.Println("\nDiff tx recipient logs")
+		fmt.Println(jsonDiff(expectedRecipients, recipientLogs))
+		return errors.New("incorrect logs")
+	}
+	return nil
+}
+
 func jsonDiff(expected, actual interface{}) string {
 	e, _ := json.MarshalIndent(expected, "", "  ")
 	a, _ := json.MarshalIndent(actual, "", "  ")
@@ -44,12 +44,15 @@ import (
 	"github.com/vechain/thor/v2/co"
 	"github.com/vechain/thor/v2/comm"
 	"github.com/vechain/thor/v2/genesis"
+	"github.com/vechain/thor/v2/kv"
 	"github.com/vechain/thor/v2/log"
 	"github.com/vechain/thor/v2/logdb"
 	"github.com/vechain/thor/v2/muxdb"
 	"github.com/vechain/thor/v2/p2psrv"
 	"github.com/vechain/thor/v2/state"
 	"github.com/vechain/thor/v2/thor"
+	"github.com/vechain/thor/v2/tracers"
+	"github.com/vechain/thor/v2/tracers/js"
 	"github.com/vechain/thor/v2/tx"
 	"github.com/vechain/thor/v2/txpool"
 	"gopkg.in/urfave/cli.v1"
@@ -285,7 +288,7 @@ func makeConfigDir(ctx *cli.Context) (string, error) {
 	return dir, nil
 }
 
-func makeInstanceDir(ctx *cli.Context, gene *genesis.Genesis) (string, error) {
+func instanceDirPath(ctx *cli.Context, gene *genesis.Genesis) (string, error) {
 	dataDir := ctx.String(dataDirFlag.Name)
 	if dataDir == "" {
 		return "", fmt.Errorf("unable to infer default data dir, use -%s to specify", dataDirFlag.Name)
@@ -296,7 +299,14 @@ func makeInstanceDir(ctx *cli.Context, gene *genesis.Genesis) (string, error) {
 		suffix = "-full"
 	}
 
-	instanceDir := filepath.Join(dataDir, fmt.Sprintf("instance-%x-v3", gene.ID().Bytes()[24:])+suffix)
+	return filepath.Join(dataDir, fmt.Sprintf("instance-%x-v3", gene.ID().Bytes()[24:])+suffix), nil
+}
+
+func makeInstanceDir(ctx *cli.Context, gene *genesis.Genesis) (string, error) {
+	instanceDir, err := instanceDirPath(ctx, gene)
+	if err != nil {
+		return "", err
+	}
 	if err := os.MkdirAll(instanceDir, 0700); err != nil {
 		return "", errors.Wrapf(err, "create instance dir [%v]", instanceDir)
 	}
@@ -743,6 +753,24 @@ func parseNodeList(list string) ([]*discover.Node, error) {
 	return nodes, nil
 }
 
+func parseLogRetention(ctx *cli.Context) (*node.LogRetention, error) {
+	blocks := ctx.Uint64(logsRetentionBlocksFlag.Name)
+	days := ctx.Uint64(logsRetentionDaysFlag.Name)
+	if blocks == 0 && days == 0 {
+		return nil, nil
+	}
+	if blocks > math.MaxUint32 {
+		return nil, fmt.Errorf("invalid %s value %d", logsRetentionBlocksFlag.Name, blocks)
+	}
+	if days > math.MaxInt64/uint64(24*time.Hour) {
+		return nil, fmt.Errorf("invalid %s value %d", logsRetentionDaysFlag.Name, days)
+	}
+	return &node.LogRetention{
+		Blocks: uint32(blocks),
+		Period: time.Duration(days) * 24 * time.Hour,
+	}, nil
+}
+
 func readIntFromUInt64Flag(val uint64) (int, error) {
 	i := int(val)
 
@@ -753,6 +781,35 @@ func readIntFromUInt64Flag(val uint64) (int, error) {
 	return i, nil
 }
 
+// registerTracers compiles and registers the JS tracers in the dir, which is optional.
+func registerTracers(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	names, err := js.RegisterDir(dir)
+	if err != nil {
+		return err
+	}
+	log.Info("JS tracers loaded", "dir", dir, "tracers", strings.Join(names, ","))
+	return nil
+}
+
+func tracerLimits(ctx *cli.Context) tracers.Limits {
+	return tracers.Limits{
+		Steps:   ctx.Uint64(apiTracerStepLimitFlag.Name),
+		Memory:  ctx.Uint64(apiTracerMemoryLimitFlag.Name) * 1024 * 1024,
+		Timeout: time.Duration(ctx.Uint64(apiTracerTimeoutFlag.Name)) * time.Millisecond,
+	}
+}
+
+// traceCacheStore returns the store to persist trace results, or nil if it's disabled.
+func traceCacheStore(ctx *cli.Context, db *muxdb.MuxDB) kv.Store {
+	if !ctx.Bool(apiTraceCacheDiskFlag.Name) {
+		return nil
+	}
+	return db.NewStore("tracecache")
+}
+
 func parseTracerList(list string) map[string]interface{} {
 	inputs := strings.Split(list, ",")
 	tracerMap := map[string]interface{}{}
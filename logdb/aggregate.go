@@ -0,0 +1,265 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package logdb
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/big"
+
+	"github.com/vechain/thor/v2/thor"
+)
+
+// GroupBy specifies how logs are grouped in aggregate queries.
+type GroupBy string
+
+const (
+	GroupByNone      GroupBy = ""          // all matched logs in a single group
+	GroupByBlock     GroupBy = "block"     // block range buckets
+	GroupByAddress   GroupBy = "address"   // event contract address
+	GroupBySender    GroupBy = "sender"    // transfer sender
+	GroupByRecipient GroupBy = "recipient" // transfer recipient
+)
+
+// Aggregation specifies the grouping of an aggregate query.
+type Aggregation struct {
+	GroupBy    GroupBy
+	BucketSize uint32 // number of blocks in each bucket when grouped by block
+	Limit      uint64 // max number of groups returned, 0 for no limit
+}
+
+// groupColumn returns the column expression used as the group key.
+func (a *Aggregation) groupColumn(columns map[GroupBy]string) (string, error) {
+	switch a.GroupBy {
+	case GroupByNone:
+		return "0", nil
+	case GroupByBlock:
+		if a.BucketSize == 0 {
+			return "", errors.New("bucket size must be positive")
+		}
+		return "(seq >> 31) / ?", nil
+	default:
+		if col, ok := columns[a.GroupBy]; ok {
+			return col, nil
+		}
+		return "", errors.New("unsupported group by: " + string(a.GroupBy))
+	}
+}
+
+// refJoin returns the join clause to resolve the group key when grouped by an address.
+func (a *Aggregation) refJoin(alias string) string {
+	if a.GroupBy == GroupByNone || a.GroupBy == GroupByBlock {
+		return ""
+	}
+	return " LEFT JOIN ref r ON " + alias + ".grp = r.id"
+}
+
+// refColumn returns the given column if the ref table is joined, or NULL otherwise.
+func (a *Aggregation) refColumn(col string) string {
+	if a.GroupBy == GroupByNone || a.GroupBy == GroupByBlock {
+		return "NULL"
+	}
+	return col
+}
+
+// orderBy returns the clause to sort groups, given the columns of the group key, the resolved address and the count.
+// Groups by block are sorted in the given order, and groups by address in descending order of count.
+func (a *Aggregation) orderBy(grp, data, cnt string, order Order) string {
+	if a.GroupBy == GroupByBlock {
+		if order == DESC {
+			return " ORDER BY " + grp + " DESC"
+		}
+		return " ORDER BY " + grp + " ASC"
+	}
+	return " ORDER BY " + cnt + " DESC, " + a.refColumn(data) + " ASC"
+}
+
+// limit returns the clause to limit the number of groups, with its args.
+func (a *Aggregation) limit() (string, []interface{}) {
+	if a.Limit == 0 {
+		return "", nil
+	}
+	return " LIMIT ?", []interface{}{a.Limit}
+}
+
+// bucketRange returns the block range of the given bucket.
+func (a *Aggregation) bucketRange(bucket uint64) *Range {
+	from := bucket * uint64(a.BucketSize)
+	to := from + uint64(a.BucketSize) - 1
+	if to > math.MaxUint32 {
+		to = math.MaxUint32
+	}
+	return &Range{From: uint32(from), To: uint32(to)}
+}
+
+// EventCount is the number of events in a group.
+type EventCount struct {
+	Range   *Range        // set when grouped by block
+	Address *thor.Address // set when grouped by address
+	Count   uint64
+}
+
+// TransferStats is the statistics of transfers in a group.
+type TransferStats struct {
+	Range   *Range        // set when grouped by block
+	Address *thor.Address // set when grouped by sender or recipient
+	Count   uint64
+	Sum     *big.Int
+	Min     *big.Int
+	Max     *big.Int
+}
+
+// CountEvents counts events matching the given filter, grouped as specified by the aggregation.
+// Groups by block are sorted in the filter order, groups by address are in descending order of count,
+// and at most agg.Limit groups are returned. Options of the filter are ignored.
+func (db *LogDB) CountEvents(ctx context.Context, filter *EventFilter, agg *Aggregation) ([]*EventCount, error) {
+	col, err := agg.groupColumn(map[GroupBy]string{GroupByAddress: "address"})
+	if err != nil {
+		return nil, err
+	}
+	if filter == nil {
+		filter = &EventFilter{}
+	}
+
+	var args []interface{}
+	if agg.GroupBy == GroupByBlock {
+		args = append(args, agg.BucketSize)
+	}
+	cond, cargs := filter.toWhereCondition()
+	args = append(args, cargs...)
+
+	query := "SELECT g.grp, " + agg.refColumn("r.data") + ", g.cnt FROM (SELECT " + col + " AS grp, COUNT(*) AS cnt FROM event WHERE " + cond + " GROUP BY grp) g" +
+		agg.refJoin("g") + agg.orderBy("g.grp", "r.data", "g.cnt", filter.Order)
+	limit, largs := agg.limit()
+	query += limit
+	args = append(args, largs...)
+
+	rows, err := db.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var counts []*EventCount
+	for rows.Next() {
+		var (
+			grp  uint64
+			data []byte
+			cnt  uint64
+		)
+		if err := rows.Scan(&grp, &data, &cnt); err != nil {
+			return nil, err
+		}
+		c := &EventCount{Count: cnt}
+		switch agg.GroupBy {
+		case GroupByBlock:
+			c.Range = agg.bucketRange(grp)
+		case GroupByAddress:
+			addr := thor.BytesToAddress(data)
+			c.Address = &addr
+		}
+		counts = append(counts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if agg.GroupBy == GroupByNone && len(counts) == 0 {
+		counts = append(counts, &EventCount{})
+	}
+	return counts, nil
+}
+
+// TransferStatistics computes statistics of transfers matching the given filter, grouped as specified by
+// the aggregation. Groups are counted, sorted and limited in sqlite, while amounts can't be summed in sqlite,
+// so they are aggregated as the transfers of the returned groups are read.
+// Groups by block are sorted in the filter order, groups by address are in descending order of count,
+// and at most agg.Limit groups are returned. Options of the filter are ignored.
+func (db *LogDB) TransferStatistics(ctx context.Context, filter *TransferFilter, agg *Aggregation) ([]*TransferStats, error) {
+	col, err := agg.groupColumn(map[GroupBy]string{GroupBySender: "sender", GroupByRecipient: "recipient"})
+	if err != nil {
+		return nil, err
+	}
+	if filter == nil {
+		filter = &TransferFilter{}
+	}
+
+	var targs []interface{}
+	if agg.GroupBy == GroupByBlock {
+		targs = append(targs, agg.BucketSize)
+	}
+	cond, cargs := filter.toWhereCondition()
+	targs = append(targs, cargs...)
+	transfers := "SELECT " + col + " AS grp, amount FROM transfer WHERE " + cond
+
+	// groups are selected first, then joined with their transfers
+	limit, largs := agg.limit()
+	groups := "SELECT g.grp AS grp, " + agg.refColumn("r.data") + " AS data, g.cnt AS cnt FROM (SELECT grp, COUNT(*) AS cnt FROM (" + transfers + ") GROUP BY grp) g" +
+		agg.refJoin("g") + agg.orderBy("g.grp", "r.data", "g.cnt", filter.Order) + limit
+	// g.grp is the last sort key, so that transfers of a group are contiguous
+	query := "SELECT g.grp, g.data, t.amount FROM (" + groups + ") g JOIN (" + transfers + ") t ON t.grp = g.grp" +
+		agg.orderBy("g.grp", "g.data", "g.cnt", filter.Order) + ", g.grp"
+
+	var args []interface{}
+	args = append(args, targs...)
+	args = append(args, largs...)
+	args = append(args, targs...)
+
+	rows, err := db.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var (
+		stats   []*TransferStats
+		lastGrp uint64
+	)
+	for rows.Next() {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		var (
+			grp    uint64
+			data   []byte
+			amount []byte
+		)
+		if err := rows.Scan(&grp, &data, &amount); err != nil {
+			return nil, err
+		}
+		if len(stats) == 0 || grp != lastGrp {
+			st := &TransferStats{Sum: new(big.Int)}
+			switch agg.GroupBy {
+			case GroupByBlock:
+				st.Range = agg.bucketRange(grp)
+			case GroupBySender, GroupByRecipient:
+				addr := thor.BytesToAddress(data)
+				st.Address = &addr
+			}
+			stats = append(stats, st)
+			lastGrp = grp
+		}
+		st := stats[len(stats)-1]
+		v := new(big.Int).SetBytes(amount)
+		st.Count++
+		st.Sum.Add(st.Sum, v)
+		if st.Min == nil || v.Cmp(st.Min) < 0 {
+			st.Min = v
+		}
+		if st.Max == nil || v.Cmp(st.Max) > 0 {
+			st.Max = v
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if agg.GroupBy == GroupByNone && len(stats) == 0 {
+		stats = append(stats, &TransferStats{Sum: new(big.Int)})
+	}
+	return stats, nil
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package logdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// backupStepPages is the number of pages copied in each backup step.
+const backupStepPages = 1024
+
+// Backup is a point-in-time view of the log db, which can be saved as a new log db while the log db is being written.
+// It holds a read transaction, so the WAL can't be checkpointed beyond it until released.
+type Backup struct {
+	conn *sql.Conn
+}
+
+// NewBackup pins the current committed state of the log db. The returned backup should be released after use.
+func (db *LogDB) NewBackup(ctx context.Context) (*Backup, error) {
+	conn, err := db.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// the read transaction starts at the first read, instead of BEGIN
+	if _, err := conn.ExecContext(ctx, "BEGIN"); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	var n int
+	if err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master").Scan(&n); err != nil {
+		_, _ = conn.ExecContext(context.Background(), "ROLLBACK")
+		_ = conn.Close()
+		return nil, err
+	}
+	return &Backup{conn}, nil
+}
+
+// SaveTo copies the pinned state into a new log db file at the given path, using the sqlite online backup API.
+// It fails if the file already exists.
+func (b *Backup) SaveTo(ctx context.Context, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("file already exists: %v", path)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	dst, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	dconn, err := dst.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer dconn.Close()
+
+	return dconn.Raw(func(dc interface{}) error {
+		return b.conn.Raw(func(sc interface{}) error {
+			dstConn, ok := dc.(*sqlite3.SQLiteConn)
+			if !ok {
+				return errors.New("unexpected driver connection")
+			}
+			srcConn, ok := sc.(*sqlite3.SQLiteConn)
+			if !ok {
+				return errors.New("unexpected driver connection")
+			}
+
+			backup, err := dstConn.Backup("main", srcConn, "main")
+			if err != nil {
+				return err
+			}
+			// the source is in a read transaction, so each step copies from the same snapshot
+			for {
+				done, err := backup.Step(backupStepPages)
+				if err != nil {
+					_ = backup.Finish()
+					return err
+				}
+				if done {
+					break
+				}
+				select {
+				case <-ctx.Done():
+					_ = backup.Finish()
+					return ctx.Err()
+				default:
+				}
+			}
+			return backup.Finish()
+		})
+	})
+}
+
+// Release releases the pinned state.
+func (b *Backup) Release() {
+	_, _ = b.conn.ExecContext(context.Background(), "ROLLBACK")
+	_ = b.conn.Close()
+}
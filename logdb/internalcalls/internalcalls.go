@@ -0,0 +1,133 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+// Package internalcalls collects internal calls of blocks to be indexed in the log db, by replaying
+// blocks with the native call tracer.
+package internalcalls
+
+import (
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	"github.com/vechain/thor/v2/block"
+	"github.com/vechain/thor/v2/chain"
+	"github.com/vechain/thor/v2/consensus"
+	"github.com/vechain/thor/v2/logdb"
+	"github.com/vechain/thor/v2/state"
+	"github.com/vechain/thor/v2/thor"
+	"github.com/vechain/thor/v2/tracers"
+	"github.com/vechain/thor/v2/vm"
+
+	// registers the call tracer
+	_ "github.com/vechain/thor/v2/tracers/native"
+)
+
+// callFrame is the result of the call tracer.
+type callFrame struct {
+	Type  string        `json:"type"`
+	From  thor.Address  `json:"from"`
+	To    *thor.Address `json:"to"`
+	Input hexutil.Bytes `json:"input"`
+	Value *hexutil.Big  `json:"value"`
+	Error string        `json:"error"`
+	Calls []callFrame   `json:"calls"`
+}
+
+// Tracer replays blocks with the call tracer to collect internal calls.
+type Tracer struct {
+	cons *consensus.Consensus
+}
+
+// NewTracer creates a tracer.
+func NewTracer(repo *chain.Repository, stater *state.Stater, forkConfig thor.ForkConfig) *Tracer {
+	return &Tracer{consensus.New(repo, stater, forkConfig)}
+}
+
+// TraceBlock replays the block on the state of its parent, and returns internal calls in the order of execution.
+// Calls of reverted clauses are included with their errors. The block must have been validated.
+func (t *Tracer) TraceBlock(blk *block.Block) ([]*logdb.InternalCall, error) {
+	header := blk.Header()
+	// the block was validated when it's processed
+	rt, err := t.cons.NewRuntimeForReplay(header, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "new runtime")
+	}
+
+	var calls []*logdb.InternalCall
+	for txIndex, tx := range blk.Transactions() {
+		origin, _ := tx.Origin()
+		txExec, err := rt.PrepareTransaction(tx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "prepare tx %v", tx.ID())
+		}
+		for clauseIndex := uint32(0); txExec.HasNextClause(); clauseIndex++ {
+			tracer, err := tracers.DefaultDirectory.New("callTracer", nil, false)
+			if err != nil {
+				return nil, err
+			}
+			tracer.SetContext(&tracers.Context{
+				BlockID:     header.ID(),
+				BlockTime:   header.Timestamp(),
+				TxID:        tx.ID(),
+				TxIndex:     uint64(txIndex),
+				ClauseIndex: clauseIndex,
+				State:       rt.State(),
+			})
+			// the vm config takes effect on clauses prepared after it's set
+			rt.SetVMConfig(vm.Config{Tracer: tracer})
+			exec, _ := txExec.PrepareNext()
+			if _, _, err := exec(); err != nil {
+				return nil, errors.Wrapf(err, "execute clause %v of tx %v", clauseIndex, tx.ID())
+			}
+
+			res, err := tracer.GetResult()
+			if err != nil {
+				return nil, err
+			}
+			var frame callFrame
+			if err := json.Unmarshal(res, &frame); err != nil {
+				return nil, errors.Wrap(err, "decode call trace")
+			}
+			calls = appendCalls(calls, &frame, 1, &logdb.InternalCall{
+				TxID:        tx.ID(),
+				TxOrigin:    origin,
+				ClauseIndex: clauseIndex,
+			})
+		}
+		rt.SetVMConfig(vm.Config{})
+		if _, err := txExec.Finalize(); err != nil {
+			return nil, errors.Wrapf(err, "finalize tx %v", tx.ID())
+		}
+	}
+	return calls, nil
+}
+
+// appendCalls appends sub calls of the frame at the given depth in pre-order, which is the order they are made.
+func appendCalls(calls []*logdb.InternalCall, frame *callFrame, depth uint32, clause *logdb.InternalCall) []*logdb.InternalCall {
+	for i := range frame.Calls {
+		sub := &frame.Calls[i]
+		call := &logdb.InternalCall{
+			TxID:        clause.TxID,
+			TxOrigin:    clause.TxOrigin,
+			ClauseIndex: clause.ClauseIndex,
+			Depth:       depth,
+			Type:        sub.Type,
+			From:        sub.From,
+			To:          sub.To,
+			Error:       sub.Error,
+		}
+		if sub.Value != nil {
+			call.Value = sub.Value.ToInt()
+		}
+		// the input of contract creation is the init code
+		if len(sub.Input) >= 4 && sub.Type != vm.CREATE.String() && sub.Type != vm.CREATE2.String() {
+			call.Selector = append([]byte(nil), sub.Input[:4]...)
+		}
+		calls = append(calls, call)
+		calls = appendCalls(calls, sub, depth+1, clause)
+	}
+	return calls
+}
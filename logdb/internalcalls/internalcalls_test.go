@@ -0,0 +1,148 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package internalcalls
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/vechain/thor/v2/block"
+	"github.com/vechain/thor/v2/chain"
+	"github.com/vechain/thor/v2/genesis"
+	"github.com/vechain/thor/v2/logdb"
+	"github.com/vechain/thor/v2/muxdb"
+	"github.com/vechain/thor/v2/packer"
+	"github.com/vechain/thor/v2/state"
+	"github.com/vechain/thor/v2/thor"
+	"github.com/vechain/thor/v2/tx"
+)
+
+var (
+	callee   = thor.BytesToAddress([]byte("callee"))
+	selector = []byte{0xa9, 0x05, 0x9c, 0xbb}
+)
+
+// callerCode is the init code which calls the callee with the selector as input, and then returns nothing.
+func callerCode() []byte {
+	code := append([]byte{0x7f}, common.RightPadBytes(selector, 32)...) // PUSH32 selector
+	code = append(code, 0x60, 0x00, 0x52)                               // PUSH1 0, MSTORE
+	code = append(code, 0x60, 0x00, 0x60, 0x00, 0x60, 0x04, 0x60, 0x00) // retSize, retOffset, argsSize, argsOffset
+	code = append(code, 0x60, 0x01)                                     // value 1 wei
+	code = append(code, 0x73)                                           // PUSH20 callee
+	code = append(code, callee[:]...)
+	return append(code, 0x5a, 0xf1, 0x00) // GAS, CALL, STOP
+}
+
+func newBlock(t *testing.T, repo *chain.Repository, stater *state.Stater, txs ...*tx.Transaction) *block.Block {
+	acc := genesis.DevAccounts()[0]
+	p := packer.New(repo, stater, acc.Address, &acc.Address, thor.NoFork)
+	flow, err := p.Schedule(repo.BestBlockSummary(), uint64(time.Now().Unix()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, trx := range txs {
+		if err := flow.Adopt(trx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b, stage, receipts, err := flow.Pack(acc.PrivateKey, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stage.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AddBlock(b, receipts, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SetBestBlockID(b.Header().ID()); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func newTx(t *testing.T, repo *chain.Repository, nonce uint64, clauses ...*tx.Clause) *tx.Transaction {
+	builder := new(tx.Builder).
+		ChainTag(repo.ChainTag()).
+		Expiration(100).
+		Gas(1000000).
+		Nonce(nonce)
+	for _, c := range clauses {
+		builder.Clause(c)
+	}
+	trx := builder.Build()
+	sig, err := crypto.Sign(trx.SigningHash().Bytes(), genesis.DevAccounts()[0].PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return trx.WithSignature(sig)
+}
+
+func TestTraceBlock(t *testing.T) {
+	db := muxdb.NewMem()
+	stater := state.NewStater(db)
+	b0, _, _, err := genesis.NewDevnet().Build(stater)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, _ := chain.NewRepository(db, b0)
+
+	to := thor.BytesToAddress([]byte("to"))
+	tx1 := newTx(t, repo, 1, tx.NewClause(&to).WithValue(big.NewInt(1)))
+	tx2 := newTx(t, repo, 2,
+		tx.NewClause(nil).WithData(callerCode()).WithValue(big.NewInt(1)),
+		tx.NewClause(nil).WithData(callerCode()).WithValue(big.NewInt(1)),
+	)
+	b1 := newBlock(t, repo, stater, tx1, tx2)
+
+	tracer := NewTracer(repo, stater, thor.NoFork)
+	calls, err := tracer.TraceBlock(b1)
+	assert.Nil(t, err)
+
+	origin := genesis.DevAccounts()[0].Address
+	assert.Equal(t, 2, len(calls))
+	for i, call := range calls {
+		assert.Equal(t, tx2.ID(), call.TxID)
+		assert.Equal(t, origin, call.TxOrigin)
+		assert.Equal(t, uint32(i), call.ClauseIndex)
+		assert.Equal(t, uint32(1), call.Depth)
+		assert.Equal(t, "CALL", call.Type)
+		assert.Equal(t, thor.CreateContractAddress(tx2.ID(), uint32(i), 0), call.From)
+		assert.Equal(t, &callee, call.To)
+		assert.Equal(t, big.NewInt(1), call.Value)
+		assert.Equal(t, selector, call.Selector)
+		assert.Equal(t, "", call.Error)
+	}
+
+	// written calls can be queried
+	logDB, err := logdb.New(filepath.Join(t.TempDir(), "logs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logDB.Close()
+	w := logDB.NewWriter()
+	assert.Nil(t, w.WriteInternalCalls(b1, calls))
+	assert.Nil(t, w.Commit())
+
+	written, err := logDB.FilterInternalCalls(context.Background(), &logdb.InternalCallFilter{
+		CriteriaSet: []*logdb.InternalCallCriteria{{To: &callee, Selector: selector}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(written))
+	for i, call := range written {
+		assert.Equal(t, b1.Header().ID(), call.BlockID)
+		assert.Equal(t, b1.Header().Number(), call.BlockNumber)
+		assert.Equal(t, uint32(i), call.Index)
+		assert.Equal(t, calls[i].From, call.From)
+		assert.Equal(t, calls[i].Value, call.Value)
+	}
+}
@@ -22,6 +22,14 @@ const (
 	refIDQuery = "(SELECT id FROM ref WHERE data=?)"
 )
 
+// Tables which may be added to a log db with logs written, and so are indexed from a later block.
+// Internal calls are also indexed from a later block if blocks are not traced, see Writer.MarkIndexedFrom.
+const (
+	TokenTransferTable = "token_transfer"
+	TxTable            = "tx" // and tx_recipient
+	InternalCallTable  = "internal_call"
+)
+
 type LogDB struct {
 	path          string
 	driverVersion string
@@ -43,10 +51,32 @@ func New(path string) (logDB *LogDB, err error) {
 		}
 	}()
 
-	if _, err := db.Exec(refTableScheme + eventTableSchema + transferTableSchema); err != nil {
+	added, err := missingTables(db, TokenTransferTable, TxTable, InternalCallTable)
+	if err != nil {
+		return nil, err
+	}
+
+	// auto_vacuum takes effect only if set before any table created, so it's a no-op for existing log db,
+	// which needs a VACUUM to enable it, see EnableAutoVacuum
+	if _, err := db.Exec("pragma auto_vacuum = incremental;" + refTableScheme + eventTableSchema + transferTableSchema + coverageTableSchema + tokenTransferTableSchema + txTableSchema + internalCallTableSchema); err != nil {
 		return nil, err
 	}
 
+	// tables added to a log db with logs written are indexed from the next block, until older logs are rebuilt
+	if len(added) > 0 {
+		var seq sql.NullInt64
+		if err := db.QueryRow("SELECT MAX(seq) FROM (SELECT MAX(seq) AS seq FROM event UNION ALL SELECT MAX(seq) FROM transfer)").Scan(&seq); err != nil {
+			return nil, err
+		}
+		if seq.Valid {
+			for _, table := range added {
+				if _, err := db.Exec("INSERT OR REPLACE INTO coverage(tbl, fromBlock) VALUES(?, ?)", table, sequence(seq.Int64).BlockNumber()+1); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
 	wconn1, err := db.Conn(context.Background())
 	if err != nil {
 		return nil, err
@@ -72,6 +102,21 @@ func New(path string) (logDB *LogDB, err error) {
 	}, nil
 }
 
+// missingTables returns tables of the given names which don't exist.
+func missingTables(db *sql.DB, names ...string) ([]string, error) {
+	var missing []string
+	for _, name := range names {
+		var n int
+		if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&n); err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			missing = append(missing, name)
+		}
+	}
+	return missing, nil
+}
+
 // NewMem create a log db in ram.
 func NewMem() (*LogDB, error) {
 	return New("file::memory:")
@@ -94,7 +139,21 @@ func (db *LogDB) Path() string {
 	return db.path
 }
 
+// FilterEvents queries events matching the given filter.
 func (db *LogDB) FilterEvents(ctx context.Context, filter *EventFilter) ([]*Event, error) {
+	var events []*Event
+	if err := db.IterateEvents(ctx, filter, func(ev *Event) error {
+		events = append(events, ev)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// IterateEvents queries events matching the given filter, and calls fn for each
+// event as it is read, without holding the whole result set in memory.
+func (db *LogDB) IterateEvents(ctx context.Context, filter *EventFilter, fn func(*Event) error) error {
 	const query = `SELECT e.seq, r0.data, e.blockTime, r1.data, r2.data, e.clauseIndex, r3.data, r4.data, r5.data, r6.data, r7.data, r8.data, e.data
 FROM (%v) e
 	LEFT JOIN ref r0 ON e.blockID = r0.id
@@ -108,138 +167,200 @@ FROM (%v) e
 	LEFT JOIN ref r8 ON e.topic4 = r8.id`
 
 	if filter == nil {
-		return db.queryEvents(ctx, fmt.Sprintf(query, "event"))
+		return db.queryEvents(ctx, fn, fmt.Sprintf(query, "event"))
 	}
 
-	var (
-		subQuery = "SELECT seq FROM event WHERE 1"
-		args     []interface{}
-	)
+	cond, args := filter.toWhereCondition()
+	pagedQuery, args := buildPagedQuery(query, "event", cond, args, filter.Options, filter.Order)
+	return db.queryEvents(ctx, fn, pagedQuery, args...)
+}
 
-	if filter.Range != nil {
-		subQuery += " AND seq >= ?"
-		args = append(args, newSequence(filter.Range.From, 0))
-		if filter.Range.To >= filter.Range.From {
-			subQuery += " AND seq <= ?"
-			args = append(args, newSequence(filter.Range.To, uint32(math.MaxInt32)))
-		}
+// FilterTransfers queries transfers matching the given filter.
+func (db *LogDB) FilterTransfers(ctx context.Context, filter *TransferFilter) ([]*Transfer, error) {
+	var transfers []*Transfer
+	if err := db.IterateTransfers(ctx, filter, func(tr *Transfer) error {
+		transfers = append(transfers, tr)
+		return nil
+	}); err != nil {
+		return nil, err
 	}
+	return transfers, nil
+}
 
-	if len(filter.CriteriaSet) > 0 {
-		subQuery += " AND ("
+// IterateTransfers queries transfers matching the given filter, and calls fn for each
+// transfer as it is read, without holding the whole result set in memory.
+func (db *LogDB) IterateTransfers(ctx context.Context, filter *TransferFilter, fn func(*Transfer) error) error {
+	const query = `SELECT t.seq, r0.data, t.blockTime, r1.data, r2.data, t.clauseIndex, r3.data, r4.data, t.amount
+FROM (%v) t 
+	LEFT JOIN ref r0 ON t.blockID = r0.id
+	LEFT JOIN ref r1 ON t.txID = r1.id
+	LEFT JOIN ref r2 ON t.txOrigin = r2.id
+	LEFT JOIN ref r3 ON t.sender = r3.id
+	LEFT JOIN ref r4 ON t.recipient = r4.id`
 
-		for i, c := range filter.CriteriaSet {
-			cond, cargs := c.toWhereCondition()
-			if i > 0 {
-				subQuery += " OR"
-			}
-			subQuery += " (" + cond + ")"
-			args = append(args, cargs...)
-		}
-		subQuery += ")"
+	if filter == nil {
+		return db.queryTransfers(ctx, fn, fmt.Sprintf(query, "transfer"))
 	}
 
-	// if there is limit option, set order inside subquery
-	if filter.Options != nil {
-		if filter.Order == DESC {
-			subQuery += " ORDER BY seq DESC "
-		} else {
-			subQuery += " ORDER BY seq ASC "
-		}
-		subQuery += " LIMIT ?, ?"
-		args = append(args, filter.Options.Offset, filter.Options.Limit)
+	cond, args := filter.toWhereCondition()
+	pagedQuery, args := buildPagedQuery(query, "transfer", cond, args, filter.Options, filter.Order)
+	return db.queryTransfers(ctx, fn, pagedQuery, args...)
+}
+
+// FilterTokenTransfers queries token transfers matching the given filter.
+func (db *LogDB) FilterTokenTransfers(ctx context.Context, filter *TokenTransferFilter) ([]*TokenTransfer, error) {
+	var transfers []*TokenTransfer
+	if err := db.IterateTokenTransfers(ctx, filter, func(tr *TokenTransfer) error {
+		transfers = append(transfers, tr)
+		return nil
+	}); err != nil {
+		return nil, err
 	}
+	return transfers, nil
+}
 
-	subQuery = "SELECT e.* FROM (" + subQuery + ") s LEFT JOIN event e ON s.seq = e.seq"
+// IterateTokenTransfers queries token transfers matching the given filter, and calls fn for each
+// token transfer as it is read, without holding the whole result set in memory.
+func (db *LogDB) IterateTokenTransfers(ctx context.Context, filter *TokenTransferFilter, fn func(*TokenTransfer) error) error {
+	const query = `SELECT t.seq, r0.data, t.blockTime, r1.data, r2.data, t.clauseIndex, r3.data, r4.data, r5.data, t.amount
+FROM (%v) t
+	LEFT JOIN ref r0 ON t.blockID = r0.id
+	LEFT JOIN ref r1 ON t.txID = r1.id
+	LEFT JOIN ref r2 ON t.txOrigin = r2.id
+	LEFT JOIN ref r3 ON t.token = r3.id
+	LEFT JOIN ref r4 ON t.sender = r4.id
+	LEFT JOIN ref r5 ON t.recipient = r5.id`
 
-	eventQuery := fmt.Sprintf(query, subQuery)
-	// if there is no limit option, set order outside
-	if filter.Options == nil {
-		if filter.Order == DESC {
-			eventQuery += " ORDER BY seq DESC "
-		} else {
-			eventQuery += " ORDER BY seq ASC "
-		}
+	if filter == nil {
+		return db.queryTokenTransfers(ctx, fn, fmt.Sprintf(query, "token_transfer"))
 	}
-	return db.queryEvents(ctx, eventQuery, args...)
+
+	cond, args := filter.toWhereCondition()
+	pagedQuery, args := buildPagedQuery(query, "token_transfer", cond, args, filter.Options, filter.Order)
+	return db.queryTokenTransfers(ctx, fn, pagedQuery, args...)
 }
 
-func (db *LogDB) FilterTransfers(ctx context.Context, filter *TransferFilter) ([]*Transfer, error) {
-	const query = `SELECT t.seq, r0.data, t.blockTime, r1.data, r2.data, t.clauseIndex, r3.data, r4.data, t.amount
-FROM (%v) t 
+// FilterTxs queries transactions matching the given filter.
+func (db *LogDB) FilterTxs(ctx context.Context, filter *TxFilter) ([]*Tx, error) {
+	const query = `SELECT t.seq, r0.data, t.blockTime, r1.data, r2.data, r3.data
+FROM (%v) t
 	LEFT JOIN ref r0 ON t.blockID = r0.id
 	LEFT JOIN ref r1 ON t.txID = r1.id
-	LEFT JOIN ref r2 ON t.txOrigin = r2.id
-	LEFT JOIN ref r3 ON t.sender = r3.id
-	LEFT JOIN ref r4 ON t.recipient = r4.id`
+	LEFT JOIN ref r2 ON t.origin = r2.id
+	LEFT JOIN ref r3 ON t.delegator = r3.id`
 
 	if filter == nil {
-		return db.queryTransfers(ctx, fmt.Sprintf(query, "transfer"))
+		return db.queryTxs(ctx, fmt.Sprintf(query, "tx"))
 	}
 
-	var (
-		subQuery = "SELECT seq FROM transfer WHERE 1"
-		args     []interface{}
-	)
+	cond, args := filter.toWhereCondition()
+	pagedQuery, args := buildPagedQuery(query, "tx", cond, args, filter.Options, filter.Order)
+	return db.queryTxs(ctx, pagedQuery, args...)
+}
 
-	if filter.Range != nil {
-		subQuery += " AND seq >= ?"
-		args = append(args, newSequence(filter.Range.From, 0))
-		if filter.Range.To >= filter.Range.From {
-			subQuery += " AND seq <= ?"
-			args = append(args, newSequence(filter.Range.To, uint32(math.MaxInt32)))
-		}
+// FilterTxRecipients queries clause recipients of txs in the given range, sorted by tx and then by recipient.
+func (db *LogDB) FilterTxRecipients(ctx context.Context, rng *Range) ([]*TxRecipient, error) {
+	cond, args := rng.toWhereCondition()
+	rows, err := db.db.QueryContext(ctx, "SELECT t.seq, r.data FROM tx_recipient t LEFT JOIN ref r ON t.recipient = r.id WHERE "+cond+" ORDER BY t.seq ASC, r.data ASC", args...)
+	if err != nil {
+		return nil, err
 	}
+	defer func() { _ = rows.Close() }()
 
-	if len(filter.CriteriaSet) > 0 {
-		subQuery += " AND ("
-		for i, c := range filter.CriteriaSet {
-			cond, cargs := c.toWhereCondition()
-			if i > 0 {
-				subQuery += " OR"
-			}
-			subQuery += " (" + cond + ")"
-			args = append(args, cargs...)
+	var recipients []*TxRecipient
+	for rows.Next() {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
 		}
-		subQuery += ")"
+		var (
+			seq       sequence
+			recipient []byte
+		)
+		if err := rows.Scan(&seq, &recipient); err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, &TxRecipient{
+			BlockNumber: seq.BlockNumber(),
+			Index:       seq.Index(),
+			Recipient:   thor.BytesToAddress(recipient),
+		})
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return recipients, nil
+}
 
-	// if there is limit option, set order inside subquery
-	if filter.Options != nil {
-		if filter.Order == DESC {
-			subQuery += " ORDER BY seq DESC"
-		} else {
-			subQuery += " ORDER BY seq ASC"
-		}
-		subQuery += " LIMIT ?, ?"
-		args = append(args, filter.Options.Offset, filter.Options.Limit)
+// FilterInternalCalls queries internal calls matching the given filter.
+func (db *LogDB) FilterInternalCalls(ctx context.Context, filter *InternalCallFilter) ([]*InternalCall, error) {
+	var calls []*InternalCall
+	if err := db.IterateInternalCalls(ctx, filter, func(call *InternalCall) error {
+		calls = append(calls, call)
+		return nil
+	}); err != nil {
+		return nil, err
 	}
+	return calls, nil
+}
 
-	subQuery = "SELECT e.* FROM (" + subQuery + ") s LEFT JOIN transfer e ON s.seq = e.seq"
-	transferQuery := fmt.Sprintf(query, subQuery)
-	// if there is no limit option, set order outside
-	if filter.Options == nil {
-		if filter.Order == DESC {
-			transferQuery += " ORDER BY seq DESC "
-		} else {
-			transferQuery += " ORDER BY seq ASC "
-		}
+// IterateInternalCalls queries internal calls matching the given filter, and calls fn for each
+// call as it is read, without holding the whole result set in memory.
+func (db *LogDB) IterateInternalCalls(ctx context.Context, filter *InternalCallFilter, fn func(*InternalCall) error) error {
+	const query = `SELECT c.seq, r0.data, c.blockTime, r1.data, r2.data, c.clauseIndex, c.depth, c.type, r3.data, r4.data, c.value, c.selector, c.error
+FROM (%v) c
+	LEFT JOIN ref r0 ON c.blockID = r0.id
+	LEFT JOIN ref r1 ON c.txID = r1.id
+	LEFT JOIN ref r2 ON c.txOrigin = r2.id
+	LEFT JOIN ref r3 ON c.caller = r3.id
+	LEFT JOIN ref r4 ON c.callee = r4.id`
+
+	if filter == nil {
+		return db.queryInternalCalls(ctx, fn, fmt.Sprintf(query, "internal_call"))
 	}
-	return db.queryTransfers(ctx, transferQuery, args...)
+
+	cond, args := filter.toWhereCondition()
+	pagedQuery, args := buildPagedQuery(query, "internal_call", cond, args, filter.Options, filter.Order)
+	return db.queryInternalCalls(ctx, fn, pagedQuery, args...)
 }
 
-func (db *LogDB) queryEvents(ctx context.Context, query string, args ...interface{}) ([]*Event, error) {
+// buildPagedQuery builds the query of rows in the table matching the condition, by filling the table placeholder of
+// the query with a sub query. With paging options, the cursor, order and limit apply to the sub query, so that only
+// rows of the page are joined. Otherwise, the order applies to the outer query.
+func buildPagedQuery(query, table, cond string, args []interface{}, opts *Options, order Order) (string, []interface{}) {
+	orderBy := " ORDER BY seq ASC"
+	if order == DESC {
+		orderBy = " ORDER BY seq DESC"
+	}
+
+	subQuery := "SELECT seq FROM " + table + " WHERE " + cond
+	if opts != nil {
+		cond, cargs := opts.Cursor.condition(order)
+		subQuery += cond + orderBy + " LIMIT ?, ?"
+		args = append(args, cargs...)
+		args = append(args, opts.offset(), opts.Limit)
+	}
+	subQuery = "SELECT e.* FROM (" + subQuery + ") s LEFT JOIN " + table + " e ON s.seq = e.seq"
+
+	pagedQuery := fmt.Sprintf(query, subQuery)
+	if opts == nil {
+		pagedQuery += orderBy
+	}
+	return pagedQuery, args
+}
+
+func (db *LogDB) queryEvents(ctx context.Context, fn func(*Event) error, query string, args ...interface{}) error {
 	rows, err := db.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer func() { _ = rows.Close() }()
 
-	var events []*Event
 	for rows.Next() {
 		select {
 		case <-ctx.Done():
-			return nil, ctx.Err()
+			return ctx.Err()
 		default:
 		}
 		var (
@@ -268,7 +389,7 @@ func (db *LogDB) queryEvents(ctx context.Context, query string, args ...interfac
 			&topics[4],
 			&data,
 		); err != nil {
-			return nil, err
+			return err
 		}
 		event := &Event{
 			BlockNumber: seq.BlockNumber(),
@@ -287,25 +408,23 @@ func (db *LogDB) queryEvents(ctx context.Context, query string, args ...interfac
 				event.Topics[i] = &h
 			}
 		}
-		events = append(events, event)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+		if err := fn(event); err != nil {
+			return err
+		}
 	}
-	return events, nil
+	return rows.Err()
 }
 
-func (db *LogDB) queryTransfers(ctx context.Context, query string, args ...interface{}) ([]*Transfer, error) {
+func (db *LogDB) queryTransfers(ctx context.Context, fn func(*Transfer) error, query string, args ...interface{}) error {
 	rows, err := db.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer func() { _ = rows.Close() }()
-	var transfers []*Transfer
 	for rows.Next() {
 		select {
 		case <-ctx.Done():
-			return nil, ctx.Err()
+			return ctx.Err()
 		default:
 		}
 		var (
@@ -330,7 +449,7 @@ func (db *LogDB) queryTransfers(ctx context.Context, query string, args ...inter
 			&recipient,
 			&amount,
 		); err != nil {
-			return nil, err
+			return err
 		}
 		trans := &Transfer{
 			BlockNumber: seq.BlockNumber(),
@@ -344,12 +463,194 @@ func (db *LogDB) queryTransfers(ctx context.Context, query string, args ...inter
 			Recipient:   thor.BytesToAddress(recipient),
 			Amount:      new(big.Int).SetBytes(amount),
 		}
-		transfers = append(transfers, trans)
+		if err := fn(trans); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (db *LogDB) queryTokenTransfers(ctx context.Context, fn func(*TokenTransfer) error, query string, args ...interface{}) error {
+	rows, err := db.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		var (
+			seq         sequence
+			blockID     []byte
+			blockTime   uint64
+			txID        []byte
+			txOrigin    []byte
+			clauseIndex uint32
+			token       []byte
+			sender      []byte
+			recipient   []byte
+			amount      []byte
+		)
+		if err := rows.Scan(
+			&seq,
+			&blockID,
+			&blockTime,
+			&txID,
+			&txOrigin,
+			&clauseIndex,
+			&token,
+			&sender,
+			&recipient,
+			&amount,
+		); err != nil {
+			return err
+		}
+		trans := &TokenTransfer{
+			BlockNumber: seq.BlockNumber(),
+			Index:       seq.Index(),
+			BlockID:     thor.BytesToBytes32(blockID),
+			BlockTime:   blockTime,
+			TxID:        thor.BytesToBytes32(txID),
+			TxOrigin:    thor.BytesToAddress(txOrigin),
+			ClauseIndex: clauseIndex,
+			Token:       thor.BytesToAddress(token),
+			Sender:      thor.BytesToAddress(sender),
+			Recipient:   thor.BytesToAddress(recipient),
+			Amount:      new(big.Int).SetBytes(amount),
+		}
+		if err := fn(trans); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (db *LogDB) queryTxs(ctx context.Context, query string, args ...interface{}) ([]*Tx, error) {
+	rows, err := db.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var txs []*Tx
+	for rows.Next() {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		var (
+			seq       sequence
+			blockID   []byte
+			blockTime uint64
+			txID      []byte
+			origin    []byte
+			delegator []byte
+		)
+		if err := rows.Scan(
+			&seq,
+			&blockID,
+			&blockTime,
+			&txID,
+			&origin,
+			&delegator,
+		); err != nil {
+			return nil, err
+		}
+		tx := &Tx{
+			BlockNumber: seq.BlockNumber(),
+			Index:       seq.Index(),
+			BlockID:     thor.BytesToBytes32(blockID),
+			BlockTime:   blockTime,
+			TxID:        thor.BytesToBytes32(txID),
+			Origin:      thor.BytesToAddress(origin),
+		}
+		if delegator != nil {
+			addr := thor.BytesToAddress(delegator)
+			tx.Delegator = &addr
+		}
+		txs = append(txs, tx)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-	return transfers, nil
+	return txs, nil
+}
+
+func (db *LogDB) queryInternalCalls(ctx context.Context, fn func(*InternalCall) error, query string, args ...interface{}) error {
+	rows, err := db.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		var (
+			seq         sequence
+			blockID     []byte
+			blockTime   uint64
+			txID        []byte
+			txOrigin    []byte
+			clauseIndex uint32
+			depth       uint32
+			typ         string
+			caller      []byte
+			callee      []byte
+			value       []byte
+			selector    []byte
+			callErr     sql.NullString
+		)
+		if err := rows.Scan(
+			&seq,
+			&blockID,
+			&blockTime,
+			&txID,
+			&txOrigin,
+			&clauseIndex,
+			&depth,
+			&typ,
+			&caller,
+			&callee,
+			&value,
+			&selector,
+			&callErr,
+		); err != nil {
+			return err
+		}
+		call := &InternalCall{
+			BlockNumber: seq.BlockNumber(),
+			Index:       seq.Index(),
+			BlockID:     thor.BytesToBytes32(blockID),
+			BlockTime:   blockTime,
+			TxID:        thor.BytesToBytes32(txID),
+			TxOrigin:    thor.BytesToAddress(txOrigin),
+			ClauseIndex: clauseIndex,
+			Depth:       depth,
+			Type:        typ,
+			From:        thor.BytesToAddress(caller),
+			Selector:    selector,
+			Error:       callErr.String,
+		}
+		if callee != nil {
+			to := thor.BytesToAddress(callee)
+			call.To = &to
+		}
+		if value != nil {
+			call.Value = new(big.Int).SetBytes(value)
+		}
+		if err := fn(call); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
 }
 
 // NewestBlockID query newest written block id.
@@ -369,6 +670,90 @@ func (db *LogDB) NewestBlockID() (thor.Bytes32, error) {
 	return thor.BytesToBytes32(data), nil
 }
 
+// OldestBlockNumber query the number of the oldest block which has logs written. It returns 0 if no logs.
+func (db *LogDB) OldestBlockNumber() (uint32, error) {
+	var seq sql.NullInt64
+	row := db.stmtCache.MustPrepare(`SELECT MIN(seq) FROM (
+			SELECT MIN(seq) AS seq FROM event
+			UNION ALL
+			SELECT MIN(seq) FROM transfer
+			UNION ALL
+			SELECT MIN(seq) FROM tx)`).QueryRow()
+	if err := row.Scan(&seq); err != nil {
+		return 0, err
+	}
+	return sequence(seq.Int64).BlockNumber(), nil
+}
+
+// IndexedFrom returns the first block indexed by the given table, which is added after logs were written,
+// or misses logs of some blocks. Logs of older blocks are missing in the table until they are rebuilt.
+// It returns 0 if the table is complete, and math.MaxUint32 if no block is indexed.
+func (db *LogDB) IndexedFrom(table string) (uint32, error) {
+	var from uint32
+	if err := db.stmtCache.MustPrepare("SELECT fromBlock FROM coverage WHERE tbl = ?").QueryRow(table).Scan(&from); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return from, nil
+}
+
+// AutoVacuum returns whether incremental auto vacuum is enabled, which is required by Writer.Vacuum to shrink the db.
+func (db *LogDB) AutoVacuum() (bool, error) {
+	var mode int
+	if err := db.db.QueryRow("pragma auto_vacuum").Scan(&mode); err != nil {
+		return false, err
+	}
+	// 2 is incremental
+	return mode == 2, nil
+}
+
+// EnableAutoVacuum enables incremental auto vacuum of log db created with it disabled. It rebuilds the db file,
+// which takes a long time on a large db and needs free disk space of the db size, so no logs should be written meanwhile.
+func (db *LogDB) EnableAutoVacuum(ctx context.Context) error {
+	conn, err := db.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	// the mode is persisted by the following VACUUM
+	if _, err := conn.ExecContext(ctx, "pragma auto_vacuum = incremental"); err != nil {
+		return err
+	}
+	_, err = conn.ExecContext(ctx, "VACUUM")
+	return err
+}
+
+// Stats is the numbers of records in the log db.
+type Stats struct {
+	Events         uint64
+	Transfers      uint64
+	TokenTransfers uint64
+	Txs            uint64
+	InternalCalls  uint64
+	Refs           uint64
+}
+
+// Stats counts records in the log db. It scans all tables, so it may take a long time on a large db.
+func (db *LogDB) Stats(ctx context.Context) (*Stats, error) {
+	var stats Stats
+	for table, n := range map[string]*uint64{
+		"event":          &stats.Events,
+		"transfer":       &stats.Transfers,
+		"token_transfer": &stats.TokenTransfers,
+		"tx":             &stats.Txs,
+		"internal_call":  &stats.InternalCalls,
+		"ref":            &stats.Refs,
+	} {
+		if err := db.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(n); err != nil {
+			return nil, err
+		}
+	}
+	return &stats, nil
+}
+
 // HasBlockID query whether given block id related logs were written.
 func (db *LogDB) HasBlockID(id thor.Bytes32) (bool, error) {
 	const query = `SELECT COUNT(*) FROM (
@@ -396,6 +781,25 @@ func (db *LogDB) NewWriterSyncOff() *Writer {
 	return &Writer{conn: db.wconnSyncOff, stmtCache: db.stmtCache}
 }
 
+// tokenTransferEventID is the topic0 of VIP-180 (ERC-20) Transfer(address,address,uint256) event.
+var tokenTransferEventID = thor.MustParseBytes32("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+
+// DecodeTokenTransfer decodes the event as a VIP-180 Transfer event, which is indexed as a token transfer.
+// ok is false if the event is not in the standard form.
+func DecodeTokenTransfer(topics []thor.Bytes32, data []byte) (sender, recipient thor.Address, amount *big.Int, ok bool) {
+	if len(topics) != 3 || topics[0] != tokenTransferEventID || len(data) != 32 {
+		return
+	}
+	for _, topic := range topics[1:] {
+		for _, b := range topic[:12] {
+			if b != 0 {
+				return
+			}
+		}
+	}
+	return thor.BytesToAddress(topics[1][12:]), thor.BytesToAddress(topics[2][12:]), new(big.Int).SetBytes(data), true
+}
+
 func topicValue(topics []thor.Bytes32, i int) []byte {
 	if i < len(topics) {
 		return topics[i][:]
@@ -421,9 +825,151 @@ func (w *Writer) Truncate(blockNum uint32) error {
 	if err := w.exec("DELETE FROM transfer WHERE seq >= ?", seq); err != nil {
 		return err
 	}
+	if err := w.exec("DELETE FROM token_transfer WHERE seq >= ?", seq); err != nil {
+		return err
+	}
+	if err := w.exec("DELETE FROM tx WHERE seq >= ?", seq); err != nil {
+		return err
+	}
+	if err := w.exec("DELETE FROM tx_recipient WHERE seq >= ?", seq); err != nil {
+		return err
+	}
+	if err := w.exec("DELETE FROM internal_call WHERE seq >= ?", seq); err != nil {
+		return err
+	}
+	// logs written from now on are complete, except internal calls, which are written only if blocks are traced
+	return w.MarkRebuilt(blockNum, math.MaxUint32)
+}
+
+// DeleteRange deletes logs of blocks in range [from, to].
+// Internal calls are kept, since they can't be rewritten from receipts, see DeleteInternalCalls.
+func (w *Writer) DeleteRange(from, to uint32) error {
+	// the max index is 1<<31-1
+	start, end := newSequence(from, 0), newSequence(to, math.MaxInt32)
+	for _, table := range []string{"event", "transfer", "token_transfer", "tx", "tx_recipient"} {
+		if err := w.exec("DELETE FROM "+table+" WHERE seq >= ? AND seq <= ?", start, end); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// DeleteInternalCalls deletes internal calls of blocks in range [from, to].
+func (w *Writer) DeleteInternalCalls(from, to uint32) error {
+	// the max index is 1<<31-1
+	return w.exec("DELETE FROM internal_call WHERE seq >= ? AND seq <= ?", newSequence(from, 0), newSequence(to, math.MaxInt32))
+}
+
+// Prune deletes logs before blockNum (excluded), and refs no longer referenced by remaining logs.
+func (w *Writer) Prune(blockNum uint32) error {
+	seq := newSequence(blockNum, 0)
+
+	// block and tx ids are unique, so refs of ids in pruned logs can only be referenced by topics of later logs.
+	// topic4 is never set since a log has at most 4 topics.
+	const idQuery = `DELETE FROM ref WHERE id IN (
+	SELECT blockID FROM event WHERE seq < ?1 UNION SELECT txID FROM event WHERE seq < ?1
+	UNION SELECT blockID FROM transfer WHERE seq < ?1 UNION SELECT txID FROM transfer WHERE seq < ?1
+	UNION SELECT blockID FROM tx WHERE seq < ?1 UNION SELECT txID FROM tx WHERE seq < ?1
+	UNION SELECT blockID FROM internal_call WHERE seq < ?1 UNION SELECT txID FROM internal_call WHERE seq < ?1)
+AND NOT EXISTS (SELECT 1 FROM event WHERE topic0 = ref.id AND seq >= ?1)
+AND NOT EXISTS (SELECT 1 FROM event WHERE topic1 = ref.id AND seq >= ?1)
+AND NOT EXISTS (SELECT 1 FROM event WHERE topic2 = ref.id AND seq >= ?1)
+AND NOT EXISTS (SELECT 1 FROM event WHERE topic3 = ref.id AND seq >= ?1)`
+	if err := w.exec(idQuery, seq); err != nil {
+		return err
+	}
+
+	// refs of addresses and topics are checked against all indexed columns of remaining logs.
+	// Origins of txs are all in the tx table, so unindexed txOrigin columns are covered, unless the tx table
+	// is incomplete. Only topics of padded values are deleted, since columns of block and tx ids are not indexed,
+	// and ids are hashes which practically never have 12 leading zero bytes.
+	const refQuery = `DELETE FROM ref WHERE id IN (
+	SELECT address FROM event WHERE seq < ?1 UNION SELECT topic1 FROM event WHERE seq < ?1
+	UNION SELECT topic2 FROM event WHERE seq < ?1 UNION SELECT topic3 FROM event WHERE seq < ?1
+	UNION SELECT txOrigin FROM transfer WHERE seq < ?1 UNION SELECT sender FROM transfer WHERE seq < ?1
+	UNION SELECT recipient FROM transfer WHERE seq < ?1
+	UNION SELECT token FROM token_transfer WHERE seq < ?1 UNION SELECT sender FROM token_transfer WHERE seq < ?1
+	UNION SELECT recipient FROM token_transfer WHERE seq < ?1
+	UNION SELECT origin FROM tx WHERE seq < ?1 UNION SELECT delegator FROM tx WHERE seq < ?1
+	UNION SELECT recipient FROM tx_recipient WHERE seq < ?1
+	UNION SELECT caller FROM internal_call WHERE seq < ?1 UNION SELECT callee FROM internal_call WHERE seq < ?1)
+AND (LENGTH(data) = 20 OR (LENGTH(data) = 32 AND SUBSTR(data, 1, 12) = ZEROBLOB(12)))
+AND NOT EXISTS (SELECT 1 FROM event WHERE address = ref.id AND seq >= ?1)
+AND NOT EXISTS (SELECT 1 FROM event WHERE topic0 = ref.id AND seq >= ?1)
+AND NOT EXISTS (SELECT 1 FROM event WHERE topic1 = ref.id AND seq >= ?1)
+AND NOT EXISTS (SELECT 1 FROM event WHERE topic2 = ref.id AND seq >= ?1)
+AND NOT EXISTS (SELECT 1 FROM event WHERE topic3 = ref.id AND seq >= ?1)
+AND NOT EXISTS (SELECT 1 FROM transfer WHERE txOrigin = ref.id AND seq >= ?1)
+AND NOT EXISTS (SELECT 1 FROM transfer WHERE sender = ref.id AND seq >= ?1)
+AND NOT EXISTS (SELECT 1 FROM transfer WHERE recipient = ref.id AND seq >= ?1)
+AND NOT EXISTS (SELECT 1 FROM token_transfer WHERE token = ref.id AND seq >= ?1)
+AND NOT EXISTS (SELECT 1 FROM token_transfer WHERE sender = ref.id AND seq >= ?1)
+AND NOT EXISTS (SELECT 1 FROM token_transfer WHERE recipient = ref.id AND seq >= ?1)
+AND NOT EXISTS (SELECT 1 FROM tx WHERE origin = ref.id AND seq >= ?1)
+AND NOT EXISTS (SELECT 1 FROM tx WHERE delegator = ref.id AND seq >= ?1)
+AND NOT EXISTS (SELECT 1 FROM tx_recipient WHERE recipient = ref.id AND seq >= ?1)
+AND NOT EXISTS (SELECT 1 FROM internal_call WHERE caller = ref.id AND seq >= ?1)
+AND NOT EXISTS (SELECT 1 FROM internal_call WHERE callee = ref.id AND seq >= ?1)
+AND NOT EXISTS (SELECT 1 FROM coverage WHERE tbl = ?2 AND fromBlock > ?3)`
+	if err := w.exec(refQuery, seq, TxTable, blockNum); err != nil {
+		return err
+	}
+
+	for _, table := range []string{"event", "transfer", "token_transfer", "tx", "tx_recipient", "internal_call"} {
+		if err := w.exec("DELETE FROM "+table+" WHERE seq < ?", seq); err != nil {
+			return err
+		}
+	}
+	// tables indexed from a pruned block are complete now
+	return w.exec("DELETE FROM coverage WHERE fromBlock <= ?", blockNum)
+}
+
+// MarkRebuilt records that logs of blocks in range [from, to] are rewritten, so tables indexed from a block in
+// the range, or right after it, are complete since from. Internal calls are not covered, see MarkCallsRebuilt.
+func (w *Writer) MarkRebuilt(from, to uint32) error {
+	return w.markRebuilt(from, to, "tbl != ?3")
+}
+
+// MarkCallsRebuilt records that internal calls of blocks in range [from, to] are rewritten by tracing the blocks.
+// It should be called before the calls are written, so that blocks failed to trace are recorded by MarkIndexedFrom.
+func (w *Writer) MarkCallsRebuilt(from, to uint32) error {
+	return w.markRebuilt(from, to, "tbl = ?3")
+}
+
+func (w *Writer) markRebuilt(from, to uint32, tableCond string) error {
+	if err := w.exec("UPDATE coverage SET fromBlock = ?1 WHERE fromBlock > ?1 AND fromBlock <= ?2 + 1 AND "+tableCond, from, uint64(to), InternalCallTable); err != nil {
+		return err
+	}
+	// block 0 is written when the chain is initialized
+	return w.exec("DELETE FROM coverage WHERE fromBlock <= 1")
+}
+
+// MarkIndexedFrom records that logs of blocks before blockNum are missing in the table, e.g. blocks failed to
+// trace for internal calls. It has no effect if the table is already indexed from a later block.
+func (w *Writer) MarkIndexedFrom(table string, blockNum uint32) error {
+	if blockNum <= 1 {
+		return nil
+	}
+	return w.exec("INSERT OR REPLACE INTO coverage(tbl, fromBlock) SELECT ?1, ?2 WHERE NOT EXISTS (SELECT 1 FROM coverage WHERE tbl = ?1 AND fromBlock >= ?2)", table, blockNum)
+}
+
+// Vacuum commits accumulated logs, and then removes at most the given number of free pages
+// to shrink the db file. It has no effect on log db created with auto vacuum disabled, see EnableAutoVacuum.
+func (w *Writer) Vacuum(pages int) error {
+	if err := w.Commit(); err != nil {
+		return err
+	}
+	// each freed page yields a row
+	rows, err := w.conn.QueryContext(context.Background(), fmt.Sprintf("pragma incremental_vacuum(%d)", pages))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+	}
+	return rows.Err()
+}
+
 // Write writes all logs of the given block.
 func (w *Writer) Write(b *block.Block, receipts tx.Receipts) error {
 	var (
@@ -443,6 +989,19 @@ func (w *Writer) Write(b *block.Block, receipts tx.Receipts) error {
 		}
 	)
 
+	if len(txs) > 0 {
+		if err := w.exec(
+			"INSERT OR IGNORE INTO ref(data) VALUES(?)",
+			blockID[:]); err != nil {
+			return err
+		}
+		for i, tx := range txs {
+			if err := w.writeTx(newSequence(blockNum, uint32(i)), blockTimestamp, blockID[:], tx); err != nil {
+				return err
+			}
+		}
+	}
+
 	for i, r := range receipts {
 		if isReceiptEmpty(r) {
 			continue
@@ -519,6 +1078,22 @@ func (w *Writer) Write(b *block.Block, receipts tx.Receipts) error {
 					topicValue(ev.Topics, 4)); err != nil {
 					return err
 				}
+
+				if sender, recipient, amount, ok := DecodeTokenTransfer(ev.Topics, ev.Data); ok {
+					if err := w.writeTokenTransfer(
+						newSequence(blockNum, eventCount),
+						blockTimestamp,
+						uint32(clauseIndex),
+						blockID[:],
+						txID[:],
+						txOrigin[:],
+						ev.Address[:],
+						sender[:],
+						recipient[:],
+						amount.Bytes()); err != nil {
+						return err
+					}
+				}
 				eventCount++
 			}
 
@@ -557,6 +1132,164 @@ func (w *Writer) Write(b *block.Block, receipts tx.Receipts) error {
 	return nil
 }
 
+// WriteInternalCalls writes internal calls of the given block, in the order of execution.
+// Block and position fields of calls are ignored, as they are determined by the block and the order.
+func (w *Writer) WriteInternalCalls(b *block.Block, calls []*InternalCall) error {
+	if len(calls) == 0 {
+		return nil
+	}
+	var (
+		blockID   = b.Header().ID()
+		blockNum  = b.Header().Number()
+		blockTime = b.Header().Timestamp()
+	)
+	if err := w.exec(
+		"INSERT OR IGNORE INTO ref(data) VALUES(?)",
+		blockID[:]); err != nil {
+		return err
+	}
+
+	for i, call := range calls {
+		var callee, value, selector, callErr interface{}
+		if call.To != nil {
+			callee = call.To[:]
+		}
+		if call.Value != nil {
+			value = call.Value.Bytes()
+		}
+		if call.Selector != nil {
+			selector = call.Selector
+		}
+		if call.Error != "" {
+			callErr = call.Error
+		}
+		if err := w.exec(
+			"INSERT OR IGNORE INTO ref(data) VALUES(?),(?),(?),(?)",
+			call.TxID[:],
+			call.TxOrigin[:],
+			call.From[:],
+			callee); err != nil {
+			return err
+		}
+
+		const query = "INSERT OR IGNORE INTO internal_call(seq, blockTime, clauseIndex, depth, type, value, selector, error, blockID, txID, txOrigin, caller, callee) " +
+			"VALUES(?,?,?,?,?,?,?,?," +
+			refIDQuery + "," +
+			refIDQuery + "," +
+			refIDQuery + "," +
+			refIDQuery + "," +
+			refIDQuery + ")"
+		if err := w.exec(
+			query,
+			newSequence(blockNum, uint32(i)),
+			blockTime,
+			call.ClauseIndex,
+			call.Depth,
+			call.Type,
+			value,
+			selector,
+			callErr,
+			blockID[:],
+			call.TxID[:],
+			call.TxOrigin[:],
+			call.From[:],
+			callee); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTx writes the tx and its clause recipients, the ref of block id should be already inserted.
+func (w *Writer) writeTx(seq sequence, blockTime uint64, blockID []byte, tx *tx.Transaction) error {
+	var (
+		txID         = tx.ID()
+		origin, _    = tx.Origin()
+		delegator, _ = tx.Delegator()
+		delegatorRef interface{}
+	)
+	if err := w.exec(
+		"INSERT OR IGNORE INTO ref(data) VALUES(?),(?)",
+		txID[:], origin[:]); err != nil {
+		return err
+	}
+	if delegator != nil {
+		if err := w.exec(
+			"INSERT OR IGNORE INTO ref(data) VALUES(?)",
+			delegator[:]); err != nil {
+			return err
+		}
+		delegatorRef = delegator[:]
+	}
+
+	const query = "INSERT OR IGNORE INTO tx(seq, blockTime, blockID, txID, origin, delegator) " +
+		"VALUES(?,?," +
+		refIDQuery + "," +
+		refIDQuery + "," +
+		refIDQuery + "," +
+		refIDQuery + ")"
+	if err := w.exec(
+		query,
+		seq,
+		blockTime,
+		blockID,
+		txID[:],
+		origin[:],
+		delegatorRef); err != nil {
+		return err
+	}
+
+	for _, clause := range tx.Clauses() {
+		to := clause.To()
+		if to == nil {
+			continue
+		}
+		if err := w.exec(
+			"INSERT OR IGNORE INTO ref(data) VALUES(?)",
+			to[:]); err != nil {
+			return err
+		}
+		if err := w.exec(
+			"INSERT OR IGNORE INTO tx_recipient(seq, recipient) VALUES(?,"+refIDQuery+")",
+			seq,
+			to[:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTokenTransfer writes a token transfer, the refs of block id, tx id, tx origin and token should be already inserted.
+func (w *Writer) writeTokenTransfer(seq sequence, blockTime uint64, clauseIndex uint32, blockID, txID, txOrigin, token, sender, recipient, amount []byte) error {
+	if err := w.exec(
+		"INSERT OR IGNORE INTO ref (data) VALUES(?),(?)",
+		sender,
+		recipient); err != nil {
+		return err
+	}
+	const query = "INSERT OR IGNORE INTO token_transfer(seq, blockTime, clauseIndex, amount, blockID, txID, txOrigin, token, sender, recipient) " +
+		"VALUES(?,?,?,?," +
+		refIDQuery + "," +
+		refIDQuery + "," +
+		refIDQuery + "," +
+		refIDQuery + "," +
+		refIDQuery + "," +
+		refIDQuery + ")"
+
+	return w.exec(
+		query,
+		seq,
+		blockTime,
+		clauseIndex,
+		amount,
+		blockID,
+		txID,
+		txOrigin,
+		token,
+		sender,
+		recipient)
+}
+
 // Commit commits accumulated logs.
 func (w *Writer) Commit() (err error) {
 	if w.tx == nil {
@@ -6,11 +6,15 @@
 package logdb_test
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
+	"database/sql"
 	"math/big"
+	"path/filepath"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/stretchr/testify/assert"
 	"github.com/vechain/thor/v2/block"
@@ -244,8 +248,851 @@ func TestEvents(t *testing.T) {
 	}
 }
 
+func TestCursor(t *testing.T) {
+	db, err := logdb.NewMem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	b := new(block.Builder).Build()
+	var allEvents eventLogs
+	for i := 0; i < 10; i++ {
+		b = new(block.Builder).
+			ParentID(b.Header().ID()).
+			Transaction(newTx()).
+			Build()
+		receipts := tx.Receipts{newEventOnlyReceipt()}
+
+		w := db.NewWriter()
+		if err := w.Write(b, receipts); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	allEvents, err = db.FilterEvents(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := func(ev *logdb.Event) *logdb.Cursor {
+		return logdb.NewCursor(ev.BlockNumber, ev.Index)
+	}
+
+	tests := []struct {
+		name string
+		arg  *logdb.EventFilter
+		want eventLogs
+	}{
+		{"empty cursor", &logdb.EventFilter{Options: &logdb.Options{Limit: 3, Cursor: &logdb.Cursor{}}}, allEvents[:3]},
+		{"empty cursor desc", &logdb.EventFilter{Order: logdb.DESC, Options: &logdb.Options{Limit: 3, Cursor: &logdb.Cursor{}}}, allEvents.Reverse()[:3]},
+		{"cursor asc", &logdb.EventFilter{Options: &logdb.Options{Limit: 3, Cursor: at(allEvents[2])}}, allEvents[3:6]},
+		{"cursor desc", &logdb.EventFilter{Order: logdb.DESC, Options: &logdb.Options{Limit: 3, Cursor: at(allEvents[7])}}, allEvents[4:7].Reverse()},
+		{"cursor ignores offset", &logdb.EventFilter{Options: &logdb.Options{Offset: 5, Limit: 3, Cursor: at(allEvents[2])}}, allEvents[3:6]},
+		{"cursor with range", &logdb.EventFilter{Range: &logdb.Range{From: 1, To: 5}, Options: &logdb.Options{Limit: 10, Cursor: at(allEvents[2])}}, allEvents[3:4]},
+		{"cursor at end", &logdb.EventFilter{Options: &logdb.Options{Limit: 3, Cursor: at(allEvents[9])}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := db.FilterEvents(context.Background(), tt.arg)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, eventLogs(got))
+		})
+	}
+
+	// cursor round trips through its text form
+	data, err := at(allEvents[5]).MarshalText()
+	assert.Nil(t, err)
+	var c logdb.Cursor
+	assert.Nil(t, c.UnmarshalText(data))
+	assert.Equal(t, *at(allEvents[5]), c)
+
+	assert.Nil(t, c.UnmarshalText(nil))
+	assert.Equal(t, logdb.Cursor{}, c)
+	assert.NotNil(t, c.UnmarshalText([]byte("invalid")))
+}
+
+func TestAggregate(t *testing.T) {
+	db, err := logdb.NewMem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var (
+		addr1 = randAddress()
+		addr2 = randAddress()
+		b     = new(block.Builder).Build()
+	)
+	// blocks 2 to 11, each has 1 event and 1 transfer with amount of the block number
+	for i := 0; i < 10; i++ {
+		b = new(block.Builder).
+			ParentID(b.Header().ID()).
+			Transaction(newTx()).
+			Build()
+		addr := addr1
+		if i%3 == 0 {
+			addr = addr2
+		}
+		receipts := tx.Receipts{{
+			Outputs: []*tx.Output{{
+				Events:    tx.Events{{Address: addr}},
+				Transfers: tx.Transfers{{Sender: addr, Recipient: randAddress(), Amount: big.NewInt(int64(b.Header().Number()))}},
+			}},
+		}}
+
+		w := db.NewWriter()
+		if err := w.Write(b, receipts); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	counts, err := db.CountEvents(context.Background(), nil, &logdb.Aggregation{})
+	assert.Nil(t, err)
+	assert.Equal(t, []*logdb.EventCount{{Count: 10}}, counts)
+
+	counts, err = db.CountEvents(context.Background(), &logdb.EventFilter{Range: &logdb.Range{From: 100, To: 200}}, &logdb.Aggregation{})
+	assert.Nil(t, err)
+	assert.Equal(t, []*logdb.EventCount{{Count: 0}}, counts)
+
+	counts, err = db.CountEvents(context.Background(), &logdb.EventFilter{Range: &logdb.Range{From: 0, To: 9}}, &logdb.Aggregation{GroupBy: logdb.GroupByBlock, BucketSize: 5})
+	assert.Nil(t, err)
+	assert.Equal(t, []*logdb.EventCount{
+		{Range: &logdb.Range{From: 0, To: 4}, Count: 3},
+		{Range: &logdb.Range{From: 5, To: 9}, Count: 5},
+	}, counts)
+
+	counts, err = db.CountEvents(context.Background(), nil, &logdb.Aggregation{GroupBy: logdb.GroupByAddress})
+	assert.Nil(t, err)
+	assert.Equal(t, []*logdb.EventCount{
+		{Address: &addr1, Count: 6},
+		{Address: &addr2, Count: 4},
+	}, counts)
+
+	counts, err = db.CountEvents(context.Background(), nil, &logdb.Aggregation{GroupBy: logdb.GroupByAddress, Limit: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, []*logdb.EventCount{{Address: &addr1, Count: 6}}, counts)
+
+	_, err = db.CountEvents(context.Background(), nil, &logdb.Aggregation{GroupBy: logdb.GroupBySender})
+	assert.NotNil(t, err)
+	_, err = db.CountEvents(context.Background(), nil, &logdb.Aggregation{GroupBy: logdb.GroupByBlock})
+	assert.NotNil(t, err)
+
+	stats, err := db.TransferStatistics(context.Background(), nil, &logdb.Aggregation{})
+	assert.Nil(t, err)
+	assert.Equal(t, []*logdb.TransferStats{{Count: 10, Sum: big.NewInt(65), Min: big.NewInt(2), Max: big.NewInt(11)}}, stats)
+
+	stats, err = db.TransferStatistics(context.Background(), &logdb.TransferFilter{Order: logdb.DESC}, &logdb.Aggregation{GroupBy: logdb.GroupByBlock, BucketSize: 5})
+	assert.Nil(t, err)
+	assert.Equal(t, []*logdb.TransferStats{
+		{Range: &logdb.Range{From: 10, To: 14}, Count: 2, Sum: big.NewInt(21), Min: big.NewInt(10), Max: big.NewInt(11)},
+		{Range: &logdb.Range{From: 5, To: 9}, Count: 5, Sum: big.NewInt(35), Min: big.NewInt(5), Max: big.NewInt(9)},
+		{Range: &logdb.Range{From: 0, To: 4}, Count: 3, Sum: big.NewInt(9), Min: big.NewInt(2), Max: big.NewInt(4)},
+	}, stats)
+
+	stats, err = db.TransferStatistics(context.Background(), nil, &logdb.Aggregation{GroupBy: logdb.GroupBySender})
+	assert.Nil(t, err)
+	assert.Equal(t, []*logdb.TransferStats{
+		{Address: &addr1, Count: 6, Sum: big.NewInt(39), Min: big.NewInt(3), Max: big.NewInt(10)},
+		{Address: &addr2, Count: 4, Sum: big.NewInt(26), Min: big.NewInt(2), Max: big.NewInt(11)},
+	}, stats)
+
+	stats, err = db.TransferStatistics(context.Background(), nil, &logdb.Aggregation{GroupBy: logdb.GroupByBlock, BucketSize: 5, Limit: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, []*logdb.TransferStats{
+		{Range: &logdb.Range{From: 0, To: 4}, Count: 3, Sum: big.NewInt(9), Min: big.NewInt(2), Max: big.NewInt(4)},
+		{Range: &logdb.Range{From: 5, To: 9}, Count: 5, Sum: big.NewInt(35), Min: big.NewInt(5), Max: big.NewInt(9)},
+	}, stats)
+}
+
+func TestTransferAmountAndClause(t *testing.T) {
+	db, err := logdb.NewMem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	amounts := []*big.Int{
+		big.NewInt(0),
+		big.NewInt(1),
+		big.NewInt(255),
+		big.NewInt(256),
+		new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil),
+	}
+	sender := randAddress()
+	b := new(block.Builder).Build()
+	b = new(block.Builder).
+		ParentID(b.Header().ID()).
+		Transaction(newTx()).
+		Build()
+
+	var outputs []*tx.Output
+	for _, amount := range amounts {
+		outputs = append(outputs, &tx.Output{
+			Transfers: tx.Transfers{{Sender: sender, Recipient: randAddress(), Amount: amount}},
+		})
+	}
+	w := db.NewWriter()
+	if err := w.Write(b, tx.Receipts{{Outputs: outputs}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	amount := func(v *big.Int) *math.HexOrDecimal256 {
+		return (*math.HexOrDecimal256)(v)
+	}
+	clause := func(i uint32) *uint32 {
+		return &i
+	}
+
+	tests := []struct {
+		name     string
+		criteria *logdb.TransferCriteria
+		want     []*big.Int
+	}{
+		{"min amount", &logdb.TransferCriteria{MinAmount: amount(big.NewInt(255))}, amounts[2:]},
+		{"min amount zero", &logdb.TransferCriteria{MinAmount: amount(big.NewInt(0))}, amounts},
+		{"max amount", &logdb.TransferCriteria{MaxAmount: amount(big.NewInt(256))}, amounts[:4]},
+		{"max amount zero", &logdb.TransferCriteria{MaxAmount: amount(big.NewInt(0))}, amounts[:1]},
+		{"amount range", &logdb.TransferCriteria{MinAmount: amount(big.NewInt(1)), MaxAmount: amount(big.NewInt(300))}, amounts[1:4]},
+		{"amount range with sender", &logdb.TransferCriteria{Sender: &sender, MinAmount: amount(big.NewInt(256))}, amounts[3:]},
+		{"clause index", &logdb.TransferCriteria{ClauseIndex: clause(3)}, amounts[3:4]},
+		{"clause index with amount", &logdb.TransferCriteria{ClauseIndex: clause(3), MaxAmount: amount(big.NewInt(1))}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := db.FilterTransfers(context.Background(), &logdb.TransferFilter{CriteriaSet: []*logdb.TransferCriteria{tt.criteria}})
+			assert.Nil(t, err)
+			var gotAmounts []*big.Int
+			for _, tr := range got {
+				gotAmounts = append(gotAmounts, tr.Amount)
+			}
+			assert.Equal(t, len(tt.want), len(gotAmounts))
+			for i := range tt.want {
+				assert.Zero(t, tt.want[i].Cmp(gotAmounts[i]))
+			}
+		})
+	}
+}
+
+func TestTokenTransfers(t *testing.T) {
+	db, err := logdb.NewMem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	transferEvent := func(token, from, to thor.Address, amount *big.Int) *tx.Event {
+		var fromTopic, toTopic thor.Bytes32
+		copy(fromTopic[12:], from[:])
+		copy(toTopic[12:], to[:])
+		return &tx.Event{
+			Address: token,
+			Topics: []thor.Bytes32{
+				thor.MustParseBytes32("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"),
+				fromTopic,
+				toTopic,
+			},
+			Data: math.PaddedBigBytes(amount, 32),
+		}
+	}
+
+	var (
+		token1 = randAddress()
+		token2 = randAddress()
+		alice  = randAddress()
+		bob    = randAddress()
+	)
+	events := tx.Events{
+		transferEvent(token1, alice, bob, big.NewInt(100)),
+		newEventOnlyReceipt().Outputs[0].Events[0],
+		transferEvent(token2, bob, alice, big.NewInt(0)),
+		transferEvent(token1, bob, alice, new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)),
+	}
+	// malformed: indexed amount (ERC-721 style)
+	malformed := transferEvent(token1, alice, bob, big.NewInt(1))
+	malformed.Topics = append(malformed.Topics, thor.Bytes32{})
+	malformed.Data = nil
+	events = append(events, malformed)
+
+	b := new(block.Builder).Build()
+	b = new(block.Builder).
+		ParentID(b.Header().ID()).
+		Transaction(newTx()).
+		Build()
+	origin, _ := b.Transactions()[0].Origin()
+
+	w := db.NewWriter()
+	if err := w.Write(b, tx.Receipts{{Outputs: []*tx.Output{{Events: events}}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := db.FilterTokenTransfers(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(all))
+	assert.Equal(t, &logdb.TokenTransfer{
+		BlockNumber: b.Header().Number(),
+		Index:       0,
+		BlockID:     b.Header().ID(),
+		BlockTime:   b.Header().Timestamp(),
+		TxID:        b.Transactions()[0].ID(),
+		TxOrigin:    origin,
+		ClauseIndex: 0,
+		Token:       token1,
+		Sender:      alice,
+		Recipient:   bob,
+		Amount:      big.NewInt(100),
+	}, all[0])
+	// index follows the event index
+	assert.Equal(t, []uint32{0, 2, 3}, []uint32{all[0].Index, all[1].Index, all[2].Index})
+
+	tests := []struct {
+		name   string
+		filter *logdb.TokenTransferFilter
+		want   []*logdb.TokenTransfer
+	}{
+		{"token", &logdb.TokenTransferFilter{CriteriaSet: []*logdb.TokenTransferCriteria{{Token: &token1}}}, []*logdb.TokenTransfer{all[0], all[2]}},
+		{"sender", &logdb.TokenTransferFilter{CriteriaSet: []*logdb.TokenTransferCriteria{{Sender: &bob}}}, []*logdb.TokenTransfer{all[1], all[2]}},
+		{"token and recipient", &logdb.TokenTransferFilter{CriteriaSet: []*logdb.TokenTransferCriteria{{Token: &token1, Recipient: &alice}}}, []*logdb.TokenTransfer{all[2]}},
+		{"criteria set", &logdb.TokenTransferFilter{CriteriaSet: []*logdb.TokenTransferCriteria{{Token: &token2}, {Recipient: &bob}}}, []*logdb.TokenTransfer{all[0], all[1]}},
+		{"min amount", &logdb.TokenTransferFilter{CriteriaSet: []*logdb.TokenTransferCriteria{{MinAmount: (*math.HexOrDecimal256)(big.NewInt(100))}}}, []*logdb.TokenTransfer{all[0], all[2]}},
+		{"tx origin", &logdb.TokenTransferFilter{CriteriaSet: []*logdb.TokenTransferCriteria{{TxOrigin: &origin}}}, all},
+		{"desc", &logdb.TokenTransferFilter{Order: logdb.DESC}, []*logdb.TokenTransfer{all[2], all[1], all[0]}},
+		{"limit", &logdb.TokenTransferFilter{Order: logdb.DESC, Options: &logdb.Options{Offset: 1, Limit: 1}}, []*logdb.TokenTransfer{all[1]}},
+		{"cursor", &logdb.TokenTransferFilter{Options: &logdb.Options{Limit: 10, Cursor: logdb.NewCursor(b.Header().Number(), 0)}}, all[1:]},
+		{"out of range", &logdb.TokenTransferFilter{Range: &logdb.Range{From: 10, To: 20}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := db.FilterTokenTransfers(context.Background(), tt.filter)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// truncate removes token transfers as well
+	w = db.NewWriterSyncOff()
+	assert.Nil(t, w.Truncate(b.Header().Number()))
+	assert.Nil(t, w.Commit())
+	all, err = db.FilterTokenTransfers(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Empty(t, all)
+}
+
+func TestTxs(t *testing.T) {
+	db, err := logdb.NewMem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var (
+		originKey, _    = crypto.GenerateKey()
+		delegatorKey, _ = crypto.GenerateKey()
+		origin          = thor.Address(crypto.PubkeyToAddress(originKey.PublicKey))
+		delegator       = thor.Address(crypto.PubkeyToAddress(delegatorKey.PublicKey))
+		alice           = randAddress()
+		bob             = randAddress()
+	)
+	sign := func(trx *tx.Transaction) *tx.Transaction {
+		sig, _ := crypto.Sign(trx.SigningHash().Bytes(), originKey)
+		if trx.Features().IsDelegated() {
+			dSig, _ := crypto.Sign(trx.DelegatorSigningHash(origin).Bytes(), delegatorKey)
+			sig = append(sig, dSig...)
+		}
+		return trx.WithSignature(sig)
+	}
+	var features tx.Features
+	features.SetDelegated(true)
+
+	txs := []*tx.Transaction{
+		sign(new(tx.Builder).Clause(tx.NewClause(&alice)).Build()),
+		sign(new(tx.Builder).Clause(tx.NewClause(&alice)).Clause(tx.NewClause(&bob)).Clause(tx.NewClause(nil)).Features(features).Build()),
+		newTx(),
+	}
+	b := new(block.Builder).Build()
+	b = new(block.Builder).
+		ParentID(b.Header().ID()).
+		Transaction(txs[0]).
+		Transaction(txs[1]).
+		Transaction(txs[2]).
+		Build()
+
+	// txs are indexed even without logs
+	w := db.NewWriter()
+	if err := w.Write(b, tx.Receipts{{}, {}, {}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := db.FilterTxs(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(all))
+	assert.Equal(t, &logdb.Tx{
+		BlockNumber: b.Header().Number(),
+		Index:       1,
+		BlockID:     b.Header().ID(),
+		BlockTime:   b.Header().Timestamp(),
+		TxID:        txs[1].ID(),
+		Origin:      origin,
+		Delegator:   &delegator,
+	}, all[1])
+	assert.Nil(t, all[0].Delegator)
+
+	tests := []struct {
+		name   string
+		filter *logdb.TxFilter
+		want   []*logdb.Tx
+	}{
+		{"origin", &logdb.TxFilter{CriteriaSet: []*logdb.TxCriteria{{Origin: &origin}}}, all[:2]},
+		{"delegator", &logdb.TxFilter{CriteriaSet: []*logdb.TxCriteria{{Delegator: &delegator}}}, all[1:2]},
+		{"recipient", &logdb.TxFilter{CriteriaSet: []*logdb.TxCriteria{{Recipient: &alice}}}, all[:2]},
+		{"origin and recipient", &logdb.TxFilter{CriteriaSet: []*logdb.TxCriteria{{Origin: &origin, Recipient: &bob}}}, all[1:2]},
+		{"criteria set", &logdb.TxFilter{CriteriaSet: []*logdb.TxCriteria{{Recipient: &bob}, {Origin: &all[2].Origin}}}, all[1:]},
+		{"desc", &logdb.TxFilter{CriteriaSet: []*logdb.TxCriteria{{Recipient: &alice}}, Order: logdb.DESC}, []*logdb.Tx{all[1], all[0]}},
+		{"limit", &logdb.TxFilter{Options: &logdb.Options{Offset: 1, Limit: 1}}, all[1:2]},
+		{"cursor", &logdb.TxFilter{Options: &logdb.Options{Limit: 10, Cursor: logdb.NewCursor(b.Header().Number(), 1)}}, all[2:]},
+		{"out of range", &logdb.TxFilter{Range: &logdb.Range{From: 10, To: 20}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := db.FilterTxs(context.Background(), tt.filter)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	recipients, err := db.FilterTxRecipients(context.Background(), &logdb.Range{From: b.Header().Number(), To: b.Header().Number()})
+	assert.Nil(t, err)
+	var want []*logdb.TxRecipient
+	for i, addrs := range [][]thor.Address{{alice}, {alice, bob}} {
+		if len(addrs) > 1 && bytes.Compare(addrs[0][:], addrs[1][:]) > 0 {
+			addrs[0], addrs[1] = addrs[1], addrs[0]
+		}
+		for _, addr := range addrs {
+			want = append(want, &logdb.TxRecipient{BlockNumber: b.Header().Number(), Index: uint32(i), Recipient: addr})
+		}
+	}
+	assert.Equal(t, want, recipients)
+
+	w = db.NewWriterSyncOff()
+	assert.Nil(t, w.Truncate(b.Header().Number()))
+	assert.Nil(t, w.Commit())
+	all, err = db.FilterTxs(context.Background(), &logdb.TxFilter{CriteriaSet: []*logdb.TxCriteria{{Recipient: &alice}}})
+	assert.Nil(t, err)
+	assert.Empty(t, all)
+}
+
+func TestInternalCalls(t *testing.T) {
+	db, err := logdb.New(filepath.Join(t.TempDir(), "logs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var (
+		trx      = newTx()
+		origin   = randAddress()
+		contract = randAddress()
+		token    = randAddress()
+		selector = []byte{0xa9, 0x05, 0x9c, 0xbb}
+	)
+	calls := []*logdb.InternalCall{
+		{TxID: trx.ID(), TxOrigin: origin, Depth: 1, Type: "CALL", From: contract, To: &token, Value: big.NewInt(0), Selector: selector},
+		{TxID: trx.ID(), TxOrigin: origin, Depth: 2, Type: "STATICCALL", From: token, To: &contract, Selector: []byte{1, 2, 3, 4}},
+		{TxID: trx.ID(), TxOrigin: origin, ClauseIndex: 1, Depth: 1, Type: "CREATE", From: contract, Value: big.NewInt(100), Error: "execution reverted"},
+	}
+	b := new(block.Builder).Build()
+	b = new(block.Builder).
+		ParentID(b.Header().ID()).
+		Transaction(trx).
+		Build()
+
+	w := db.NewWriter()
+	assert.Nil(t, w.WriteInternalCalls(b, calls))
+	assert.Nil(t, w.Commit())
+
+	all, err := db.FilterInternalCalls(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, len(calls), len(all))
+	for i, call := range calls {
+		want := *call
+		want.BlockNumber = b.Header().Number()
+		want.Index = uint32(i)
+		want.BlockID = b.Header().ID()
+		want.BlockTime = b.Header().Timestamp()
+		assert.Equal(t, &want, all[i])
+	}
+
+	tests := []struct {
+		name   string
+		filter *logdb.InternalCallFilter
+		want   []*logdb.InternalCall
+	}{
+		{"from", &logdb.InternalCallFilter{CriteriaSet: []*logdb.InternalCallCriteria{{From: &contract}}}, []*logdb.InternalCall{all[0], all[2]}},
+		{"to", &logdb.InternalCallFilter{CriteriaSet: []*logdb.InternalCallCriteria{{To: &token}}}, all[:1]},
+		{"to and selector", &logdb.InternalCallFilter{CriteriaSet: []*logdb.InternalCallCriteria{{To: &contract, Selector: selector}}}, nil},
+		{"selector", &logdb.InternalCallFilter{CriteriaSet: []*logdb.InternalCallCriteria{{Selector: selector}}}, all[:1]},
+		{"tx origin", &logdb.InternalCallFilter{CriteriaSet: []*logdb.InternalCallCriteria{{TxOrigin: &origin}}}, all},
+		{"criteria set", &logdb.InternalCallFilter{CriteriaSet: []*logdb.InternalCallCriteria{{To: &contract}, {To: &token}}}, all[:2]},
+		{"desc", &logdb.InternalCallFilter{Order: logdb.DESC, Options: &logdb.Options{Limit: 2}}, []*logdb.InternalCall{all[2], all[1]}},
+		{"cursor", &logdb.InternalCallFilter{Options: &logdb.Options{Limit: 10, Cursor: logdb.NewCursor(b.Header().Number(), 0)}}, all[1:]},
+		{"out of range", &logdb.InternalCallFilter{Range: &logdb.Range{From: 10, To: 20}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := db.FilterInternalCalls(context.Background(), tt.filter)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	stats, err := db.Stats(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), stats.InternalCalls)
+
+	w = db.NewWriterSyncOff()
+	assert.Nil(t, w.Truncate(b.Header().Number()))
+	assert.Nil(t, w.Commit())
+	all, err = db.FilterInternalCalls(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Empty(t, all)
+}
+
+func TestPrune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.db")
+	db, err := logdb.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	b := new(block.Builder).Build()
+	var (
+		prunedTxID thor.Bytes32
+		shared     = randAddress()
+	)
+	w := db.NewWriter()
+	for i := 0; i < 10; i++ {
+		trx := newTx()
+		b = new(block.Builder).
+			ParentID(b.Header().ID()).
+			Transaction(trx).
+			Build()
+		receipt := newReceipt()
+		if i == 0 {
+			prunedTxID = trx.ID()
+			receipt.Outputs[0].Transfers[0].Sender = shared
+			// a padded address topic is deleted with the log
+			receipt.Outputs[0].Events[0].Topics = append(receipt.Outputs[0].Events[0].Topics, thor.BytesToBytes32(randAddress().Bytes()))
+		}
+		if i == 9 {
+			// the id of a pruned tx is referenced by a later topic
+			receipt.Outputs[0].Events[0].Topics = append(receipt.Outputs[0].Events[0].Topics, prunedTxID)
+			// the address of a pruned transfer is referenced by a later event
+			receipt.Outputs[0].Events[0].Address = shared
+		}
+		if err := w.Write(b, tx.Receipts{receipt}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	countRefs := func() (n int) {
+		sdb, err := sql.Open("sqlite3", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sdb.Close()
+		if err := sdb.QueryRow("SELECT COUNT(*) FROM ref").Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	allEvents, err := db.FilterEvents(context.Background(), nil)
+	assert.Nil(t, err)
+	allTransfers, err := db.FilterTransfers(context.Background(), nil)
+	assert.Nil(t, err)
+	allTxs, err := db.FilterTxs(context.Background(), nil)
+	assert.Nil(t, err)
+	refs := countRefs()
+
+	oldest, err := db.OldestBlockNumber()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(2), oldest)
+
+	// block numbers start from 2
+	assert.Nil(t, w.Prune(7))
+	assert.Nil(t, w.Vacuum(100))
+
+	events, err := db.FilterEvents(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, allEvents[5:], events)
+	assert.Equal(t, prunedTxID, *events[len(events)-1].Topics[1])
+
+	transfers, err := db.FilterTransfers(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, allTransfers[5:], transfers)
+
+	txs, err := db.FilterTxs(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, allTxs[5:], txs)
+
+	// refs of 5 block ids, 4 tx ids, 19 addresses and a topic are deleted, random topics are kept
+	assert.Equal(t, refs-29, countRefs())
+
+	oldest, err = db.OldestBlockNumber()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(7), oldest)
+
+	// prune again is a no-op
+	assert.Nil(t, w.Prune(7))
+	assert.Nil(t, w.Commit())
+	assert.Equal(t, refs-29, countRefs())
+}
+
+func TestIndexedFrom(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.db")
+	db, err := logdb.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := new(block.Builder).Build()
+	w := db.NewWriter()
+	for i := 0; i < 5; i++ {
+		b = new(block.Builder).
+			ParentID(b.Header().ID()).
+			Transaction(newTx()).
+			Build()
+		if err := w.Write(b, tx.Receipts{newReceipt()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assert.Nil(t, w.Commit())
+
+	from, err := db.IndexedFrom(logdb.TokenTransferTable)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(0), from)
+	db.Close()
+
+	// simulate a log db written before the token transfer table is added
+	sdb, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = sdb.Exec("DROP TABLE token_transfer; DROP TABLE tx; DROP TABLE tx_recipient")
+	assert.Nil(t, err)
+	sdb.Close()
+
+	db, err = logdb.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	from, err = db.IndexedFrom(logdb.TokenTransferTable)
+	assert.Nil(t, err)
+	assert.Equal(t, b.Header().Number()+1, from)
+	from, err = db.IndexedFrom(logdb.TxTable)
+	assert.Nil(t, err)
+	assert.Equal(t, b.Header().Number()+1, from)
+
+	w = db.NewWriter()
+	// rebuilding blocks not adjacent to the indexed range keeps it
+	assert.Nil(t, w.MarkRebuilt(1, 2))
+	assert.Nil(t, w.Commit())
+	from, err = db.IndexedFrom(logdb.TokenTransferTable)
+	assert.Nil(t, err)
+	assert.Equal(t, b.Header().Number()+1, from)
+
+	assert.Nil(t, w.MarkRebuilt(3, b.Header().Number()))
+	assert.Nil(t, w.Commit())
+	from, err = db.IndexedFrom(logdb.TokenTransferTable)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(3), from)
+
+	assert.Nil(t, w.MarkRebuilt(1, 2))
+	assert.Nil(t, w.Commit())
+	from, err = db.IndexedFrom(logdb.TokenTransferTable)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(0), from)
+	from, err = db.IndexedFrom(logdb.TxTable)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(0), from)
+}
+
+func TestIndexedFromInternalCalls(t *testing.T) {
+	db, err := logdb.New(filepath.Join(t.TempDir(), "logs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	indexedFrom := func() uint32 {
+		from, err := db.IndexedFrom(logdb.InternalCallTable)
+		assert.Nil(t, err)
+		return from
+	}
+
+	w := db.NewWriter()
+	// blocks failed to trace
+	assert.Nil(t, w.MarkIndexedFrom(logdb.InternalCallTable, 10))
+	assert.Nil(t, w.MarkIndexedFrom(logdb.InternalCallTable, 5))
+	assert.Nil(t, w.Commit())
+	assert.Equal(t, uint32(10), indexedFrom())
+
+	// rewriting logs doesn't rewrite internal calls
+	assert.Nil(t, w.MarkRebuilt(2, 20))
+	assert.Nil(t, w.Truncate(3))
+	assert.Nil(t, w.Commit())
+	assert.Equal(t, uint32(10), indexedFrom())
+
+	assert.Nil(t, w.MarkCallsRebuilt(6, 9))
+	assert.Nil(t, w.Commit())
+	assert.Equal(t, uint32(6), indexedFrom())
+
+	// not indexed at all
+	assert.Nil(t, w.MarkIndexedFrom(logdb.InternalCallTable, math.MaxUint32))
+	assert.Nil(t, w.Commit())
+	assert.Equal(t, uint32(math.MaxUint32), indexedFrom())
+
+	assert.Nil(t, w.MarkCallsRebuilt(1, math.MaxUint32))
+	assert.Nil(t, w.Commit())
+	assert.Equal(t, uint32(0), indexedFrom())
+}
+
+func TestEnableAutoVacuum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.db")
+
+	// create a log db with auto vacuum disabled
+	sdb, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = sdb.Exec("CREATE TABLE legacy (x)")
+	assert.Nil(t, err)
+	sdb.Close()
+
+	db, err := logdb.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	enabled, err := db.AutoVacuum()
+	assert.Nil(t, err)
+	assert.False(t, enabled)
+
+	assert.Nil(t, db.EnableAutoVacuum(context.Background()))
+	enabled, err = db.AutoVacuum()
+	assert.Nil(t, err)
+	assert.True(t, enabled)
+}
+
 // TestLogDB_NewestBlockID performs a series of read/write tests on the NewestBlockID functionality of the LogDB.
 // It validates the correctness of the NewestBlockID method under various scenarios.
+func TestDeleteRange(t *testing.T) {
+	db, err := logdb.NewMem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var (
+		blocks   []*block.Block
+		receipts []tx.Receipts
+	)
+	b := new(block.Builder).Build()
+	w := db.NewWriter()
+	for i := 0; i < 10; i++ {
+		b = new(block.Builder).
+			ParentID(b.Header().ID()).
+			Transaction(newTx()).
+			Build()
+		blocks = append(blocks, b)
+		receipts = append(receipts, tx.Receipts{newReceipt()})
+		if err := w.Write(b, receipts[i]); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.WriteInternalCalls(b, []*logdb.InternalCall{{Type: "CALL", From: randAddress()}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	allEvents, err := db.FilterEvents(context.Background(), nil)
+	assert.Nil(t, err)
+	allTransfers, err := db.FilterTransfers(context.Background(), nil)
+	assert.Nil(t, err)
+	allTxs, err := db.FilterTxs(context.Background(), nil)
+	assert.Nil(t, err)
+	stats, err := db.Stats(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(len(allEvents)), stats.Events)
+	assert.Equal(t, uint64(len(allTransfers)), stats.Transfers)
+	assert.Equal(t, uint64(len(allTxs)), stats.Txs)
+
+	// block numbers start from 2, delete blocks [5, 7]
+	assert.Nil(t, w.DeleteRange(5, 7))
+	assert.Nil(t, w.Commit())
+
+	events, err := db.FilterEvents(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, append(append([]*logdb.Event(nil), allEvents[:3]...), allEvents[6:]...), events)
+	txs, err := db.FilterTxs(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, append(append([]*logdb.Tx(nil), allTxs[:3]...), allTxs[6:]...), txs)
+
+	deleted, err := db.Stats(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, stats.Events-3, deleted.Events)
+	assert.Equal(t, stats.Transfers-3, deleted.Transfers)
+	assert.Equal(t, stats.Txs-3, deleted.Txs)
+	// internal calls are kept until deleted explicitly
+	assert.Equal(t, stats.InternalCalls, deleted.InternalCalls)
+	assert.Nil(t, w.DeleteInternalCalls(5, 7))
+	assert.Nil(t, w.Commit())
+	deleted, err = db.Stats(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, stats.InternalCalls-3, deleted.InternalCalls)
+
+	// rewrite the deleted range
+	for i := 3; i < 6; i++ {
+		if err := w.Write(blocks[i], receipts[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assert.Nil(t, w.Commit())
+
+	events, err = db.FilterEvents(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, allEvents, events)
+	transfers, err := db.FilterTransfers(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, allTransfers, transfers)
+	txs, err = db.FilterTxs(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, allTxs, txs)
+}
+
 func TestLogDB_NewestBlockID(t *testing.T) {
 	db, err := logdb.NewMem()
 	if err != nil {
@@ -431,3 +1278,64 @@ func TestLogDB_HasBlockID(t *testing.T) {
 	}
 	assert.True(t, has)
 }
+
+func TestBackup(t *testing.T) {
+	dir := t.TempDir()
+	db, err := logdb.New(filepath.Join(dir, "logs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	b := new(block.Builder).Build()
+	w := db.NewWriter()
+	writeBlocks := func(n int) {
+		for i := 0; i < n; i++ {
+			b = new(block.Builder).
+				ParentID(b.Header().ID()).
+				Transaction(newTx()).
+				Build()
+			if err := w.Write(b, tx.Receipts{newReceipt()}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeBlocks(10)
+	pinnedID := b.Header().ID()
+	allEvents, err := db.FilterEvents(context.Background(), nil)
+	assert.Nil(t, err)
+	allTxs, err := db.FilterTxs(context.Background(), nil)
+	assert.Nil(t, err)
+
+	backup, err := db.NewBackup(context.Background())
+	assert.Nil(t, err)
+	defer backup.Release()
+
+	// logs written after pinned are not in the backup
+	writeBlocks(5)
+
+	path := filepath.Join(dir, "backup.db")
+	assert.Nil(t, backup.SaveTo(context.Background(), path))
+	assert.NotNil(t, backup.SaveTo(context.Background(), path), "should fail if the file exists")
+
+	restored, err := logdb.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restored.Close()
+
+	newestID, err := restored.NewestBlockID()
+	assert.Nil(t, err)
+	assert.Equal(t, pinnedID, newestID)
+
+	events, err := restored.FilterEvents(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, allEvents, events)
+	txs, err := restored.FilterTxs(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, allTxs, txs)
+}
@@ -0,0 +1,344 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+// Package logexport dumps events and transfers of the log db into CSV or Parquet files.
+//
+// Logs are exported in ascending order. Addresses, topics, ids and data are hex encoded,
+// and amounts are hex encoded as in the logs API.
+package logexport
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/parquet-go/parquet-go"
+	"github.com/pkg/errors"
+	"github.com/vechain/thor/v2/logdb"
+	"github.com/vechain/thor/v2/thor"
+)
+
+// Kind is the kind of logs to export.
+type Kind string
+
+const (
+	Events    Kind = "events"
+	Transfers Kind = "transfers"
+)
+
+// Format is the file format of exported logs.
+type Format string
+
+const (
+	CSV     Format = "csv"
+	Parquet Format = "parquet"
+)
+
+// parquetRowGroupSize is the number of rows buffered before a row group is flushed.
+const parquetRowGroupSize = 100_000
+
+// Options specifies logs to export.
+type Options struct {
+	Kind             Kind
+	Format           Format
+	Range            *logdb.Range
+	EventCriteria    []*logdb.EventCriteria    // used if kind is events
+	TransferCriteria []*logdb.TransferCriteria // used if kind is transfers
+}
+
+// Validate checks whether the kind and format are supported.
+func (o *Options) Validate() error {
+	switch o.Kind {
+	case Events, Transfers:
+	default:
+		return fmt.Errorf("unsupported kind %q", o.Kind)
+	}
+	switch o.Format {
+	case CSV, Parquet:
+	default:
+		return fmt.Errorf("unsupported format %q", o.Format)
+	}
+	return nil
+}
+
+// eventCriteria is the event criteria in the form of the events API.
+type eventCriteria struct {
+	Address *thor.Address `json:"address"`
+	Topic0  *thor.Bytes32 `json:"topic0"`
+	Topic1  *thor.Bytes32 `json:"topic1"`
+	Topic2  *thor.Bytes32 `json:"topic2"`
+	Topic3  *thor.Bytes32 `json:"topic3"`
+	Topic4  *thor.Bytes32 `json:"topic4"`
+}
+
+// UnmarshalCriteriaSet sets the criteria set of the kind from JSON, which is in the same form as the
+// criteria set of the events or transfers API.
+func (o *Options) UnmarshalCriteriaSet(data []byte) error {
+	switch o.Kind {
+	case Events:
+		var criteriaSet []*eventCriteria
+		if err := json.Unmarshal(data, &criteriaSet); err != nil {
+			return err
+		}
+		o.EventCriteria = nil
+		for _, c := range criteriaSet {
+			o.EventCriteria = append(o.EventCriteria, &logdb.EventCriteria{
+				Address: c.Address,
+				Topics:  [5]*thor.Bytes32{c.Topic0, c.Topic1, c.Topic2, c.Topic3, c.Topic4},
+			})
+		}
+		return nil
+	case Transfers:
+		o.TransferCriteria = nil
+		return json.Unmarshal(data, &o.TransferCriteria)
+	default:
+		return fmt.Errorf("unsupported kind %q", o.Kind)
+	}
+}
+
+type eventRow struct {
+	BlockNumber uint32 `parquet:"blockNumber"`
+	Index       uint32 `parquet:"index"`
+	BlockID     string `parquet:"blockID"`
+	BlockTime   uint64 `parquet:"blockTime"`
+	TxID        string `parquet:"txID"`
+	TxOrigin    string `parquet:"txOrigin"`
+	ClauseIndex uint32 `parquet:"clauseIndex"`
+	Address     string `parquet:"address"`
+	Topic0      string `parquet:"topic0,optional"`
+	Topic1      string `parquet:"topic1,optional"`
+	Topic2      string `parquet:"topic2,optional"`
+	Topic3      string `parquet:"topic3,optional"`
+	Topic4      string `parquet:"topic4,optional"`
+	Data        string `parquet:"data"`
+}
+
+var eventHeader = []string{
+	"blockNumber", "index", "blockID", "blockTime", "txID", "txOrigin", "clauseIndex",
+	"address", "topic0", "topic1", "topic2", "topic3", "topic4", "data",
+}
+
+func newEventRow(ev *logdb.Event) *eventRow {
+	var topics [5]string
+	for i, topic := range ev.Topics {
+		if topic != nil {
+			topics[i] = topic.String()
+		}
+	}
+	return &eventRow{
+		BlockNumber: ev.BlockNumber,
+		Index:       ev.Index,
+		BlockID:     ev.BlockID.String(),
+		BlockTime:   ev.BlockTime,
+		TxID:        ev.TxID.String(),
+		TxOrigin:    ev.TxOrigin.String(),
+		ClauseIndex: ev.ClauseIndex,
+		Address:     ev.Address.String(),
+		Topic0:      topics[0],
+		Topic1:      topics[1],
+		Topic2:      topics[2],
+		Topic3:      topics[3],
+		Topic4:      topics[4],
+		Data:        hexutil.Encode(ev.Data),
+	}
+}
+
+func (r *eventRow) record() []string {
+	return []string{
+		strconv.FormatUint(uint64(r.BlockNumber), 10),
+		strconv.FormatUint(uint64(r.Index), 10),
+		r.BlockID,
+		strconv.FormatUint(r.BlockTime, 10),
+		r.TxID,
+		r.TxOrigin,
+		strconv.FormatUint(uint64(r.ClauseIndex), 10),
+		r.Address,
+		r.Topic0,
+		r.Topic1,
+		r.Topic2,
+		r.Topic3,
+		r.Topic4,
+		r.Data,
+	}
+}
+
+type transferRow struct {
+	BlockNumber uint32 `parquet:"blockNumber"`
+	Index       uint32 `parquet:"index"`
+	BlockID     string `parquet:"blockID"`
+	BlockTime   uint64 `parquet:"blockTime"`
+	TxID        string `parquet:"txID"`
+	TxOrigin    string `parquet:"txOrigin"`
+	ClauseIndex uint32 `parquet:"clauseIndex"`
+	Sender      string `parquet:"sender"`
+	Recipient   string `parquet:"recipient"`
+	Amount      string `parquet:"amount"`
+}
+
+var transferHeader = []string{
+	"blockNumber", "index", "blockID", "blockTime", "txID", "txOrigin", "clauseIndex",
+	"sender", "recipient", "amount",
+}
+
+func newTransferRow(tr *logdb.Transfer) *transferRow {
+	return &transferRow{
+		BlockNumber: tr.BlockNumber,
+		Index:       tr.Index,
+		BlockID:     tr.BlockID.String(),
+		BlockTime:   tr.BlockTime,
+		TxID:        tr.TxID.String(),
+		TxOrigin:    tr.TxOrigin.String(),
+		ClauseIndex: tr.ClauseIndex,
+		Sender:      tr.Sender.String(),
+		Recipient:   tr.Recipient.String(),
+		Amount:      hexutil.EncodeBig(tr.Amount),
+	}
+}
+
+func (r *transferRow) record() []string {
+	return []string{
+		strconv.FormatUint(uint64(r.BlockNumber), 10),
+		strconv.FormatUint(uint64(r.Index), 10),
+		r.BlockID,
+		strconv.FormatUint(r.BlockTime, 10),
+		r.TxID,
+		r.TxOrigin,
+		strconv.FormatUint(uint64(r.ClauseIndex), 10),
+		r.Sender,
+		r.Recipient,
+		r.Amount,
+	}
+}
+
+// rowWriter writes rows in a file format.
+type rowWriter[T any] interface {
+	Write(row *T) error
+	Close() error
+}
+
+type csvWriter[T any] struct {
+	w      *csv.Writer
+	record func(*T) []string
+}
+
+func (c *csvWriter[T]) Write(row *T) error {
+	return c.w.Write(c.record(row))
+}
+
+func (c *csvWriter[T]) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type parquetWriter[T any] struct {
+	w        *parquet.GenericWriter[T]
+	buffered int
+}
+
+func (p *parquetWriter[T]) Write(row *T) error {
+	if _, err := p.w.Write([]T{*row}); err != nil {
+		return err
+	}
+	p.buffered++
+	// flush row groups to keep memory bounded
+	if p.buffered >= parquetRowGroupSize {
+		p.buffered = 0
+		return p.w.Flush()
+	}
+	return nil
+}
+
+func (p *parquetWriter[T]) Close() error {
+	return p.w.Close()
+}
+
+func newRowWriter[T any](w io.Writer, format Format, header []string, record func(*T) []string) (rowWriter[T], error) {
+	switch format {
+	case CSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(header); err != nil {
+			return nil, err
+		}
+		return &csvWriter[T]{cw, record}, nil
+	case Parquet:
+		return &parquetWriter[T]{w: parquet.NewGenericWriter[T](w, parquet.Compression(&parquet.Snappy))}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// Export writes logs specified by opts to w, and returns the number of exported logs.
+func Export(ctx context.Context, db *logdb.LogDB, opts *Options, w io.Writer) (uint64, error) {
+	if err := opts.Validate(); err != nil {
+		return 0, err
+	}
+
+	var count uint64
+	if opts.Kind == Events {
+		rw, err := newRowWriter(w, opts.Format, eventHeader, (*eventRow).record)
+		if err != nil {
+			return 0, err
+		}
+		filter := &logdb.EventFilter{
+			CriteriaSet: opts.EventCriteria,
+			Range:       opts.Range,
+			Order:       logdb.ASC,
+		}
+		if err := db.IterateEvents(ctx, filter, func(ev *logdb.Event) error {
+			count++
+			return rw.Write(newEventRow(ev))
+		}); err != nil {
+			return 0, err
+		}
+		return count, rw.Close()
+	}
+
+	rw, err := newRowWriter(w, opts.Format, transferHeader, (*transferRow).record)
+	if err != nil {
+		return 0, err
+	}
+	filter := &logdb.TransferFilter{
+		CriteriaSet: opts.TransferCriteria,
+		Range:       opts.Range,
+		Order:       logdb.ASC,
+	}
+	if err := db.IterateTransfers(ctx, filter, func(tr *logdb.Transfer) error {
+		count++
+		return rw.Write(newTransferRow(tr))
+	}); err != nil {
+		return 0, err
+	}
+	return count, rw.Close()
+}
+
+// ExportFile writes logs specified by opts to a new file at path, and returns the number of exported logs.
+// The file is removed if the export fails.
+func ExportFile(ctx context.Context, db *logdb.LogDB, opts *Options, path string) (count uint64, err error) {
+	if err := opts.Validate(); err != nil {
+		return 0, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			_ = os.Remove(path)
+		}
+	}()
+
+	if count, err = Export(ctx, db, opts, f); err != nil {
+		return 0, errors.Wrap(err, "export logs")
+	}
+	return count, nil
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package logexport
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/vechain/thor/v2/block"
+	"github.com/vechain/thor/v2/logdb"
+	"github.com/vechain/thor/v2/thor"
+	"github.com/vechain/thor/v2/tx"
+)
+
+func newTestDB(t *testing.T) *logdb.LogDB {
+	db, err := logdb.New(filepath.Join(t.TempDir(), "logs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	pk, _ := crypto.GenerateKey()
+	b := new(block.Builder).Build()
+	w := db.NewWriter()
+	for i := 0; i < 10; i++ {
+		trx := new(tx.Builder).Nonce(uint64(i)).Build()
+		sig, _ := crypto.Sign(trx.Hash().Bytes(), pk)
+		trx = trx.WithSignature(sig)
+
+		b = new(block.Builder).
+			ParentID(b.Header().ID()).
+			Transaction(trx).
+			Build()
+		receipt := &tx.Receipt{
+			Outputs: []*tx.Output{{
+				Events: tx.Events{{
+					Address: thor.BytesToAddress([]byte{byte(i % 2)}),
+					Topics:  []thor.Bytes32{thor.BytesToBytes32([]byte{byte(i)})},
+					Data:    []byte{byte(i)},
+				}},
+				Transfers: tx.Transfers{{
+					Sender:    thor.BytesToAddress([]byte("sender")),
+					Recipient: thor.BytesToAddress([]byte{byte(i % 2)}),
+					Amount:    big.NewInt(int64(i + 1)),
+				}},
+			}},
+		}
+		if err := w.Write(b, tx.Receipts{receipt}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestExportCSV(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	addr := thor.BytesToAddress([]byte{1})
+	opts := &Options{
+		Kind:          Events,
+		Format:        CSV,
+		Range:         &logdb.Range{From: 0, To: 8},
+		EventCriteria: []*logdb.EventCriteria{{Address: &addr}},
+	}
+	want, err := db.FilterEvents(ctx, &logdb.EventFilter{CriteriaSet: opts.EventCriteria, Range: opts.Range})
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	count, err := Export(ctx, db, opts, &buf)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(len(want)), count)
+	assert.NotZero(t, count)
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	assert.Nil(t, err)
+	assert.Equal(t, eventHeader, records[0])
+	assert.Equal(t, len(want)+1, len(records))
+	for i, ev := range want {
+		assert.Equal(t, newEventRow(ev).record(), records[i+1])
+		assert.Equal(t, addr.String(), records[i+1][7])
+		assert.Equal(t, ev.Topics[0].String(), records[i+1][8])
+		assert.Equal(t, "", records[i+1][9])
+	}
+
+	opts = &Options{Kind: Transfers, Format: CSV}
+	buf.Reset()
+	count, err = Export(ctx, db, opts, &buf)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(10), count)
+	records, err = csv.NewReader(&buf).ReadAll()
+	assert.Nil(t, err)
+	assert.Equal(t, transferHeader, records[0])
+	assert.Equal(t, "0x1", records[1][9])
+}
+
+func TestExportParquet(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "transfers.parquet")
+	recipient := thor.BytesToAddress([]byte{0})
+	opts := &Options{
+		Kind:             Transfers,
+		Format:           Parquet,
+		TransferCriteria: []*logdb.TransferCriteria{{Recipient: &recipient}},
+	}
+	count, err := ExportFile(ctx, db, opts, path)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(5), count)
+
+	want, err := db.FilterTransfers(ctx, &logdb.TransferFilter{CriteriaSet: opts.TransferCriteria})
+	assert.Nil(t, err)
+	rows, err := parquet.ReadFile[transferRow](path)
+	assert.Nil(t, err)
+	assert.Equal(t, len(want), len(rows))
+	for i, tr := range want {
+		assert.Equal(t, *newTransferRow(tr), rows[i])
+	}
+
+	_, err = ExportFile(ctx, db, opts, path)
+	assert.NotNil(t, err, "should fail if the file exists")
+
+	path = filepath.Join(dir, "events.parquet")
+	count, err = ExportFile(ctx, db, &Options{Kind: Events, Format: Parquet}, path)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(10), count)
+	events, err := parquet.ReadFile[eventRow](path)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, len(events))
+	assert.Equal(t, "", events[0].Topic1)
+}
+
+func TestExportInvalidOptions(t *testing.T) {
+	db := newTestDB(t)
+	path := filepath.Join(t.TempDir(), "logs")
+
+	_, err := ExportFile(context.Background(), db, &Options{Kind: "txs", Format: CSV}, path)
+	assert.EqualError(t, err, `unsupported kind "txs"`)
+	_, err = ExportFile(context.Background(), db, &Options{Kind: Events, Format: "json"}, path)
+	assert.EqualError(t, err, `unsupported format "json"`)
+
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestUnmarshalCriteriaSet(t *testing.T) {
+	addr := thor.BytesToAddress([]byte("addr"))
+	topic := thor.BytesToBytes32([]byte("topic"))
+
+	opts := &Options{Kind: Events}
+	assert.Nil(t, opts.UnmarshalCriteriaSet([]byte(`[{"address":"`+addr.String()+`","topic2":"`+topic.String()+`"}]`)))
+	assert.Equal(t, []*logdb.EventCriteria{{Address: &addr, Topics: [5]*thor.Bytes32{nil, nil, &topic}}}, opts.EventCriteria)
+
+	opts = &Options{Kind: Transfers}
+	assert.Nil(t, opts.UnmarshalCriteriaSet([]byte(`[{"txOrigin":"`+addr.String()+`"}]`)))
+	assert.Equal(t, []*logdb.TransferCriteria{{TxOrigin: &addr}}, opts.TransferCriteria)
+
+	assert.NotNil(t, opts.UnmarshalCriteriaSet([]byte(`{}`)))
+	assert.NotNil(t, (&Options{Kind: "txs"}).UnmarshalCriteriaSet([]byte(`[]`)))
+}
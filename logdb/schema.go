@@ -50,4 +50,72 @@ CREATE INDEX IF NOT EXISTS event_i4 ON event(topic3, topic0, address) WHERE topi
 CREATE INDEX IF NOT EXISTS transfer_i0 ON transfer(txOrigin);
 CREATE INDEX IF NOT EXISTS transfer_i1 ON transfer(sender);
 CREATE INDEX IF NOT EXISTS transfer_i2 ON transfer(recipient);`
+
+	// create coverage table, which records the first block indexed by tables added after logs were written.
+	coverageTableSchema = `CREATE TABLE IF NOT EXISTS coverage (
+	tbl TEXT PRIMARY KEY NOT NULL,
+	fromBlock INTEGER NOT NULL
+);`
+
+	// create token transfers table, which indexes decoded Transfer(address,address,uint256) events.
+	// seq is shared with the event table.
+	tokenTransferTableSchema = `CREATE TABLE IF NOT EXISTS token_transfer (
+	seq INTEGER PRIMARY KEY NOT NULL,
+	blockID	INTEGER NOT NULL,
+	blockTime INTEGER NOT NULL,
+	txID INTEGER NOT NULL,
+	txOrigin INTEGER NOT NULL,
+	clauseIndex INTEGER NOT NULL,
+	token INTEGER NOT NULL,
+	sender INTEGER NOT NULL,
+	recipient INTEGER NOT NULL,
+	amount BLOB(32)
+);
+
+CREATE INDEX IF NOT EXISTS token_transfer_i0 ON token_transfer(token);
+CREATE INDEX IF NOT EXISTS token_transfer_i1 ON token_transfer(sender);
+CREATE INDEX IF NOT EXISTS token_transfer_i2 ON token_transfer(recipient);`
+
+	// create transactions table, which indexes transactions by origin and delegator.
+	// seq is composed of block number and tx index.
+	txTableSchema = `CREATE TABLE IF NOT EXISTS tx (
+	seq INTEGER PRIMARY KEY NOT NULL,
+	blockID INTEGER NOT NULL,
+	blockTime INTEGER NOT NULL,
+	txID INTEGER NOT NULL,
+	origin INTEGER NOT NULL,
+	delegator INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS tx_i0 ON tx(origin);
+CREATE INDEX IF NOT EXISTS tx_i1 ON tx(delegator);
+
+CREATE TABLE IF NOT EXISTS tx_recipient (
+	recipient INTEGER NOT NULL,
+	seq INTEGER NOT NULL,
+	PRIMARY KEY (recipient, seq)
+) WITHOUT ROWID;
+
+CREATE INDEX IF NOT EXISTS tx_recipient_i0 ON tx_recipient(seq);`
+
+	// create internal calls table, which indexes calls made by contracts during clause execution.
+	// seq is composed of block number and the index of the call in the block.
+	internalCallTableSchema = `CREATE TABLE IF NOT EXISTS internal_call (
+	seq INTEGER PRIMARY KEY NOT NULL,
+	blockID INTEGER NOT NULL,
+	blockTime INTEGER NOT NULL,
+	txID INTEGER NOT NULL,
+	txOrigin INTEGER NOT NULL,
+	clauseIndex INTEGER NOT NULL,
+	depth INTEGER NOT NULL,
+	type TEXT NOT NULL,
+	caller INTEGER NOT NULL,
+	callee INTEGER,
+	value BLOB(32),
+	selector BLOB(4),
+	error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS internal_call_i0 ON internal_call(caller);
+CREATE INDEX IF NOT EXISTS internal_call_i1 ON internal_call(callee, selector);`
 )
@@ -6,9 +6,13 @@
 package logdb
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"math/big"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethmath "github.com/ethereum/go-ethereum/common/math"
 	"github.com/vechain/thor/v2/thor"
 )
 
@@ -40,6 +44,58 @@ type Transfer struct {
 	Amount      *big.Int
 }
 
+// TokenTransfer represents a decoded VIP-180 (ERC-20) Transfer event that can be stored in db.
+type TokenTransfer struct {
+	BlockNumber uint32
+	Index       uint32 // index of the Transfer event in the block
+	BlockID     thor.Bytes32
+	BlockTime   uint64
+	TxID        thor.Bytes32
+	TxOrigin    thor.Address
+	ClauseIndex uint32
+	Token       thor.Address // the token contract which emitted the event
+	Sender      thor.Address
+	Recipient   thor.Address
+	Amount      *big.Int
+}
+
+// Tx represents an indexed transaction that can be stored in db.
+type Tx struct {
+	BlockNumber uint32
+	Index       uint32 // index of the tx in the block
+	BlockID     thor.Bytes32
+	BlockTime   uint64
+	TxID        thor.Bytes32
+	Origin      thor.Address
+	Delegator   *thor.Address // nil if the tx is not delegated
+}
+
+// TxRecipient represents a clause recipient of an indexed transaction.
+type TxRecipient struct {
+	BlockNumber uint32
+	Index       uint32 // index of the tx in the block
+	Recipient   thor.Address
+}
+
+// InternalCall represents a call made by a contract during clause execution, which is collected by
+// replaying the block with the call tracer.
+type InternalCall struct {
+	BlockNumber uint32
+	Index       uint32 // index of the call in the block
+	BlockID     thor.Bytes32
+	BlockTime   uint64
+	TxID        thor.Bytes32
+	TxOrigin    thor.Address
+	ClauseIndex uint32
+	Depth       uint32        // 1 for calls made by the contract called by the clause
+	Type        string        // the opcode making the call, e.g. CALL, DELEGATECALL or CREATE
+	From        thor.Address  // the caller
+	To          *thor.Address // the callee, nil if a contract creation failed
+	Value       *big.Int      // nil if the call carries no value field
+	Selector    []byte        // the first 4 bytes of the input, nil if the input is shorter
+	Error       string        // empty if the call succeeded
+}
+
 type Order string
 
 const (
@@ -52,9 +108,79 @@ type Range struct {
 	To   uint32
 }
 
+func (r *Range) toWhereCondition() (cond string, args []interface{}) {
+	cond = "1"
+	if r != nil {
+		cond += " AND seq >= ?"
+		args = append(args, newSequence(r.From, 0))
+		if r.To >= r.From {
+			cond += " AND seq <= ?"
+			args = append(args, newSequence(r.To, uint32(math.MaxInt32)))
+		}
+	}
+	return
+}
+
 type Options struct {
 	Offset uint64
 	Limit  uint64
+	Cursor *Cursor `json:",omitempty"` // if set, offset is ignored and logs are returned after the cursor
+}
+
+// offset returns the offset to be applied, which is always zero in cursor mode.
+func (o *Options) offset() uint64 {
+	if o.Cursor != nil {
+		return 0
+	}
+	return o.Offset
+}
+
+// Cursor marks a position in the log db for cursor-based pagination.
+// A cursor decoded from an empty string points to the start of the result set.
+type Cursor struct {
+	seq   sequence
+	isSet bool
+}
+
+// NewCursor creates a cursor pointing to the log at the given position.
+func NewCursor(blockNum uint32, index uint32) *Cursor {
+	return &Cursor{seq: newSequence(blockNum, index), isSet: true}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (c Cursor) MarshalText() ([]byte, error) {
+	if !c.isSet {
+		return []byte{}, nil
+	}
+	return []byte(hexutil.EncodeUint64(uint64(c.seq))), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (c *Cursor) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*c = Cursor{}
+		return nil
+	}
+	v, err := hexutil.DecodeUint64(string(text))
+	if err != nil {
+		return err
+	}
+	if v > math.MaxInt64 {
+		return errors.New("cursor out of range")
+	}
+	*c = Cursor{seq: sequence(v), isSet: true}
+	return nil
+}
+
+// condition returns the where condition that skips logs up to the cursor.
+func (c *Cursor) condition(order Order) (cond string, args []interface{}) {
+	if c == nil || !c.isSet {
+		return "", nil
+	}
+	if order == DESC {
+		return " AND seq < ?", []interface{}{c.seq}
+	}
+	return " AND seq > ?", []interface{}{c.seq}
 }
 
 type EventCriteria struct {
@@ -85,10 +211,48 @@ type EventFilter struct {
 	Order       Order //default asc
 }
 
+func (f *EventFilter) toWhereCondition() (cond string, args []interface{}) {
+	cond, args = f.Range.toWhereCondition()
+	if len(f.CriteriaSet) > 0 {
+		cond += " AND ("
+		for i, c := range f.CriteriaSet {
+			ccond, cargs := c.toWhereCondition()
+			if i > 0 {
+				cond += " OR"
+			}
+			cond += " (" + ccond + ")"
+			args = append(args, cargs...)
+		}
+		cond += ")"
+	}
+	return
+}
+
 type TransferCriteria struct {
-	TxOrigin  *thor.Address //who send transaction
-	Sender    *thor.Address //who transferred tokens
-	Recipient *thor.Address //who received tokens
+	TxOrigin    *thor.Address            //who send transaction
+	Sender      *thor.Address            //who transferred tokens
+	Recipient   *thor.Address            //who received tokens
+	MinAmount   *ethmath.HexOrDecimal256 `json:",omitempty"` //inclusive lower bound of amount
+	MaxAmount   *ethmath.HexOrDecimal256 `json:",omitempty"` //inclusive upper bound of amount
+	ClauseIndex *uint32                  `json:",omitempty"` //index of the clause in which the transfer happened
+}
+
+// Validate checks the amount bounds, which must not be negative, and the lower bound must not exceed the upper bound.
+func (c *TransferCriteria) Validate() error {
+	return validateAmountRange(c.MinAmount, c.MaxAmount)
+}
+
+func validateAmountRange(lower, upper *ethmath.HexOrDecimal256) error {
+	if lower != nil && (*big.Int)(lower).Sign() < 0 {
+		return errors.New("minAmount: must not be negative")
+	}
+	if upper != nil && (*big.Int)(upper).Sign() < 0 {
+		return errors.New("maxAmount: must not be negative")
+	}
+	if lower != nil && upper != nil && (*big.Int)(lower).Cmp((*big.Int)(upper)) > 0 {
+		return errors.New("minAmount: must not exceed maxAmount")
+	}
+	return nil
 }
 
 func (c *TransferCriteria) toWhereCondition() (cond string, args []interface{}) {
@@ -105,12 +269,204 @@ func (c *TransferCriteria) toWhereCondition() (cond string, args []interface{})
 		cond += " AND recipient = " + refIDQuery
 		args = append(args, c.Recipient.Bytes())
 	}
+	if c.MinAmount != nil {
+		if v := (*big.Int)(c.MinAmount); v.Sign() > 0 {
+			cond += " AND " + amountCondition(">")
+			b := v.Bytes()
+			args = append(args, len(b), len(b), b)
+		}
+	}
+	if c.MaxAmount != nil {
+		cond += " AND " + amountCondition("<")
+		b := (*big.Int)(c.MaxAmount).Bytes()
+		args = append(args, len(b), len(b), b)
+	}
+	if c.ClauseIndex != nil {
+		cond += " AND clauseIndex = ?"
+		args = append(args, *c.ClauseIndex)
+	}
 	return
 }
 
+// amountCondition returns the condition to compare the amount column with a value, which takes the byte
+// length of the value, twice, and the value as args. Amounts are stored as big-endian bytes without leading
+// zeros, so a longer amount is always larger, and amounts of the same length compare as blobs.
+func amountCondition(op string) string {
+	return "(IFNULL(LENGTH(amount), 0) " + op + " ? OR (IFNULL(LENGTH(amount), 0) = ? AND IFNULL(amount, x'') " + op + "= ?))"
+}
+
 type TransferFilter struct {
 	CriteriaSet []*TransferCriteria
 	Range       *Range
 	Options     *Options
 	Order       Order //default asc
 }
+
+func (f *TransferFilter) toWhereCondition() (cond string, args []interface{}) {
+	cond, args = f.Range.toWhereCondition()
+	if len(f.CriteriaSet) > 0 {
+		cond += " AND ("
+		for i, c := range f.CriteriaSet {
+			ccond, cargs := c.toWhereCondition()
+			if i > 0 {
+				cond += " OR"
+			}
+			cond += " (" + ccond + ")"
+			args = append(args, cargs...)
+		}
+		cond += ")"
+	}
+	return
+}
+
+type TokenTransferCriteria struct {
+	Token     *thor.Address            //the token contract
+	TxOrigin  *thor.Address            //who send transaction
+	Sender    *thor.Address            //who transferred tokens
+	Recipient *thor.Address            //who received tokens
+	MinAmount *ethmath.HexOrDecimal256 `json:",omitempty"` //inclusive lower bound of amount
+	MaxAmount *ethmath.HexOrDecimal256 `json:",omitempty"` //inclusive upper bound of amount
+}
+
+// Validate checks the amount bounds, which must not be negative, and the lower bound must not exceed the upper bound.
+func (c *TokenTransferCriteria) Validate() error {
+	return validateAmountRange(c.MinAmount, c.MaxAmount)
+}
+
+func (c *TokenTransferCriteria) toWhereCondition() (cond string, args []interface{}) {
+	cond, args = (&TransferCriteria{
+		TxOrigin:  c.TxOrigin,
+		Sender:    c.Sender,
+		Recipient: c.Recipient,
+		MinAmount: c.MinAmount,
+		MaxAmount: c.MaxAmount,
+	}).toWhereCondition()
+	if c.Token != nil {
+		cond += " AND token = " + refIDQuery
+		args = append(args, c.Token.Bytes())
+	}
+	return
+}
+
+type TokenTransferFilter struct {
+	CriteriaSet []*TokenTransferCriteria
+	Range       *Range
+	Options     *Options
+	Order       Order //default asc
+}
+
+func (f *TokenTransferFilter) toWhereCondition() (cond string, args []interface{}) {
+	cond, args = f.Range.toWhereCondition()
+	if len(f.CriteriaSet) > 0 {
+		cond += " AND ("
+		for i, c := range f.CriteriaSet {
+			ccond, cargs := c.toWhereCondition()
+			if i > 0 {
+				cond += " OR"
+			}
+			cond += " (" + ccond + ")"
+			args = append(args, cargs...)
+		}
+		cond += ")"
+	}
+	return
+}
+
+type TxCriteria struct {
+	Origin    *thor.Address //who send transaction
+	Delegator *thor.Address //who paid the gas
+	Recipient *thor.Address //to address of any clause
+}
+
+func (c *TxCriteria) toWhereCondition() (cond string, args []interface{}) {
+	cond = "1"
+	if c.Origin != nil {
+		cond += " AND origin = " + refIDQuery
+		args = append(args, c.Origin.Bytes())
+	}
+	if c.Delegator != nil {
+		cond += " AND delegator = " + refIDQuery
+		args = append(args, c.Delegator.Bytes())
+	}
+	if c.Recipient != nil {
+		cond += " AND seq IN (SELECT seq FROM tx_recipient WHERE recipient = " + refIDQuery + ")"
+		args = append(args, c.Recipient.Bytes())
+	}
+	return
+}
+
+type TxFilter struct {
+	CriteriaSet []*TxCriteria
+	Range       *Range
+	Options     *Options
+	Order       Order //default asc
+}
+
+func (f *TxFilter) toWhereCondition() (cond string, args []interface{}) {
+	cond, args = f.Range.toWhereCondition()
+	if len(f.CriteriaSet) > 0 {
+		cond += " AND ("
+		for i, c := range f.CriteriaSet {
+			ccond, cargs := c.toWhereCondition()
+			if i > 0 {
+				cond += " OR"
+			}
+			cond += " (" + ccond + ")"
+			args = append(args, cargs...)
+		}
+		cond += ")"
+	}
+	return
+}
+
+type InternalCallCriteria struct {
+	TxOrigin *thor.Address //who send transaction
+	From     *thor.Address //the caller
+	To       *thor.Address //the callee
+	Selector hexutil.Bytes `json:",omitempty"` //the first 4 bytes of the call input
+}
+
+func (c *InternalCallCriteria) toWhereCondition() (cond string, args []interface{}) {
+	cond = "1"
+	if c.TxOrigin != nil {
+		cond += " AND txOrigin = " + refIDQuery
+		args = append(args, c.TxOrigin.Bytes())
+	}
+	if c.From != nil {
+		cond += " AND caller = " + refIDQuery
+		args = append(args, c.From.Bytes())
+	}
+	if c.To != nil {
+		cond += " AND callee = " + refIDQuery
+		args = append(args, c.To.Bytes())
+	}
+	if c.Selector != nil {
+		cond += " AND selector = ?"
+		args = append(args, []byte(c.Selector))
+	}
+	return
+}
+
+type InternalCallFilter struct {
+	CriteriaSet []*InternalCallCriteria
+	Range       *Range
+	Options     *Options
+	Order       Order //default asc
+}
+
+func (f *InternalCallFilter) toWhereCondition() (cond string, args []interface{}) {
+	cond, args = f.Range.toWhereCondition()
+	if len(f.CriteriaSet) > 0 {
+		cond += " AND ("
+		for i, c := range f.CriteriaSet {
+			ccond, cargs := c.toWhereCondition()
+			if i > 0 {
+				cond += " OR"
+			}
+			cond += " (" + ccond + ")"
+			args = append(args, cargs...)
+		}
+		cond += ")"
+	}
+	return
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package muxdb
+
+import (
+	"context"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/filter"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+)
+
+// Backup is a point-in-time view of the DB, which can be saved as a new DB while the DB is being written.
+type Backup struct {
+	snapshot *leveldb.Snapshot
+}
+
+// NewBackup pins the current state of the DB. The returned backup should be released after use.
+func (db *MuxDB) NewBackup() (*Backup, error) {
+	snapshot, err := db.ldb.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &Backup{snapshot}, nil
+}
+
+// SaveTo copies all entries of the backup into a new DB at the given path.
+// It fails if a DB already exists at the path.
+func (b *Backup) SaveTo(ctx context.Context, path string) error {
+	const idealBatchSize = 128 * 1024
+
+	ldb, err := leveldb.OpenFile(path, &opt.Options{
+		ErrorIfExist:        true,
+		Filter:              filter.NewBloomFilter(10),
+		BlockSize:           1024 * 32,
+		CompactionTableSize: 4 * opt.MiB,
+	})
+	if err != nil {
+		return err
+	}
+	defer ldb.Close()
+
+	iter := b.snapshot.NewIterator(nil, &opt.ReadOptions{DontFillCache: true})
+	defer iter.Release()
+
+	var (
+		batch leveldb.Batch
+		cnt   int
+	)
+	for iter.Next() {
+		cnt++
+		// check context every 1000 times.
+		if cnt%1000 == 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+		}
+		batch.Put(iter.Key(), iter.Value())
+		if len(batch.Dump()) >= idealBatchSize {
+			if err := ldb.Write(&batch, nil); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	if err := ldb.Write(&batch, &opt.WriteOptions{Sync: true}); err != nil {
+		return err
+	}
+	return ldb.Close()
+}
+
+// Release releases the pinned state.
+func (b *Backup) Release() {
+	b.snapshot.Release()
+}
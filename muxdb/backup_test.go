@@ -0,0 +1,49 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package muxdb
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackup(t *testing.T) {
+	db := NewMem()
+	defer db.Close()
+
+	store := db.NewStore("test")
+	assert.Nil(t, store.Put([]byte("k1"), []byte("v1")))
+	assert.Nil(t, store.Put([]byte("k2"), []byte("v2")))
+
+	backup, err := db.NewBackup()
+	assert.Nil(t, err)
+	defer backup.Release()
+
+	// changes after pinned are not in the backup
+	assert.Nil(t, store.Put([]byte("k1"), []byte("v1x")))
+	assert.Nil(t, store.Put([]byte("k3"), []byte("v3")))
+
+	path := filepath.Join(t.TempDir(), "main.db")
+	assert.Nil(t, backup.SaveTo(context.Background(), path))
+	assert.NotNil(t, backup.SaveTo(context.Background(), path), "should fail if the db exists")
+
+	restored, err := Open(path, &Options{})
+	assert.Nil(t, err)
+	defer restored.Close()
+
+	rstore := restored.NewStore("test")
+	v, err := rstore.Get([]byte("k1"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("v1"), v)
+	v, err = rstore.Get([]byte("k2"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("v2"), v)
+	_, err = rstore.Get([]byte("k3"))
+	assert.True(t, rstore.IsNotFound(err))
+}
@@ -64,6 +64,7 @@ type Options struct {
 
 // MuxDB is the database to efficiently store state trie and block-chain data.
 type MuxDB struct {
+	ldb         *leveldb.DB
 	engine      engine.Engine
 	trieBackend *trie.Backend
 }
@@ -119,6 +120,7 @@ func Open(path string, options *Options) (*MuxDB, error) {
 		options.TrieLeafBankSlotCapacity)
 
 	return &MuxDB{
+		ldb:    ldb,
 		engine: engine,
 		trieBackend: &trie.Backend{
 			Store:            engine,
@@ -140,6 +142,7 @@ func NewMem() *MuxDB {
 
 	engine := engine.NewLevelEngine(ldb)
 	return &MuxDB{
+		ldb:    ldb,
 		engine: engine,
 		trieBackend: &trie.Backend{
 			Store:            engine,
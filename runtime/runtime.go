@@ -227,6 +227,10 @@ func (rt *Runtime) newEVM(stateDB *statedb.StateDB, clauseIndex uint32, txCtx *x
 				panic("serious bug: native call returned gas over consumed")
 			}
 
+			if logger, ok := rt.vmConfig.Tracer.(vm.NativeCallLogger); ok {
+				logger.CaptureNativeCall(contract.Address(), abi.Name())
+			}
+
 			ret, err := xenv.New(abi, rt.chain, rt.state, rt.ctx, txCtx, evm, contract).Call(run)
 			return ret, err, true
 		},
@@ -358,6 +358,45 @@ func (s *State) RevertTo(revision int) {
 	s.sm.PopTo(revision)
 }
 
+// StorageBarrier returns the barrier of the account storage, which is increased each time the storage is cleared by
+// deleting the account. Storage is cleared between two states if their barriers differ.
+func (s *State) StorageBarrier(addr thor.Address) int {
+	return s.getStorageBarrier(addr)
+}
+
+// Touched returns addresses of accounts changed in the life-cycle of this state instance, along with keys of their
+// changed storage. Storage cleared by deleting an account is not enumerated, see StorageBarrier.
+func (s *State) Touched() map[thor.Address][]thor.Bytes32 {
+	var (
+		touched = make(map[thor.Address][]thor.Bytes32)
+		seen    = make(map[storageKey]bool)
+	)
+	touch := func(addr thor.Address) {
+		if _, ok := touched[addr]; !ok {
+			touched[addr] = nil
+		}
+	}
+
+	s.sm.Journal(func(k, _ interface{}) bool {
+		switch key := k.(type) {
+		case thor.Address:
+			touch(key)
+		case codeKey:
+			touch(thor.Address(key))
+		case storageBarrierKey:
+			touch(thor.Address(key))
+		case storageKey:
+			// keys under different barriers are the same slot
+			if sk := (storageKey{addr: key.addr, key: key.key}); !seen[sk] {
+				seen[sk] = true
+				touched[key.addr] = append(touched[key.addr], key.key)
+			}
+		}
+		return true
+	})
+	return touched
+}
+
 // BuildStorageTrie build up storage trie for given address with cumulative changes.
 func (s *State) BuildStorageTrie(addr thor.Address) (trie *muxdb.Trie, err error) {
 	acc, err := s.getAccount(addr)
@@ -226,3 +226,38 @@ func TestStorageBarrier(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, 0, len(acc.StorageRoot), "should skip storage writes when account deleteed then recreated")
 }
+
+func TestTouched(t *testing.T) {
+	db := muxdb.NewMem()
+	st := New(db, thor.Bytes32{}, 0, 0, 0)
+
+	addr1 := thor.BytesToAddress([]byte("addr1"))
+	addr2 := thor.BytesToAddress([]byte("addr2"))
+	addr3 := thor.BytesToAddress([]byte("addr3"))
+	key1 := thor.BytesToBytes32([]byte("key1"))
+	key2 := thor.BytesToBytes32([]byte("key2"))
+
+	assert.Equal(t, map[thor.Address][]thor.Bytes32{}, st.Touched())
+
+	st.SetBalance(addr1, big.NewInt(1))
+	st.SetStorage(addr2, key1, thor.BytesToBytes32([]byte("v1")))
+	st.SetStorage(addr2, key2, thor.BytesToBytes32([]byte("v2")))
+	st.SetStorage(addr2, key1, thor.BytesToBytes32([]byte("v3")))
+
+	// reverted changes are not touched
+	chk := st.NewCheckpoint()
+	st.SetCode(addr3, []byte("code"))
+	st.RevertTo(chk)
+
+	assert.Equal(t, map[thor.Address][]thor.Bytes32{
+		addr1: nil,
+		addr2: {key1, key2},
+	}, st.Touched())
+
+	// the slot set again after deleting the account
+	assert.Equal(t, 0, st.StorageBarrier(addr2))
+	st.Delete(addr2)
+	st.SetStorage(addr2, key2, thor.BytesToBytes32([]byte("v4")))
+	assert.Equal(t, []thor.Bytes32{key1, key2}, st.Touched()[addr2])
+	assert.Equal(t, 1, st.StorageBarrier(addr2))
+}
@@ -21,6 +21,9 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/dop251/goja"
 
@@ -42,18 +45,53 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
-	type ctorFn = func(json.RawMessage) (tracers.Tracer, error)
-	lookup := func(code string) ctorFn {
-		return func(cfg json.RawMessage) (tracers.Tracer, error) {
-			return newJsTracer(code, cfg)
-		}
-	}
 	for name, code := range assetTracers {
-		tracers.DefaultDirectory.Register(name, lookup(code), true)
+		program, err := compileTracer(name, code)
+		if err != nil {
+			panic(err)
+		}
+		tracers.DefaultDirectory.Register(name, lookup(program), true)
 	}
 	tracers.DefaultDirectory.RegisterJSEval(newJsTracer)
 }
 
+// lookup returns the constructor of the tracer of the compiled code.
+func lookup(program *goja.Program) func(json.RawMessage) (tracers.Tracer, error) {
+	return func(cfg json.RawMessage) (tracers.Tracer, error) {
+		return newJsTracerFromProgram(program, cfg)
+	}
+}
+
+// RegisterDir compiles the JS tracers in the dir, and registers them in the default
+// directory. A tracer is named after its file, e.g. my_call_tracer.js is registered as
+// myCallTracer, and it must not shadow any existing tracer. It returns the names of the
+// registered tracers.
+func RegisterDir(dir string) ([]string, error) {
+	codes, err := jsassets.LoadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(codes))
+	programs := make(map[string]*goja.Program, len(codes))
+	for name, code := range codes {
+		if tracers.DefaultDirectory.Has(name) {
+			return nil, fmt.Errorf("tracer %s: already exists", name)
+		}
+		program, err := compileTracer(name, code)
+		if err != nil {
+			return nil, fmt.Errorf("tracer %s: %w", name, err)
+		}
+		names = append(names, name)
+		programs[name] = program
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		tracers.DefaultDirectory.Register(name, lookup(programs[name]), true)
+	}
+	return names, nil
+}
+
 // bigIntProgram is compiled once and the exported function mostly invoked to convert
 // hex strings into big ints.
 var bigIntProgram = goja.MustCompile("bigInt", bigIntegerJS, false)
@@ -108,6 +146,10 @@ type jsTracer struct {
 	gasLimit          uint64                // Amount of gas bought for the whole tx
 	err               error                 // Any error that should stop tracing
 	obj               *goja.Object          // Trace object
+	limits            tracers.Limits        // Execution limits of the tracer code
+	calls             uint64                // Number of calls into the tracer code
+	budget            *memoryBudget         // Bytes handed to the tracer code and returned by it
+	deadline          *time.Timer           // Interrupts the tracer code on timeout
 
 	// Methods exposed by tracer
 	result goja.Callable
@@ -136,17 +178,33 @@ type jsTracer struct {
 // The methods `step`, `enter`, and `exit` are optional, but note that
 // `enter` and `exit` always go together.
 func newJsTracer(code string, cfg json.RawMessage) (tracers.Tracer, error) {
+	program, err := compileTracer("", code)
+	if err != nil {
+		return nil, err
+	}
+	return newJsTracerFromProgram(program, cfg)
+}
+
+// compileTracer compiles the tracer code, which is evaluated as an expression.
+func compileTracer(name, code string) (*goja.Program, error) {
+	return goja.Compile(name, "("+code+")", false)
+}
+
+// newJsTracerFromProgram instantiates a new JS tracer instance from the
+// compiled tracer code. See newJsTracer.
+func newJsTracerFromProgram(program *goja.Program, cfg json.RawMessage) (tracers.Tracer, error) {
 	vm := goja.New()
 	// By default field names are exported to JS as is, i.e. capitalized.
 	vm.SetFieldNameMapper(goja.UncapFieldNameMapper())
 	t := &jsTracer{
-		vm:  vm,
-		ctx: make(map[string]goja.Value),
+		vm:     vm,
+		ctx:    make(map[string]goja.Value),
+		budget: &memoryBudget{},
 	}
 
 	t.setTypeConverters()
 	t.setBuiltinFunctions()
-	ret, err := vm.RunString("(" + code + ")")
+	ret, err := vm.RunProgram(program)
 	if err != nil {
 		return nil, err
 	}
@@ -189,8 +247,8 @@ func newJsTracer(code string, cfg json.RawMessage) (tracers.Tracer, error) {
 	t.log = &steplog{
 		vm:       vm,
 		op:       &opObj{vm: vm},
-		memory:   &memoryObj{vm: vm, toBig: t.toBig, toBuf: t.toBuf},
-		stack:    &stackObj{vm: vm, toBig: t.toBig},
+		memory:   &memoryObj{vm: vm, toBig: t.toBig, toBuf: t.toBuf, budget: t.budget},
+		stack:    &stackObj{vm: vm, toBig: t.toBig, budget: t.budget},
 		contract: &contractObj{vm: vm, toBig: t.toBig, toBuf: t.toBuf},
 	}
 	t.frame = &callframe{vm: vm, toBig: t.toBig, toBuf: t.toBuf}
@@ -205,6 +263,12 @@ func newJsTracer(code string, cfg json.RawMessage) (tracers.Tracer, error) {
 // clause processing.
 func (t *jsTracer) CaptureClauseStart(gasLimit uint64) {
 	t.gasLimit = gasLimit
+	if t.limits.Timeout > 0 && t.deadline == nil {
+		err := &tracers.LimitError{Limit: "timeout", Max: t.limits.Timeout.String()}
+		t.deadline = time.AfterFunc(t.limits.Timeout, func() {
+			t.vm.Interrupt(err)
+		})
+	}
 }
 
 // CaptureClauseEnd implements the Tracer interface and is invoked at the end of
@@ -216,7 +280,7 @@ func (t *jsTracer) CaptureClauseEnd(restGas uint64) {
 // CaptureStart implements the Tracer interface to initialize the tracing operation.
 func (t *jsTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
 	t.env = env
-	db := &dbObj{db: env.StateDB, vm: t.vm, toBig: t.toBig, toBuf: t.toBuf, fromBuf: t.fromBuf}
+	db := &dbObj{db: env.StateDB, vm: t.vm, toBig: t.toBig, toBuf: t.toBuf, fromBuf: t.fromBuf, budget: t.budget}
 	t.dbValue = db.setupObject()
 	if create {
 		t.ctx["type"] = t.vm.ToValue("CREATE")
@@ -260,6 +324,10 @@ func (t *jsTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, memor
 	log.refund = t.env.StateDB.GetRefund()
 	log.depth = depth
 	log.err = err
+	if err := t.checkLimits(); err != nil {
+		t.onError("step", err)
+		return
+	}
 	if _, err := t.step(t.obj, t.logValue, t.dbValue); err != nil {
 		t.onError("step", err)
 	}
@@ -272,6 +340,10 @@ func (t *jsTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, memor
 	}
 	// Other log fields have been already set as part of the last CaptureState.
 	t.log.err = err
+	if err := t.checkLimits(); err != nil {
+		t.onError("fault", err)
+		return
+	}
 	if _, err := t.fault(t.obj, t.logValue, t.dbValue); err != nil {
 		t.onError("fault", err)
 	}
@@ -304,6 +376,10 @@ func (t *jsTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Ad
 		t.frame.value = new(big.Int).SetBytes(value.Bytes())
 	}
 
+	if err := t.checkLimits(); err != nil {
+		t.onError("enter", err)
+		return
+	}
 	if _, err := t.enter(t.obj, t.frameValue); err != nil {
 		t.onError("enter", err)
 	}
@@ -320,6 +396,10 @@ func (t *jsTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
 	t.frameResult.output = common.CopyBytes(output)
 	t.frameResult.err = err
 
+	if err := t.checkLimits(); err != nil {
+		t.onError("exit", err)
+		return
+	}
 	if _, err := t.exit(t.obj, t.frameResultValue); err != nil {
 		t.onError("exit", err)
 	}
@@ -346,6 +426,9 @@ func (t *jsTracer) SetContext(ctx *tracers.Context) {
 func (t *jsTracer) GetResult() (json.RawMessage, error) {
 	ctx := t.vm.ToValue(t.ctx)
 	res, err := t.result(t.obj, ctx, t.dbValue)
+	if t.deadline != nil {
+		t.deadline.Stop()
+	}
 	if err != nil {
 		return nil, wrapError("result", err)
 	}
@@ -353,6 +436,9 @@ func (t *jsTracer) GetResult() (json.RawMessage, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := t.budget.charge(len(encoded)); err != nil {
+		return nil, wrapError("result", err)
+	}
 	return json.RawMessage(encoded), t.err
 }
 
@@ -361,6 +447,42 @@ func (t *jsTracer) Stop(err error) {
 	t.vm.Interrupt(err)
 }
 
+// SetLimits implements the tracers.LimitedTracer interface to limit the execution of
+// the tracer code. The step budget counts the calls into the tracer code. The memory budget
+// counts the bytes read by the memory, stack and db accessors, and the size of the result,
+// while allocations made by the tracer code alone are bounded by the steps and the timeout.
+// The timeout starts with the clause and also covers the result function.
+func (t *jsTracer) SetLimits(limits tracers.Limits) {
+	t.limits = limits
+	t.budget.max = limits.Memory
+}
+
+// checkLimits counts a call into the tracer code, and returns the error if it exceeds the step
+// budget.
+func (t *jsTracer) checkLimits() error {
+	t.calls++
+	if t.limits.Steps > 0 && t.calls > t.limits.Steps {
+		return &tracers.LimitError{Limit: "steps", Max: strconv.FormatUint(t.limits.Steps, 10)}
+	}
+	return nil
+}
+
+// memoryBudget counts the bytes handed to the tracer code and returned by it, up to max (0 for
+// unlimited).
+type memoryBudget struct {
+	used uint64
+	max  uint64
+}
+
+// charge adds n bytes to the budget, and returns the error if it exceeds the max.
+func (b *memoryBudget) charge(n int) error {
+	b.used += uint64(n)
+	if b.max > 0 && b.used > b.max {
+		return &tracers.LimitError{Limit: "memory", Max: strconv.FormatUint(b.max, 10)}
+	}
+	return nil
+}
+
 // onError is called anytime the running JS code is interrupted
 // and returns an error. It in turn pings the EVM to cancel its
 // execution.
@@ -372,7 +494,7 @@ func (t *jsTracer) onError(context string, err error) {
 }
 
 func wrapError(context string, err error) error {
-	return fmt.Errorf("%v    in server-side tracer function '%v'", err, context)
+	return fmt.Errorf("%w    in server-side tracer function '%v'", err, context)
 }
 
 // setBuiltinFunctions injects Go functions which are available to tracers into the environment.
@@ -535,10 +657,14 @@ type memoryObj struct {
 	vm     *goja.Runtime
 	toBig  toBigFn
 	toBuf  toBufFn
+	budget *memoryBudget
 }
 
 func (mo *memoryObj) Slice(begin, end int64) goja.Value {
 	b, err := mo.slice(begin, end)
+	if err == nil {
+		err = mo.budget.charge(len(b))
+	}
 	if err != nil {
 		mo.vm.Interrupt(err)
 		return nil
@@ -568,6 +694,9 @@ func (mo *memoryObj) slice(begin, end int64) ([]byte, error) {
 
 func (mo *memoryObj) GetUint(addr int64) goja.Value {
 	value, err := mo.getUint(addr)
+	if err == nil {
+		err = mo.budget.charge(32)
+	}
 	if err != nil {
 		mo.vm.Interrupt(err)
 		return nil
@@ -601,13 +730,17 @@ func (m *memoryObj) setupObject() *goja.Object {
 }
 
 type stackObj struct {
-	stack *vm.Stack
-	vm    *goja.Runtime
-	toBig toBigFn
+	stack  *vm.Stack
+	vm     *goja.Runtime
+	toBig  toBigFn
+	budget *memoryBudget
 }
 
 func (s *stackObj) Peek(idx int) goja.Value {
 	value, err := s.peek(idx)
+	if err == nil {
+		err = s.budget.charge(32)
+	}
 	if err != nil {
 		s.vm.Interrupt(err)
 		return nil
@@ -645,6 +778,7 @@ type dbObj struct {
 	toBig   toBigFn
 	toBuf   toBufFn
 	fromBuf fromBufFn
+	budget  *memoryBudget
 }
 
 func (do *dbObj) GetBalance(addrSlice goja.Value) goja.Value {
@@ -655,6 +789,10 @@ func (do *dbObj) GetBalance(addrSlice goja.Value) goja.Value {
 	}
 	addr := common.BytesToAddress(a)
 	value := do.db.GetBalance(addr)
+	if err := do.budget.charge(32); err != nil {
+		do.vm.Interrupt(err)
+		return nil
+	}
 	res, err := do.toBig(do.vm, value.String())
 	if err != nil {
 		do.vm.Interrupt(err)
@@ -681,6 +819,10 @@ func (do *dbObj) GetCode(addrSlice goja.Value) goja.Value {
 	}
 	addr := common.BytesToAddress(a)
 	code := do.db.GetCode(addr)
+	if err := do.budget.charge(len(code)); err != nil {
+		do.vm.Interrupt(err)
+		return nil
+	}
 	res, err := do.toBuf(do.vm, code)
 	if err != nil {
 		do.vm.Interrupt(err)
@@ -703,6 +845,10 @@ func (do *dbObj) GetState(addrSlice goja.Value, hashSlice goja.Value) goja.Value
 	}
 	hash := common.BytesToHash(h)
 	state := do.db.GetState(addr, hash).Bytes()
+	if err := do.budget.charge(len(state)); err != nil {
+		do.vm.Interrupt(err)
+		return nil
+	}
 	res, err := do.toBuf(do.vm, state)
 	if err != nil {
 		do.vm.Interrupt(err)
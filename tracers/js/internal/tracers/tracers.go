@@ -19,7 +19,10 @@ package tracers
 
 import (
 	"embed"
+	"fmt"
 	"io/fs"
+	"os"
+	"path/filepath"
 	"strings"
 	"unicode"
 )
@@ -30,15 +33,49 @@ var files embed.FS
 // Load reads the built-in JS tracer files embedded in the binary and
 // returns a mapping of tracer name to source.
 func Load() (map[string]string, error) {
+	return load(files)
+}
+
+// LoadDir reads the JS tracer files (*.js) in the dir, and returns a mapping of
+// tracer name to source. Sub directories are not walked. Files converted to the same
+// name, e.g. my_tracer.js and myTracer.js, fail the loading.
+func LoadDir(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		assetTracers = make(map[string]string)
+		fileNames    = make(map[string]string)
+	)
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".js" {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		name := camel(strings.TrimSuffix(e.Name(), ".js"))
+		if prev, ok := fileNames[name]; ok {
+			return nil, fmt.Errorf("tracer %s: duplicated by %s and %s", name, prev, e.Name())
+		}
+		fileNames[name] = e.Name()
+		assetTracers[name] = string(b)
+	}
+	return assetTracers, nil
+}
+
+func load(fsys fs.FS) (map[string]string, error) {
 	var assetTracers = make(map[string]string)
-	err := fs.WalkDir(files, ".", func(path string, d fs.DirEntry, err error) error {
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 		if d.IsDir() {
 			return nil
 		}
-		b, err := fs.ReadFile(files, path)
+		b, err := fs.ReadFile(fsys, path)
 		if err != nil {
 			return err
 		}
@@ -20,6 +20,8 @@ import (
 	"encoding/json"
 	"errors"
 	"math/big"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -320,3 +322,152 @@ func TestSetup(t *testing.T) {
 		t.Errorf("tracer returned wrong result. have: %s, want: \"bar\"\n", string(have))
 	}
 }
+
+func TestRegisterDir(t *testing.T) {
+	dir := t.TempDir()
+	code := `{count: 0, step: function() { this.count++ }, fault: function() {}, result: function() { return this.count }}`
+	if err := os.WriteFile(filepath.Join(dir, "step_count.js"), []byte(code), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("ignored"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := RegisterDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0] != "stepCount" {
+		t.Fatalf("unexpected tracers registered: %v", names)
+	}
+	// callable by name without custom tracers allowed
+	for i := 0; i < 2; i++ {
+		tracer, err := tracers.DefaultDirectory.New("stepCount", nil, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		have, err := runTrace(tracer, testCtx(), &vm.ChainConfig{ChainConfig: *params.TestChainConfig}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(have) != `3` {
+			t.Errorf("tracer returned wrong result. have: %s, want: 3", string(have))
+		}
+	}
+	if !tracers.DefaultDirectory.IsJS("stepCount") {
+		t.Error("expected a JS tracer")
+	}
+
+	// existing tracers can't be shadowed
+	if _, err := RegisterDir(dir); err == nil || !strings.Contains(err.Error(), "tracer stepCount: already exists") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	dir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "unigram.js"), []byte(code), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RegisterDir(dir); err == nil || !strings.Contains(err.Error(), "tracer unigram: already exists") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// files converted to the same name fail the loading
+	dir = t.TempDir()
+	for _, name := range []string{"my_tracer.js", "myTracer.js"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(code), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := RegisterDir(dir); err == nil || !strings.Contains(err.Error(), "tracer myTracer: duplicated by") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// compile errors fail the loading
+	dir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "broken.js"), []byte(`{result: function() {`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RegisterDir(dir); err == nil || !strings.Contains(err.Error(), "tracer broken: ") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if tracers.DefaultDirectory.Has("broken") {
+		t.Error("broken tracer registered")
+	}
+}
+
+func TestLimits(t *testing.T) {
+	// JUMPDEST, PUSH1 0, JUMP: loops until out of gas
+	loop := []byte{byte(vm.JUMPDEST), byte(vm.PUSH1), 0x0, byte(vm.JUMP)}
+
+	for _, tc := range []struct {
+		name   string
+		code   string
+		limits tracers.Limits
+		limit  string
+	}{
+		{
+			name:   "steps",
+			code:   "{step: function() {}, fault: function() {}, result: function() { return null; }}",
+			limits: tracers.Limits{Steps: 10},
+			limit:  "steps",
+		},
+		{
+			name:   "timeout",
+			code:   "{step: function() { while(1); }, fault: function() {}, result: function() { return null; }}",
+			limits: tracers.Limits{Timeout: 100 * time.Millisecond},
+			limit:  "timeout",
+		},
+		{
+			name:   "memory",
+			code:   "{data: [], step: function(log) { this.data.push(log.memory.slice(0, 1024)); }, fault: function() {}, result: function() { return this.data.length; }}",
+			limits: tracers.Limits{Memory: 64 * 1024},
+			limit:  "memory",
+		},
+		{
+			name:   "memory of stack",
+			code:   "{data: [], step: function(log) { if (log.stack.length() > 0) this.data.push(log.stack.peek(0)); }, fault: function() {}, result: function() { return this.data.length; }}",
+			limits: tracers.Limits{Memory: 1024},
+			limit:  "memory",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tracer, err := newJsTracer(tc.code, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tracer.(tracers.LimitedTracer).SetLimits(tc.limits)
+			_, err = runTrace(tracer, testCtx(), &vm.ChainConfig{ChainConfig: *params.TestChainConfig}, loop)
+			limitErr, ok := tracers.AsLimitError(err)
+			if !ok {
+				t.Fatalf("expected limit error, got %v", err)
+			}
+			if limitErr.Limit != tc.limit {
+				t.Errorf("expected %s limit, got %s", tc.limit, limitErr.Limit)
+			}
+		})
+	}
+
+	// the result is charged to the memory budget
+	tracer, err := newJsTracer("{step: function() {}, fault: function() {}, result: function() { return new Array(1000).fill('data'); }}", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer.(tracers.LimitedTracer).SetLimits(tracers.Limits{Memory: 1024})
+	_, err = runTrace(tracer, testCtx(), &vm.ChainConfig{ChainConfig: *params.TestChainConfig}, nil)
+	if limitErr, ok := tracers.AsLimitError(err); !ok || limitErr.Limit != "memory" {
+		t.Fatalf("expected memory limit error, got %v", err)
+	}
+
+	// within the limits
+	tracer, err = newJsTracer("{steps: 0, step: function() { this.steps++ }, fault: function() {}, result: function() { return this.steps; }}", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer.(tracers.LimitedTracer).SetLimits(tracers.Limits{Steps: 10, Memory: 1024, Timeout: time.Minute})
+	have, err := runTrace(tracer, testCtx(), &vm.ChainConfig{ChainConfig: *params.TestChainConfig}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(have) != `3` {
+		t.Errorf("tracer returned wrong result. have: %s, want: 3", string(have))
+	}
+}
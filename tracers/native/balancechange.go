@@ -0,0 +1,281 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/vechain/thor/v2/builtin"
+	"github.com/vechain/thor/v2/thor"
+	"github.com/vechain/thor/v2/tracers"
+	"github.com/vechain/thor/v2/tx"
+	"github.com/vechain/thor/v2/vm"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("balanceChangeTracer", newBalanceChangeTracer, false)
+}
+
+var _ tracers.TxTracer = (*balanceChangeTracer)(nil)
+
+const (
+	tokenVET  = "VET"
+	tokenVTHO = "VTHO"
+)
+
+// transferEventID is the id of the VIP-180 (ERC-20) Transfer(address,address,uint256) event.
+var transferEventID = common.Hash(thor.Keccak256([]byte("Transfer(address,address,uint256)")))
+
+// balanceChange is the balance change of a token of an account.
+type balanceChange struct {
+	Address  common.Address `json:"address"`
+	Token    string         `json:"token"` // VET, VTHO or the address of the VIP-180 contract
+	Sent     *hexutil.Big   `json:"sent"`
+	Received *hexutil.Big   `json:"received"`
+	Change   *hexutil.Big   `json:"change"`
+}
+
+type tokenTransfer struct {
+	token    string
+	from, to common.Address
+	amount   *big.Int
+}
+
+// balanceChangeTracer records the balance changes of VET, VTHO and VIP-180 tokens by account. VET changes come from
+// value transfers of calls, creations and self-destructs, and token changes are decoded from Transfer events, including
+// those emitted by the builtin energy contract for VTHO. Transfers of reverted frames are discarded.
+//
+// The result of a clause excludes VTHO moved without Transfer events, e.g. the gas payment and the VTHO growth
+// generated by VET, and the result of a transaction, see TxResult, adds the gas payment to its clauses.
+type balanceChangeTracer struct {
+	noopTracer
+	frames    [][]*tokenTransfer // the transfers of each frame on the call stack
+	transfers []*tokenTransfer   // the transfers of the completed clause
+	interrupt atomic.Value       // Atomic flag to signal execution interruption
+	reason    error              // Textual reason for the interruption
+}
+
+// newBalanceChangeTracer returns a native go tracer which records token balance changes of a clause.
+func newBalanceChangeTracer(_ json.RawMessage) (tracers.Tracer, error) {
+	return &balanceChangeTracer{}, nil
+}
+
+func (t *balanceChangeTracer) interrupted() bool {
+	stop := t.interrupt.Load()
+	return stop != nil && stop.(bool)
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *balanceChangeTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.enter(tokenVET, from, to, value)
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *balanceChangeTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	if t.interrupted() || len(t.frames) == 0 {
+		return
+	}
+	if err == nil {
+		t.transfers = append(t.transfers, t.frames[0]...)
+	}
+	t.frames = nil
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *balanceChangeTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	// the value of CALLCODE stays with the caller
+	if typ == vm.CALLCODE {
+		value = nil
+	}
+	t.enter(tokenVET, from, to, value)
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *balanceChangeTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	if t.interrupted() || len(t.frames) < 2 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+	if err == nil {
+		t.frames[len(t.frames)-1] = append(t.frames[len(t.frames)-1], frame...)
+	}
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *balanceChangeTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, rData []byte, depth int, err error) {
+	if err != nil || op != vm.LOG3 || t.interrupted() || len(t.frames) == 0 {
+		return
+	}
+	stackData := stack.Data()
+	if len(stackData) < 5 {
+		return
+	}
+	// Don't modify the stack
+	if common.Hash(stackData[len(stackData)-3].Bytes32()) != transferEventID {
+		return
+	}
+	mStart := stackData[len(stackData)-1]
+	mSize := stackData[len(stackData)-2]
+	if !mSize.IsUint64() || mSize.Uint64() != 32 || !mStart.IsUint64() {
+		return
+	}
+	data, err := tracers.GetMemoryCopyPadded(memory, int64(mStart.Uint64()), 32)
+	if err != nil {
+		return
+	}
+
+	token := strings.ToLower(contract.Address().Hex())
+	if contract.Address() == common.Address(builtin.Energy.Address) {
+		token = tokenVTHO
+	}
+	frame := &t.frames[len(t.frames)-1]
+	*frame = append(*frame, &tokenTransfer{
+		token:  token,
+		from:   common.Address(stackData[len(stackData)-4].Bytes20()),
+		to:     common.Address(stackData[len(stackData)-5].Bytes20()),
+		amount: new(big.Int).SetBytes(data),
+	})
+}
+
+func (t *balanceChangeTracer) enter(token string, from, to common.Address, value *big.Int) {
+	if t.interrupted() {
+		return
+	}
+	var frame []*tokenTransfer
+	if value != nil && value.Sign() > 0 {
+		frame = append(frame, &tokenTransfer{token: token, from: from, to: to, amount: new(big.Int).Set(value)})
+	}
+	t.frames = append(t.frames, frame)
+}
+
+// GetResult returns the json-encoded list of balance changes sorted by address and token, and any error
+// arising from the encoding or forceful termination (via `Stop`).
+func (t *balanceChangeTracer) GetResult() (json.RawMessage, error) {
+	changes := make(balanceChanges)
+	for _, tr := range t.transfers {
+		// the zero address stands for minting and burning of tokens
+		if tr.amount.Sign() == 0 || tr.from == tr.to {
+			continue
+		}
+		if tr.token == tokenVET || tr.from != (common.Address{}) {
+			changes.add(tr.from, tr.token, tr.amount, nil)
+		}
+		if tr.token == tokenVET || tr.to != (common.Address{}) {
+			changes.add(tr.to, tr.token, nil, tr.amount)
+		}
+	}
+	res, err := changes.marshal()
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// TxResult merges the balance changes of clauses into those of the transaction, and accounts for the gas payment,
+// which moves VTHO without Transfer events: the payer sends the paid VTHO, and the beneficiary receives the reward,
+// with the rest being burned. Balance changes of clauses are discarded if the transaction is reverted.
+func (t *balanceChangeTracer) TxResult(clauses []json.RawMessage, receipt *tx.Receipt, beneficiary thor.Address) (json.RawMessage, error) {
+	changes := make(balanceChanges)
+	if !receipt.Reverted {
+		for _, clause := range clauses {
+			// the signed change is derived, and not decoded
+			var list []struct {
+				Address  common.Address `json:"address"`
+				Token    string         `json:"token"`
+				Sent     *hexutil.Big   `json:"sent"`
+				Received *hexutil.Big   `json:"received"`
+			}
+			if err := json.Unmarshal(clause, &list); err != nil {
+				return nil, err
+			}
+			for _, c := range list {
+				changes.add(c.Address, c.Token, c.Sent.ToInt(), c.Received.ToInt())
+			}
+		}
+	}
+	if receipt.Paid != nil && receipt.Paid.Sign() > 0 {
+		changes.add(common.Address(receipt.GasPayer), tokenVTHO, receipt.Paid, nil)
+	}
+	if receipt.Reward != nil && receipt.Reward.Sign() > 0 {
+		changes.add(common.Address(beneficiary), tokenVTHO, nil, receipt.Reward)
+	}
+	return changes.marshal()
+}
+
+type balanceKey struct {
+	address common.Address
+	token   string
+}
+
+// balanceChanges accumulates balance changes by account and token.
+type balanceChanges map[balanceKey]*balanceChange
+
+func (changes balanceChanges) add(address common.Address, token string, sent, received *big.Int) {
+	k := balanceKey{address, token}
+	c, ok := changes[k]
+	if !ok {
+		c = &balanceChange{
+			Address:  address,
+			Token:    token,
+			Sent:     (*hexutil.Big)(new(big.Int)),
+			Received: (*hexutil.Big)(new(big.Int)),
+		}
+		changes[k] = c
+	}
+	if sent != nil {
+		c.Sent.ToInt().Add(c.Sent.ToInt(), sent)
+	}
+	if received != nil {
+		c.Received.ToInt().Add(c.Received.ToInt(), received)
+	}
+}
+
+// marshal encodes the balance changes as a list sorted by address and token.
+func (changes balanceChanges) marshal() (json.RawMessage, error) {
+	list := make([]*balanceChange, 0, len(changes))
+	for _, c := range changes {
+		c.Change = (*hexutil.Big)(new(big.Int).Sub(c.Received.ToInt(), c.Sent.ToInt()))
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.Address != b.Address {
+			return a.Address.Hex() < b.Address.Hex()
+		}
+		return tokenOrder(a.Token) < tokenOrder(b.Token) || (tokenOrder(a.Token) == tokenOrder(b.Token) && a.Token < b.Token)
+	})
+
+	res, err := json.Marshal(list)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(res), nil
+}
+
+// tokenOrder places VET and VTHO ahead of VIP-180 tokens.
+func tokenOrder(token string) int {
+	switch token {
+	case tokenVET:
+		return 0
+	case tokenVTHO:
+		return 1
+	}
+	return 2
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *balanceChangeTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
@@ -51,6 +51,8 @@ type callFrame struct {
 	Error   string          `json:"error,omitempty" rlp:"optional"`
 	Calls   []callFrame     `json:"calls,omitempty" rlp:"optional"`
 	Logs    []callLog       `json:"logs,omitempty" rlp:"optional"`
+	// The method of the builtin native contract if the call is intercepted and run natively
+	NativeMethod string `json:"nativeMethod,omitempty" rlp:"optional"`
 	// Placed at end on purpose. The RLP will be decoded to 0 instead of
 	// nil if there are non-empty elements after in the struct.
 	Value *big.Int `json:"value,omitempty" rlp:"optional"`
@@ -224,6 +226,20 @@ func (t *callTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
 	t.callstack[size-1].Calls = append(t.callstack[size-1].Calls, call)
 }
 
+// CaptureNativeCall implements the vm.NativeCallLogger interface to mark the current call frame
+// as a call into the builtin native contract.
+func (t *callTracer) CaptureNativeCall(contract common.Address, method string) {
+	// native calls are never the top call
+	if t.config.OnlyTopCall {
+		return
+	}
+	// Skip if tracing was interrupted
+	if stop := t.interrupt.Load(); stop != nil && stop.(bool) {
+		return
+	}
+	t.callstack[len(t.callstack)-1].NativeMethod = method
+}
+
 func (t *callTracer) CaptureClauseStart(gasLimit uint64) {
 	t.gasLimit = gasLimit
 }
@@ -0,0 +1,262 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/vechain/thor/v2/thor"
+	"github.com/vechain/thor/v2/tracers"
+	"github.com/vechain/thor/v2/vm"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("flatCallTracer", newFlatCallTracer, false)
+}
+
+// flatCallFrame is a Parity-style trace of a call frame.
+type flatCallFrame struct {
+	Action       flatCallAction  `json:"action"`
+	BlockID      *thor.Bytes32   `json:"blockID,omitempty"`
+	TxID         *thor.Bytes32   `json:"txID,omitempty"`
+	TxIndex      uint64          `json:"txIndex"`
+	ClauseIndex  uint32          `json:"clauseIndex"`
+	Error        string          `json:"error,omitempty"`
+	Result       *flatCallResult `json:"result,omitempty"`
+	Subtraces    int             `json:"subtraces"`
+	TraceAddress []int           `json:"traceAddress"`
+	Type         string          `json:"type"`
+}
+
+type flatCallAction struct {
+	Address       *common.Address `json:"address,omitempty"`
+	Balance       *hexutil.Big    `json:"balance,omitempty"`
+	CallType      string          `json:"callType,omitempty"`
+	From          *common.Address `json:"from,omitempty"`
+	Gas           *hexutil.Uint64 `json:"gas,omitempty"`
+	Init          *hexutil.Bytes  `json:"init,omitempty"`
+	Input         *hexutil.Bytes  `json:"input,omitempty"`
+	NativeMethod  string          `json:"nativeMethod,omitempty"`
+	RefundAddress *common.Address `json:"refundAddress,omitempty"`
+	To            *common.Address `json:"to,omitempty"`
+	Value         *hexutil.Big    `json:"value,omitempty"`
+}
+
+type flatCallResult struct {
+	Address *common.Address `json:"address,omitempty"`
+	Code    *hexutil.Bytes  `json:"code,omitempty"`
+	GasUsed *hexutil.Uint64 `json:"gasUsed,omitempty"`
+	Output  *hexutil.Bytes  `json:"output,omitempty"`
+}
+
+// flatCallTracer reports the call frames of a clause as a flat list in the Parity trace format, where each
+// frame is located by its traceAddress, i.e. the path of child indexes from the top call. VET transfers are
+// calls with value, and calls into builtin native contracts carry the name of the native method.
+type flatCallTracer struct {
+	tracer            *callTracer
+	config            flatCallTracerConfig
+	ctx               *tracers.Context
+	activePrecompiles []common.Address
+}
+
+type flatCallTracerConfig struct {
+	IncludePrecompiles bool `json:"includePrecompiles"` // If true, calls to precompiled contracts are included
+}
+
+// newFlatCallTracer returns a new flatCallTracer.
+func newFlatCallTracer(cfg json.RawMessage) (tracers.Tracer, error) {
+	var config flatCallTracerConfig
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	tracer, err := newCallTracer(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &flatCallTracer{tracer: tracer.(*callTracer), config: config}, nil
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *flatCallTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.tracer.CaptureStart(env, from, to, create, input, gas, value)
+	t.activePrecompiles = vm.ActivePrecompiles(env.ChainConfig().Rules(env.Context.BlockNumber))
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *flatCallTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	t.tracer.CaptureEnd(output, gasUsed, err)
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *flatCallTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, rData []byte, depth int, err error) {
+	t.tracer.CaptureState(pc, op, gas, cost, memory, stack, contract, rData, depth, err)
+}
+
+// CaptureFault implements the EVMLogger interface to trace an execution fault.
+func (t *flatCallTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) {
+	t.tracer.CaptureFault(pc, op, gas, cost, memory, stack, contract, depth, err)
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *flatCallTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.tracer.CaptureEnter(typ, from, to, input, gas, value)
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *flatCallTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	t.tracer.CaptureExit(output, gasUsed, err)
+
+	if t.config.IncludePrecompiles {
+		return
+	}
+	// Parity traces don't include calls to precompiles
+	if size := len(t.tracer.callstack); size > 0 {
+		parent := &t.tracer.callstack[size-1]
+		if n := len(parent.Calls); n > 0 {
+			call := parent.Calls[n-1]
+			if (call.Type == vm.CALL || call.Type == vm.STATICCALL) && call.To != nil && t.isPrecompiled(*call.To) {
+				parent.Calls = parent.Calls[:n-1]
+			}
+		}
+	}
+}
+
+// CaptureNativeCall implements the vm.NativeCallLogger interface.
+func (t *flatCallTracer) CaptureNativeCall(contract common.Address, method string) {
+	t.tracer.CaptureNativeCall(contract, method)
+}
+
+func (t *flatCallTracer) CaptureClauseStart(gasLimit uint64) {
+	t.tracer.CaptureClauseStart(gasLimit)
+}
+
+func (t *flatCallTracer) CaptureClauseEnd(restGas uint64) {
+	t.tracer.CaptureClauseEnd(restGas)
+}
+
+// SetContext set the tracer context
+func (t *flatCallTracer) SetContext(ctx *tracers.Context) {
+	t.ctx = ctx
+}
+
+// GetResult returns the json-encoded flat list of call traces, and any
+// error arising from the encoding or forceful termination (via `Stop`).
+func (t *flatCallTracer) GetResult() (json.RawMessage, error) {
+	if len(t.tracer.callstack) != 1 {
+		return nil, errors.New("incorrect number of top-level calls")
+	}
+	flat := t.flatten(&t.tracer.callstack[0], []int{}, nil)
+	res, err := json.Marshal(flat)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(res), t.tracer.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *flatCallTracer) Stop(err error) {
+	t.tracer.Stop(err)
+}
+
+func (t *flatCallTracer) isPrecompiled(addr common.Address) bool {
+	for _, p := range t.activePrecompiles {
+		if p == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// flatten appends the frame and its descendants in depth-first order.
+func (t *flatCallTracer) flatten(input *callFrame, traceAddress []int, output []*flatCallFrame) []*flatCallFrame {
+	frame := newFlatCallFrame(input)
+	frame.Subtraces = len(input.Calls)
+	frame.TraceAddress = traceAddress
+	if t.ctx != nil {
+		if !t.ctx.BlockID.IsZero() {
+			blockID := t.ctx.BlockID
+			frame.BlockID = &blockID
+		}
+		if !t.ctx.TxID.IsZero() {
+			txID := t.ctx.TxID
+			frame.TxID = &txID
+		}
+		frame.TxIndex = t.ctx.TxIndex
+		frame.ClauseIndex = t.ctx.ClauseIndex
+	}
+	output = append(output, frame)
+
+	for i := range input.Calls {
+		childAddress := make([]int, len(traceAddress), len(traceAddress)+1)
+		copy(childAddress, traceAddress)
+		output = t.flatten(&input.Calls[i], append(childAddress, i), output)
+	}
+	return output
+}
+
+func newFlatCallFrame(input *callFrame) *flatCallFrame {
+	var (
+		frame   = &flatCallFrame{Error: input.Error}
+		gas     = hexutil.Uint64(input.Gas)
+		gasUsed = hexutil.Uint64(input.GasUsed)
+		value   = (*hexutil.Big)(input.Value)
+	)
+
+	switch input.Type {
+	case vm.CREATE, vm.CREATE2:
+		init := hexutil.Bytes(input.Input)
+		frame.Type = "create"
+		frame.Action = flatCallAction{
+			From:  &input.From,
+			Gas:   &gas,
+			Init:  &init,
+			Value: value,
+		}
+		if !input.failed() {
+			code := hexutil.Bytes(input.Output)
+			frame.Result = &flatCallResult{
+				Address: input.To,
+				Code:    &code,
+				GasUsed: &gasUsed,
+			}
+		}
+	case vm.SELFDESTRUCT:
+		frame.Type = "suicide"
+		frame.Action = flatCallAction{
+			Address:       &input.From,
+			Balance:       value,
+			RefundAddress: input.To,
+		}
+	default:
+		data := hexutil.Bytes(input.Input)
+		frame.Type = "call"
+		frame.Action = flatCallAction{
+			CallType:     strings.ToLower(input.Type.String()),
+			From:         &input.From,
+			Gas:          &gas,
+			Input:        &data,
+			NativeMethod: input.NativeMethod,
+			To:           input.To,
+			Value:        value,
+		}
+		if !input.failed() {
+			out := hexutil.Bytes(input.Output)
+			frame.Result = &flatCallResult{
+				GasUsed: &gasUsed,
+				Output:  &out,
+			}
+		}
+	}
+	return frame
+}
@@ -0,0 +1,303 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package native
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/vechain/thor/v2/tracers"
+	"github.com/vechain/thor/v2/vm"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("gasProfilerTracer", newGasProfilerTracer, false)
+}
+
+const (
+	gasProfileJSON   = "json"
+	gasProfileFolded = "folded"
+)
+
+// gasProfileFunction aggregates the gas used by a function of a contract, which is identified by the selector.
+type gasProfileFunction struct {
+	Selector string          `json:"selector"`
+	Calls    uint64          `json:"calls"`
+	Gas      uint64          `json:"gas"`
+	Steps    uint64          `json:"steps"`
+	PCs      []*gasProfilePC `json:"pcs"`
+
+	pcs map[uint64]*gasProfilePC
+}
+
+// gasProfilePC aggregates the gas used by an instruction.
+type gasProfilePC struct {
+	PC    uint64 `json:"pc"`
+	Op    string `json:"op"`
+	Gas   uint64 `json:"gas"`
+	Count uint64 `json:"count"`
+}
+
+type gasProfileContract struct {
+	Address   common.Address        `json:"address"`
+	Gas       uint64                `json:"gas"`
+	Steps     uint64                `json:"steps"`
+	Functions []*gasProfileFunction `json:"functions"`
+
+	functions map[string]*gasProfileFunction
+}
+
+type gasProfileFrame struct {
+	contract *gasProfileContract
+	function *gasProfileFunction
+	stack    string // the folded call stack down to this frame
+	gas      uint64 // the gas given to the frame
+	selfGas  uint64 // the gas used by the frame itself
+	childGas uint64 // the gas used by child frames
+	// the last step, of which the gas is known on the next step or the exit of the frame
+	pending    *gasProfilePC
+	pendingGas uint64
+	stepChild  uint64 // the gas used by child frames since the last step
+}
+
+// gasProfilerTracer aggregates the gas used and the steps executed by contract, function selector and
+// program counter. Gas is exclusive, i.e. the gas used by a call frame doesn't include that of its child frames,
+// and the gas of a step is the gas spent between the step and the next step of the same frame.
+//
+// The result is either a JSON summary sorted by gas, or a string of folded stacks for flame graphs if the
+// format is "folded", where each line is the call stack of frames named by <address>:<selector> and the gas used.
+type gasProfilerTracer struct {
+	noopTracer
+	config    gasProfilerTracerConfig
+	contracts map[common.Address]*gasProfileContract
+	folded    map[string]uint64
+	frames    []*gasProfileFrame
+	gasUsed   uint64
+	interrupt atomic.Value // Atomic flag to signal execution interruption
+	reason    error        // Textual reason for the interruption
+}
+
+type gasProfilerTracerConfig struct {
+	Format string `json:"format"` // json or folded, defaults to json
+}
+
+func newGasProfilerTracer(cfg json.RawMessage) (tracers.Tracer, error) {
+	var config gasProfilerTracerConfig
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	switch config.Format {
+	case "":
+		config.Format = gasProfileJSON
+	case gasProfileJSON, gasProfileFolded:
+	default:
+		return nil, fmt.Errorf("unsupported format %q", config.Format)
+	}
+	return &gasProfilerTracer{
+		config:    config,
+		contracts: make(map[common.Address]*gasProfileContract),
+		folded:    make(map[string]uint64),
+	}, nil
+}
+
+func (t *gasProfilerTracer) interrupted() bool {
+	stop := t.interrupt.Load()
+	return stop != nil && stop.(bool)
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *gasProfilerTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.enter(to, create, input, gas)
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *gasProfilerTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	t.exit(gasUsed)
+	t.gasUsed += gasUsed
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *gasProfilerTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.enter(to, typ == vm.CREATE || typ == vm.CREATE2, input, gas)
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *gasProfilerTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	t.exit(gasUsed)
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *gasProfilerTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, rData []byte, depth int, err error) {
+	if t.interrupted() || len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	if frame.pending != nil {
+		t.settle(frame, gas)
+	}
+
+	entry, ok := frame.function.pcs[pc]
+	if !ok {
+		entry = &gasProfilePC{PC: pc, Op: op.String()}
+		frame.function.pcs[pc] = entry
+	}
+	entry.Count++
+	frame.function.Steps++
+	frame.contract.Steps++
+	frame.pending = entry
+	frame.pendingGas = gas
+}
+
+func (t *gasProfilerTracer) enter(to common.Address, create bool, input []byte, gas uint64) {
+	if t.interrupted() {
+		return
+	}
+	contract, ok := t.contracts[to]
+	if !ok {
+		contract = &gasProfileContract{Address: to, functions: make(map[string]*gasProfileFunction)}
+		t.contracts[to] = contract
+	}
+	var selector string
+	switch {
+	case create:
+		selector = "create"
+	case len(input) < 4:
+		selector = "fallback"
+	default:
+		selector = bytesToHex(input[:4])
+	}
+	function, ok := contract.functions[selector]
+	if !ok {
+		function = &gasProfileFunction{Selector: selector, pcs: make(map[uint64]*gasProfilePC)}
+		contract.functions[selector] = function
+	}
+	function.Calls++
+
+	name := strings.ToLower(to.Hex()) + ":" + selector
+	if len(t.frames) > 0 {
+		name = t.frames[len(t.frames)-1].stack + ";" + name
+	}
+	t.frames = append(t.frames, &gasProfileFrame{
+		contract: contract,
+		function: function,
+		stack:    name,
+		gas:      gas,
+	})
+}
+
+func (t *gasProfilerTracer) exit(gasUsed uint64) {
+	if t.interrupted() || len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+
+	if frame.pending != nil {
+		var left uint64
+		if frame.gas > gasUsed {
+			left = frame.gas - gasUsed
+		}
+		t.settle(frame, left)
+	}
+	// the gas not spent by steps, e.g. that of precompiled contracts and builtin native calls
+	if spent := frame.selfGas + frame.childGas; gasUsed > spent {
+		frame.function.Gas += gasUsed - spent
+		frame.contract.Gas += gasUsed - spent
+		frame.selfGas += gasUsed - spent
+	}
+	if frame.selfGas > 0 {
+		t.folded[frame.stack] += frame.selfGas
+	}
+
+	if len(t.frames) > 0 {
+		parent := t.frames[len(t.frames)-1]
+		parent.childGas += gasUsed
+		parent.stepChild += gasUsed
+	}
+}
+
+// settle attributes the gas spent since the pending step, excluding that of child frames, to the step.
+func (t *gasProfilerTracer) settle(frame *gasProfileFrame, gas uint64) {
+	var used uint64
+	if frame.pendingGas > gas+frame.stepChild {
+		used = frame.pendingGas - gas - frame.stepChild
+	}
+	frame.pending.Gas += used
+	frame.function.Gas += used
+	frame.contract.Gas += used
+	frame.selfGas += used
+	frame.pending = nil
+	frame.stepChild = 0
+}
+
+// GetResult returns the json-encoded gas profile, and any error arising from the encoding or forceful
+// termination (via `Stop`).
+func (t *gasProfilerTracer) GetResult() (json.RawMessage, error) {
+	var (
+		res []byte
+		err error
+	)
+	if t.config.Format == gasProfileFolded {
+		stacks := make([]string, 0, len(t.folded))
+		for stack := range t.folded {
+			stacks = append(stacks, stack)
+		}
+		sort.Strings(stacks)
+
+		var b strings.Builder
+		for _, stack := range stacks {
+			fmt.Fprintf(&b, "%s %d\n", stack, t.folded[stack])
+		}
+		res, err = json.Marshal(b.String())
+	} else {
+		contracts := make([]*gasProfileContract, 0, len(t.contracts))
+		for _, contract := range t.contracts {
+			contract.Functions = make([]*gasProfileFunction, 0, len(contract.functions))
+			for _, function := range contract.functions {
+				function.PCs = make([]*gasProfilePC, 0, len(function.pcs))
+				for _, pc := range function.pcs {
+					function.PCs = append(function.PCs, pc)
+				}
+				sort.Slice(function.PCs, func(i, j int) bool {
+					a, b := function.PCs[i], function.PCs[j]
+					return a.Gas > b.Gas || (a.Gas == b.Gas && a.PC < b.PC)
+				})
+				contract.Functions = append(contract.Functions, function)
+			}
+			sort.Slice(contract.Functions, func(i, j int) bool {
+				a, b := contract.Functions[i], contract.Functions[j]
+				return a.Gas > b.Gas || (a.Gas == b.Gas && a.Selector < b.Selector)
+			})
+			contracts = append(contracts, contract)
+		}
+		sort.Slice(contracts, func(i, j int) bool {
+			a, b := contracts[i], contracts[j]
+			return a.Gas > b.Gas || (a.Gas == b.Gas && a.Address.Hex() < b.Address.Hex())
+		})
+		res, err = json.Marshal(struct {
+			GasUsed   uint64                `json:"gasUsed"`
+			Contracts []*gasProfileContract `json:"contracts"`
+		}{t.gasUsed, contracts})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(res), t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *gasProfilerTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
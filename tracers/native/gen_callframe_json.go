@@ -16,18 +16,19 @@ var _ = (*callFrameMarshaling)(nil)
 // MarshalJSON marshals as JSON.
 func (c callFrame) MarshalJSON() ([]byte, error) {
 	type callFrame0 struct {
-		Type       vm.OpCode       `json:"-"`
-		From       common.Address  `json:"from"`
-		Gas        hexutil.Uint64  `json:"gas"`
-		GasUsed    hexutil.Uint64  `json:"gasUsed"`
-		To         *common.Address `json:"to,omitempty" rlp:"optional"`
-		Input      hexutil.Bytes   `json:"input" rlp:"optional"`
-		Output     hexutil.Bytes   `json:"output,omitempty" rlp:"optional"`
-		Error      string          `json:"error,omitempty" rlp:"optional"`
-		Calls      []callFrame     `json:"calls,omitempty" rlp:"optional"`
-		Logs       []callLog       `json:"logs,omitempty" rlp:"optional"`
-		Value      *hexutil.Big    `json:"value,omitempty" rlp:"optional"`
-		TypeString string          `json:"type"`
+		Type         vm.OpCode       `json:"-"`
+		From         common.Address  `json:"from"`
+		Gas          hexutil.Uint64  `json:"gas"`
+		GasUsed      hexutil.Uint64  `json:"gasUsed"`
+		To           *common.Address `json:"to,omitempty" rlp:"optional"`
+		Input        hexutil.Bytes   `json:"input" rlp:"optional"`
+		Output       hexutil.Bytes   `json:"output,omitempty" rlp:"optional"`
+		Error        string          `json:"error,omitempty" rlp:"optional"`
+		Calls        []callFrame     `json:"calls,omitempty" rlp:"optional"`
+		Logs         []callLog       `json:"logs,omitempty" rlp:"optional"`
+		NativeMethod string          `json:"nativeMethod,omitempty" rlp:"optional"`
+		Value        *hexutil.Big    `json:"value,omitempty" rlp:"optional"`
+		TypeString   string          `json:"type"`
 	}
 	var enc callFrame0
 	enc.Type = c.Type
@@ -40,6 +41,7 @@ func (c callFrame) MarshalJSON() ([]byte, error) {
 	enc.Error = c.Error
 	enc.Calls = c.Calls
 	enc.Logs = c.Logs
+	enc.NativeMethod = c.NativeMethod
 	enc.Value = (*hexutil.Big)(c.Value)
 	enc.TypeString = c.TypeString()
 	return json.Marshal(&enc)
@@ -48,17 +50,18 @@ func (c callFrame) MarshalJSON() ([]byte, error) {
 // UnmarshalJSON unmarshals from JSON.
 func (c *callFrame) UnmarshalJSON(input []byte) error {
 	type callFrame0 struct {
-		Type    *vm.OpCode      `json:"-"`
-		From    *common.Address `json:"from"`
-		Gas     *hexutil.Uint64 `json:"gas"`
-		GasUsed *hexutil.Uint64 `json:"gasUsed"`
-		To      *common.Address `json:"to,omitempty" rlp:"optional"`
-		Input   *hexutil.Bytes  `json:"input" rlp:"optional"`
-		Output  *hexutil.Bytes  `json:"output,omitempty" rlp:"optional"`
-		Error   *string         `json:"error,omitempty" rlp:"optional"`
-		Calls   []callFrame     `json:"calls,omitempty" rlp:"optional"`
-		Logs    []callLog       `json:"logs,omitempty" rlp:"optional"`
-		Value   *hexutil.Big    `json:"value,omitempty" rlp:"optional"`
+		Type         *vm.OpCode      `json:"-"`
+		From         *common.Address `json:"from"`
+		Gas          *hexutil.Uint64 `json:"gas"`
+		GasUsed      *hexutil.Uint64 `json:"gasUsed"`
+		To           *common.Address `json:"to,omitempty" rlp:"optional"`
+		Input        *hexutil.Bytes  `json:"input" rlp:"optional"`
+		Output       *hexutil.Bytes  `json:"output,omitempty" rlp:"optional"`
+		Error        *string         `json:"error,omitempty" rlp:"optional"`
+		Calls        []callFrame     `json:"calls,omitempty" rlp:"optional"`
+		Logs         []callLog       `json:"logs,omitempty" rlp:"optional"`
+		NativeMethod *string         `json:"nativeMethod,omitempty" rlp:"optional"`
+		Value        *hexutil.Big    `json:"value,omitempty" rlp:"optional"`
 	}
 	var dec callFrame0
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -94,6 +97,9 @@ func (c *callFrame) UnmarshalJSON(input []byte) error {
 	if dec.Logs != nil {
 		c.Logs = dec.Logs
 	}
+	if dec.NativeMethod != nil {
+		c.NativeMethod = *dec.NativeMethod
+	}
 	if dec.Value != nil {
 		c.Value = (*big.Int)(dec.Value)
 	}
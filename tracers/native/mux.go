@@ -0,0 +1,168 @@
+// Copyright (c) 2024 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package native
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/vechain/thor/v2/tracers"
+	"github.com/vechain/thor/v2/vm"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("muxTracer", newMuxTracer, false)
+}
+
+// muxTracer runs multiple tracers in one execution, and combines their results into one object keyed by
+// the tracer names.
+type muxTracer struct {
+	names   []string
+	tracers []tracers.Tracer
+}
+
+// newMuxTracer returns a new mux tracer. The config maps tracer names to their configs, and only the
+// registered tracers are allowed, except the mux tracer itself, so that the sub tracers can be checked by the caller.
+func newMuxTracer(cfg json.RawMessage) (tracers.Tracer, error) {
+	var config map[string]json.RawMessage
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	if len(config) == 0 {
+		return nil, fmt.Errorf("no tracer specified")
+	}
+
+	names := make([]string, 0, len(config))
+	for name := range config {
+		if name == "mux" || name == "muxTracer" {
+			return nil, fmt.Errorf("nested mux tracer is not allowed")
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	objects := make([]tracers.Tracer, 0, len(names))
+	for _, name := range names {
+		t, err := tracers.DefaultDirectory.New(name, config[name], false)
+		if err != nil {
+			return nil, fmt.Errorf("create tracer %s: %w", name, err)
+		}
+		objects = append(objects, t)
+	}
+	return &muxTracer{names: names, tracers: objects}, nil
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *muxTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	for _, t := range t.tracers {
+		t.CaptureStart(env, from, to, create, input, gas, value)
+	}
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *muxTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	for _, t := range t.tracers {
+		t.CaptureEnd(output, gasUsed, err)
+	}
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *muxTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, rData []byte, depth int, err error) {
+	for _, t := range t.tracers {
+		t.CaptureState(pc, op, gas, cost, memory, stack, contract, rData, depth, err)
+	}
+}
+
+// CaptureFault implements the EVMLogger interface to trace an execution fault.
+func (t *muxTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) {
+	for _, t := range t.tracers {
+		t.CaptureFault(pc, op, gas, cost, memory, stack, contract, depth, err)
+	}
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *muxTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	for _, t := range t.tracers {
+		t.CaptureEnter(typ, from, to, input, gas, value)
+	}
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *muxTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	for _, t := range t.tracers {
+		t.CaptureExit(output, gasUsed, err)
+	}
+}
+
+// CaptureNativeCall implements the vm.NativeCallLogger interface, and is passed to the tracers which
+// implement it.
+func (t *muxTracer) CaptureNativeCall(contract common.Address, method string) {
+	for _, t := range t.tracers {
+		if logger, ok := t.(vm.NativeCallLogger); ok {
+			logger.CaptureNativeCall(contract, method)
+		}
+	}
+}
+
+func (t *muxTracer) CaptureClauseStart(gasLimit uint64) {
+	for _, t := range t.tracers {
+		t.CaptureClauseStart(gasLimit)
+	}
+}
+
+func (t *muxTracer) CaptureClauseEnd(restGas uint64) {
+	for _, t := range t.tracers {
+		t.CaptureClauseEnd(restGas)
+	}
+}
+
+// SetContext set the tracer context
+func (t *muxTracer) SetContext(ctx *tracers.Context) {
+	for _, t := range t.tracers {
+		t.SetContext(ctx)
+	}
+}
+
+// GetResult returns an object of the json-encoded results of the tracers keyed by the tracer names,
+// and the first error of the tracers.
+func (t *muxTracer) GetResult() (json.RawMessage, error) {
+	resObject := make(map[string]json.RawMessage, len(t.tracers))
+	for i, tracer := range t.tracers {
+		r, err := tracer.GetResult()
+		if err != nil {
+			return nil, err
+		}
+		resObject[t.names[i]] = r
+	}
+	res, err := json.Marshal(resObject)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// SetLimits implements the tracers.LimitedTracer interface, and is passed to the tracers which
+// implement it.
+func (t *muxTracer) SetLimits(limits tracers.Limits) {
+	for _, t := range t.tracers {
+		if limited, ok := t.(tracers.LimitedTracer); ok {
+			limited.SetLimits(limits)
+		}
+	}
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *muxTracer) Stop(err error) {
+	for _, t := range t.tracers {
+		t.Stop(err)
+	}
+}
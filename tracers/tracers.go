@@ -19,11 +19,13 @@ package tracers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
-	"github.com/pkg/errors"
 	"github.com/vechain/thor/v2/state"
 	"github.com/vechain/thor/v2/thor"
+	"github.com/vechain/thor/v2/tx"
 	"github.com/vechain/thor/v2/vm"
 )
 
@@ -48,6 +50,46 @@ type Tracer interface {
 	Stop(err error)
 }
 
+// Limits are the execution limits of a tracer. A zero value means unlimited.
+type Limits struct {
+	Steps   uint64        // Max number of calls into the tracer code
+	Memory  uint64        // Max bytes handed to the tracer code by the EVM accessors and returned as its result
+	Timeout time.Duration // Max wall-clock time of the tracer since the clause starts
+}
+
+// LimitedTracer is an optional interface of Tracer, implemented by tracers running
+// untrusted code, to be interrupted once any of the limits is exceeded.
+type LimitedTracer interface {
+	SetLimits(limits Limits)
+}
+
+// TxTracer is an optional interface of Tracer, implemented by tracers whose results of clauses add up to the
+// result of the transaction.
+type TxTracer interface {
+	// TxResult returns the result of the transaction from the results of its clauses and the receipt, with
+	// beneficiary being the one rewarded for the gas.
+	TxResult(clauses []json.RawMessage, receipt *tx.Receipt, beneficiary thor.Address) (json.RawMessage, error)
+}
+
+// LimitError is the error of a tracer interrupted for exceeding an execution limit.
+type LimitError struct {
+	Limit string // steps, memory or timeout
+	Max   string // the value of the limit
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("tracer execution limit exceeded: %s (max %s)", e.Limit, e.Max)
+}
+
+// AsLimitError returns the LimitError in the chain of the err, if any.
+func AsLimitError(err error) (*LimitError, bool) {
+	var e *LimitError
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
 type ctorFn func(json.RawMessage) (Tracer, error)
 type jsCtorFn func(string, json.RawMessage) (Tracer, error)
 
@@ -95,7 +137,7 @@ func (d *directory) New(name string, cfg json.RawMessage, allowCustom bool) (Tra
 		// Assume JS code
 		tracer, err := d.jsEval(name, cfg)
 		if err != nil {
-			return nil, errors.Wrap(err, "unable to create custom tracer")
+			return nil, fmt.Errorf("unable to create custom tracer: %w", err)
 		}
 		return tracer, nil
 	} else {
@@ -103,12 +145,30 @@ func (d *directory) New(name string, cfg json.RawMessage, allowCustom bool) (Tra
 	}
 }
 
+// Has returns whether the tracer of the name is registered, taking the
+// omitted "Tracer" suffix into account.
+func (d *directory) Has(name string) bool {
+	_, ok := d.Lookup(name)
+	return ok
+}
+
+// Lookup returns the registered name of the tracer, which may be given without the "Tracer" suffix.
+func (d *directory) Lookup(name string) (string, bool) {
+	if _, ok := d.elems[name]; ok {
+		return name, true
+	}
+	if _, ok := d.elems[name+"Tracer"]; ok {
+		return name + "Tracer", true
+	}
+	return "", false
+}
+
 // IsJS will return true if the given tracer will evaluate
 // JS code. Because code evaluation has high overhead, this
 // info will be used in determining fast and slow code paths.
 func (d *directory) IsJS(name string) bool {
-	if elem, ok := d.elems[name]; ok {
-		return elem.isJS
+	if registered, ok := d.Lookup(name); ok {
+		return d.elems[registered].isJS
 	}
 	// JS eval will execute JS code
 	return true
@@ -21,6 +21,7 @@ import (
 	"math/big"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -29,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/stretchr/testify/assert"
 	"github.com/vechain/thor/v2/block"
+	"github.com/vechain/thor/v2/builtin"
 	"github.com/vechain/thor/v2/chain"
 	"github.com/vechain/thor/v2/genesis"
 	"github.com/vechain/thor/v2/muxdb"
@@ -185,6 +187,20 @@ func TestNewTracer(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestLookupTracer(t *testing.T) {
+	for _, name := range []string{"call", "callTracer"} {
+		registered, ok := tracers.DefaultDirectory.Lookup(name)
+		assert.True(t, ok)
+		assert.Equal(t, "callTracer", registered)
+		assert.False(t, tracers.DefaultDirectory.IsJS(name))
+	}
+	assert.True(t, tracers.DefaultDirectory.IsJS("unigram"))
+
+	_, ok := tracers.DefaultDirectory.Lookup("{result: function() {}}")
+	assert.False(t, ok)
+	assert.True(t, tracers.DefaultDirectory.IsJS("{result: function() {}}"))
+}
+
 func TestAllTracers(t *testing.T) {
 	var testData callTest
 	if blob, err := os.ReadFile("testdata/calls.json"); err != nil {
@@ -200,6 +216,337 @@ func TestAllTracers(t *testing.T) {
 	RunTracerTest(t, &testData.traceTest, "trigram")
 	RunTracerTest(t, &testData.traceTest, "evmdis")
 	RunTracerTest(t, &testData.traceTest, "opcount")
+	RunTracerTest(t, &testData.traceTest, "gasProfilerTracer")
+	RunTracerTest(t, &testData.traceTest, "flatCallTracer")
+	RunTracerTest(t, &testData.traceTest, "balanceChangeTracer")
+}
+
+func TestGasProfilerTracer(t *testing.T) {
+	var testData callTest
+	if blob, err := os.ReadFile("testdata/calls.json"); err != nil {
+		t.Fatalf("failed to read testcase: %v", err)
+	} else if err := json.Unmarshal(blob, &testData); err != nil {
+		t.Fatalf("failed to parse testcase: %v", err)
+	}
+
+	type profile struct {
+		GasUsed   uint64 `json:"gasUsed"`
+		Contracts []struct {
+			Address   common.Address `json:"address"`
+			Gas       uint64         `json:"gas"`
+			Steps     uint64         `json:"steps"`
+			Functions []struct {
+				Selector string `json:"selector"`
+				Calls    uint64 `json:"calls"`
+				Gas      uint64 `json:"gas"`
+				Steps    uint64 `json:"steps"`
+				PCs      []struct {
+					PC    uint64 `json:"pc"`
+					Op    string `json:"op"`
+					Gas   uint64 `json:"gas"`
+					Count uint64 `json:"count"`
+				} `json:"pcs"`
+			} `json:"functions"`
+		} `json:"contracts"`
+	}
+	var got profile
+	if err := json.Unmarshal(RunTracerTest(t, &testData.traceTest, "gasProfilerTracer"), &got); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint64(testData.Calls.GasUsed), got.GasUsed)
+
+	// the exclusive gas sums up to the gas used
+	var sum uint64
+	for i, contract := range got.Contracts {
+		if i > 0 {
+			assert.True(t, got.Contracts[i-1].Gas >= contract.Gas, "sorted by gas")
+		}
+		var functionGas, steps uint64
+		for _, function := range contract.Functions {
+			functionGas += function.Gas
+			steps += function.Steps
+			assert.NotZero(t, function.Calls)
+		}
+		assert.Equal(t, contract.Gas, functionGas)
+		assert.Equal(t, contract.Steps, steps)
+		sum += contract.Gas
+	}
+	assert.Equal(t, got.GasUsed, sum)
+	// the called contract creates contracts
+	var called, creates int
+	for _, contract := range got.Contracts {
+		for _, function := range contract.Functions {
+			if contract.Address == common.Address(*testData.Clause.To) && function.Selector == "0x706e3506" {
+				called++
+			} else if function.Selector == "create" {
+				creates++
+			}
+		}
+	}
+	assert.Equal(t, 1, called)
+	assert.NotZero(t, creates)
+
+	testData.Config = json.RawMessage(`{"format": "folded"}`)
+	var folded string
+	if err := json.Unmarshal(RunTracerTest(t, &testData.traceTest, "gasProfilerTracer"), &folded); err != nil {
+		t.Fatal(err)
+	}
+	sum = 0
+	lines := strings.Split(strings.TrimSpace(folded), "\n")
+	for _, line := range lines {
+		i := strings.LastIndex(line, " ")
+		gas, err := strconv.ParseUint(line[i+1:], 10, 64)
+		assert.Nil(t, err)
+		sum += gas
+	}
+	assert.Equal(t, got.GasUsed, sum)
+	assert.True(t, strings.HasPrefix(lines[0], strings.ToLower(testData.Clause.To.String())+":0x706e3506"))
+
+	_, err := tracers.DefaultDirectory.New("gasProfilerTracer", json.RawMessage(`{"format": "svg"}`), false)
+	assert.EqualError(t, err, `unsupported format "svg"`)
+}
+
+type flatCallFrame struct {
+	Action struct {
+		CallType     string                `json:"callType"`
+		From         thor.Address          `json:"from"`
+		To           *thor.Address         `json:"to"`
+		Value        *math.HexOrDecimal256 `json:"value"`
+		Init         hexutil.Bytes         `json:"init"`
+		Input        hexutil.Bytes         `json:"input"`
+		NativeMethod string                `json:"nativeMethod"`
+	} `json:"action"`
+	Error  string `json:"error"`
+	Result *struct {
+		Address *thor.Address       `json:"address"`
+		GasUsed math.HexOrDecimal64 `json:"gasUsed"`
+	} `json:"result"`
+	Subtraces    int    `json:"subtraces"`
+	TraceAddress []int  `json:"traceAddress"`
+	Type         string `json:"type"`
+}
+
+func TestFlatCallTracer(t *testing.T) {
+	var testData callTest
+	if blob, err := os.ReadFile("testdata/calls.json"); err != nil {
+		t.Fatalf("failed to read testcase: %v", err)
+	} else if err := json.Unmarshal(blob, &testData); err != nil {
+		t.Fatalf("failed to parse testcase: %v", err)
+	}
+
+	var flat []flatCallFrame
+	if err := json.Unmarshal(RunTracerTest(t, &testData.traceTest, "flatCallTracer"), &flat); err != nil {
+		t.Fatal(err)
+	}
+
+	// the nested frames are flattened in depth-first order
+	var walk func(frame callFrame, traceAddress []int)
+	walk = func(frame callFrame, traceAddress []int) {
+		if !assert.NotEmpty(t, flat) {
+			return
+		}
+		got := flat[0]
+		flat = flat[1:]
+
+		assert.Equal(t, traceAddress, got.TraceAddress)
+		assert.Equal(t, len(frame.Calls), got.Subtraces)
+		assert.Equal(t, frame.From, got.Action.From)
+		assert.Equal(t, frame.Value, got.Action.Value)
+		assert.Equal(t, frame.Error, got.Error)
+		switch frame.Type {
+		case "CREATE", "CREATE2":
+			assert.Equal(t, "create", got.Type)
+			assert.Equal(t, frame.Input, got.Action.Init)
+			if frame.Error == "" {
+				assert.Equal(t, frame.To, *got.Result.Address)
+			}
+		default:
+			assert.Equal(t, "call", got.Type)
+			assert.Equal(t, strings.ToLower(frame.Type), got.Action.CallType)
+			assert.Equal(t, frame.To, *got.Action.To)
+			assert.Equal(t, frame.Input, got.Action.Input)
+		}
+		if frame.Error == "" {
+			assert.Equal(t, frame.GasUsed, got.Result.GasUsed)
+		} else {
+			assert.Nil(t, got.Result)
+		}
+		for i, call := range frame.Calls {
+			walk(call, append(append([]int{}, traceAddress...), i))
+		}
+	}
+	walk(testData.Calls, []int{})
+	assert.Empty(t, flat)
+
+	// calls into the native methods of builtin contracts
+	holder := thor.MustParseAddress("0x000000000000000000000000000000000000feed")
+	energy := builtin.Energy.Address
+	method, _ := builtin.Energy.ABI.MethodByName("balanceOf")
+	input, err := method.EncodeInput(holder)
+	assert.Nil(t, err)
+	testData.Clause = clause{To: &energy, Value: (*math.HexOrDecimal256)(big.NewInt(0)), Data: input}
+	testData.State = nil
+
+	flat = nil
+	if err := json.Unmarshal(RunTracerTest(t, &testData.traceTest, "flatCallTracer"), &flat); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(flat))
+	assert.Equal(t, 1, flat[0].Subtraces)
+	assert.Equal(t, "", flat[0].Action.NativeMethod)
+	assert.Equal(t, []int{0}, flat[1].TraceAddress)
+	assert.Equal(t, energy, flat[1].Action.From)
+	assert.Equal(t, energy, *flat[1].Action.To)
+	assert.Equal(t, "native_get", flat[1].Action.NativeMethod)
+
+	// the native method is also in the call tracer result
+	var nested struct {
+		Calls []struct {
+			NativeMethod string `json:"nativeMethod"`
+		} `json:"calls"`
+	}
+	if err := json.Unmarshal(RunTracerTest(t, &testData.traceTest, "callTracer"), &nested); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(nested.Calls))
+	assert.Equal(t, "native_get", nested.Calls[0].NativeMethod)
+}
+
+func TestMuxTracer(t *testing.T) {
+	var testData callTest
+	if blob, err := os.ReadFile("testdata/calls.json"); err != nil {
+		t.Fatalf("failed to read testcase: %v", err)
+	} else if err := json.Unmarshal(blob, &testData); err != nil {
+		t.Fatalf("failed to parse testcase: %v", err)
+	}
+
+	names := []string{"callTracer", "4byteTracer", "prestateTracer"}
+	testData.Config = json.RawMessage(`{"callTracer": {"withLog": true}, "4byteTracer": null, "prestateTracer": {}}`)
+	var results map[string]json.RawMessage
+	if err := json.Unmarshal(RunTracerTest(t, &testData.traceTest, "muxTracer"), &results); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(names), len(results))
+
+	// the same as running the tracers one by one
+	var configs map[string]json.RawMessage
+	if err := json.Unmarshal(testData.Config, &configs); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		testData.Config = configs[name]
+		assert.JSONEq(t, string(RunTracerTest(t, &testData.traceTest, name)), string(results[name]), name)
+	}
+
+	_, err := tracers.DefaultDirectory.New("muxTracer", nil, false)
+	assert.EqualError(t, err, "no tracer specified")
+	_, err = tracers.DefaultDirectory.New("muxTracer", json.RawMessage(`{"non-existent": {}}`), false)
+	assert.EqualError(t, err, "create tracer non-existent: unsupported tracer")
+}
+
+func TestBalanceChangeTracer(t *testing.T) {
+	var (
+		origin    = thor.MustParseAddress("0x000000000000000000000000000000000000feed")
+		contract  = thor.MustParseAddress("0x00000000000000000000000000000000deadbeef")
+		recipient = thor.MustParseAddress("0x00000000000000000000000000000000000000ff")
+		reverter  = thor.MustParseAddress("0x00000000000000000000000000000000000000fe")
+		energy    = builtin.Energy.Address
+		balance   = (*math.HexOrDecimal256)(big.NewInt(1000))
+	)
+	type change struct {
+		Address  thor.Address `json:"address"`
+		Token    string       `json:"token"`
+		Sent     string       `json:"sent"`
+		Received string       `json:"received"`
+		Change   string       `json:"change"` // signed hex
+	}
+	hexBig := func(x int64) *math.HexOrDecimal256 { return (*math.HexOrDecimal256)(big.NewInt(x)) }
+
+	// the contract sends 1 wei to the recipient, and 2 wei in a reverted call
+	data := traceTest{
+		Clause: clause{To: &contract, Value: hexBig(10)},
+		Context: context{
+			TxOrigin: origin,
+			Gas:      math.HexOrDecimal64(100000),
+		},
+		State: map[common.Address]account{
+			common.Address(origin): {Balance: balance, Energy: balance},
+			common.Address(contract): {Balance: hexBig(0), Energy: hexBig(0), Code: []byte{
+				byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1),
+				byte(vm.PUSH1), 0x1, byte(vm.PUSH1), 0xff, byte(vm.GAS), byte(vm.CALL),
+				byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1),
+				byte(vm.PUSH1), 0x2, byte(vm.PUSH1), 0xfe, byte(vm.GAS), byte(vm.CALL),
+			}},
+			common.Address(reverter): {Balance: hexBig(0), Energy: hexBig(0), Code: []byte{
+				byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.REVERT),
+			}},
+		},
+	}
+	var got []change
+	if err := json.Unmarshal(RunTracerTest(t, &data, "balanceChangeTracer"), &got); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []change{
+		{Address: recipient, Token: "VET", Sent: "0x0", Received: "0x1", Change: "0x1"},
+		{Address: origin, Token: "VET", Sent: "0xa", Received: "0x0", Change: "-0xa"},
+		{Address: contract, Token: "VET", Sent: "0x1", Received: "0xa", Change: "0x9"},
+	}, got)
+
+	// VTHO transfer decoded from the Transfer event of the energy contract
+	method, _ := builtin.Energy.ABI.MethodByName("transfer")
+	input, err := method.EncodeInput(recipient, big.NewInt(100))
+	assert.Nil(t, err)
+	data.Clause = clause{To: &energy, Value: hexBig(0), Data: input}
+	got = nil
+	if err := json.Unmarshal(RunTracerTest(t, &data, "balanceChangeTracer"), &got); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []change{
+		{Address: recipient, Token: "VTHO", Sent: "0x0", Received: "0x64", Change: "0x64"},
+		{Address: origin, Token: "VTHO", Sent: "0x64", Received: "0x0", Change: "-0x64"},
+	}, got)
+
+	// nothing changes if the transfer fails
+	input, err = method.EncodeInput(recipient, big.NewInt(2000))
+	assert.Nil(t, err)
+	data.Clause.Data = input
+	got = nil
+	if err := json.Unmarshal(RunTracerTest(t, &data, "balanceChangeTracer"), &got); err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, got)
+
+	// the result of the transaction merges clauses, and adds the gas payment
+	tracer, err := tracers.DefaultDirectory.New("balanceChange", nil, false)
+	assert.Nil(t, err)
+	txTracer, ok := tracer.(tracers.TxTracer)
+	assert.True(t, ok)
+
+	beneficiary := thor.MustParseAddress("0x00000000000000000000000000000000000000bb")
+	clauses := []json.RawMessage{
+		json.RawMessage(`[{"address":"0x000000000000000000000000000000000000feed","token":"VTHO","sent":"0x64","received":"0x0","change":"-0x64"}]`),
+		json.RawMessage(`[{"address":"0x000000000000000000000000000000000000feed","token":"VTHO","sent":"0x10","received":"0x0","change":"-0x10"}]`),
+	}
+	receipt := &tx.Receipt{GasPayer: origin, Paid: big.NewInt(30), Reward: big.NewInt(9)}
+	res, err := txTracer.TxResult(clauses, receipt, beneficiary)
+	assert.Nil(t, err)
+	got = nil
+	assert.Nil(t, json.Unmarshal(res, &got))
+	assert.Equal(t, []change{
+		{Address: beneficiary, Token: "VTHO", Sent: "0x0", Received: "0x9", Change: "0x9"},
+		{Address: origin, Token: "VTHO", Sent: "0x92", Received: "0x0", Change: "-0x92"},
+	}, got)
+
+	// clauses of a reverted transaction change nothing but the gas payment
+	receipt.Reverted = true
+	res, err = txTracer.TxResult(clauses, receipt, beneficiary)
+	assert.Nil(t, err)
+	got = nil
+	assert.Nil(t, json.Unmarshal(res, &got))
+	assert.Equal(t, []change{
+		{Address: beneficiary, Token: "VTHO", Sent: "0x0", Received: "0x9", Change: "0x9"},
+		{Address: origin, Token: "VTHO", Sent: "0x1e", Received: "0x0", Change: "-0x1e"},
+	}, got)
 }
 
 func TestCallTracers(t *testing.T) {
@@ -300,6 +647,27 @@ func TestInternals(t *testing.T) {
 			tracer: mkTracer("callTracer", nil),
 			want:   `{"from":"0x000000000000000000000000000000000000feed","gas":"0x13880","gasUsed":"0x54d8","to":"0x00000000000000000000000000000000deadbeef","input":"0x","calls":[{"from":"0x00000000000000000000000000000000deadbeef","gas":"0xe01a","gasUsed":"0x0","to":"0x00000000000000000000000000000000000000ff","input":"0x","value":"0x0","type":"CALL"}],"value":"0x0","type":"CALL"}`,
 		},
+		{
+			// Calls to precompiled contracts are excluded by the flat call tracer unless configured
+			name: "Flat-tracer - precompile excluded",
+			code: []byte{
+				byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), // in and outs zero
+				byte(vm.DUP1), byte(vm.PUSH1), 0x02, byte(vm.GAS), // value=0,address=0x02, gas=GAS
+				byte(vm.CALL),
+			},
+			tracer: mkTracer("flatCallTracer", nil),
+			want:   `[{"action":{"callType":"call","from":"0x000000000000000000000000000000000000feed","gas":"0x13880","input":"0x","to":"0x00000000000000000000000000000000deadbeef","value":"0x0"},"txIndex":0,"clauseIndex":0,"result":{"gasUsed":"0x5514","output":"0x"},"subtraces":0,"traceAddress":[],"type":"call"}]`,
+		},
+		{
+			name: "Flat-tracer - precompile included",
+			code: []byte{
+				byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), // in and outs zero
+				byte(vm.DUP1), byte(vm.PUSH1), 0x02, byte(vm.GAS), // value=0,address=0x02, gas=GAS
+				byte(vm.CALL),
+			},
+			tracer: mkTracer("flatCallTracer", json.RawMessage(`{ "includePrecompiles": true }`)),
+			want:   `[{"action":{"callType":"call","from":"0x000000000000000000000000000000000000feed","gas":"0x13880","input":"0x","to":"0x00000000000000000000000000000000deadbeef","value":"0x0"},"txIndex":0,"clauseIndex":0,"result":{"gasUsed":"0x5514","output":"0x"},"subtraces":1,"traceAddress":[],"type":"call"},{"action":{"callType":"call","from":"0x00000000000000000000000000000000deadbeef","gas":"0xe01a","input":"0x","to":"0x0000000000000000000000000000000000000002","value":"0x0"},"txIndex":0,"clauseIndex":0,"result":{"gasUsed":"0x3c","output":"0xe3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},"subtraces":0,"traceAddress":[0],"type":"call"}]`,
+		},
 		{
 			name:   "Stack depletion in LOG0",
 			code:   []byte{byte(vm.LOG3)},
@@ -41,3 +41,10 @@ type Logger interface {
 	CaptureState(pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, rData []byte, depth int, err error)
 	CaptureFault(pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, depth int, err error)
 }
+
+// NativeCallLogger is an optional interface of Logger to capture calls into builtin native contracts.
+// Such calls are intercepted and run natively in the current call frame instead of by the interpreter,
+// so CaptureNativeCall is called between CaptureEnter and CaptureExit of the frame without any CaptureState.
+type NativeCallLogger interface {
+	CaptureNativeCall(contract common.Address, method string)
+}